@@ -1,16 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/dennisloska/noji/internal/commands"
+	"github.com/dennisloska/noji/internal/secrets"
 )
 
 func main() {
 	rootCmd := commands.BuildRoot()
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		var exit *commands.ExitError
+		if errors.As(err, &exit) {
+			if exit.Err != nil {
+				fmt.Fprintln(os.Stderr, secrets.Redact(exit.Err.Error()))
+			}
+			os.Exit(exit.Code)
+		}
+		// errors may quote gh or opencode output; never print tokens
+		fmt.Fprintln(os.Stderr, secrets.Redact(err.Error()))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,205 @@
+// Package cilog extracts the failure-relevant parts of CI job logs so they
+// fit in a model prompt. It works on plain text and needs no network access.
+package cilog
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Options tunes the extraction.
+type Options struct {
+	Before   int // lines kept before a marker
+	After    int // lines kept after a marker
+	MaxLines int // cap on the total number of extracted lines (0 = no cap)
+	Tail     int // lines taken from the end when no marker matches
+}
+
+// DefaultOptions suits typical GitHub Actions logs.
+var DefaultOptions = Options{Before: 5, After: 25, MaxLines: 400, Tail: 80}
+
+// Region is a contiguous block of log lines around one or more failure markers.
+type Region struct {
+	Start  int      // 1-based line number in the cleaned log
+	Lines  []string // cleaned lines
+	Reason string   // marker that selected the region, "tail" when none matched
+}
+
+// marker is a failure heuristic.
+type marker struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var markers = []marker{
+	{"go test failure", regexp.MustCompile(`^\s*--- FAIL: |^FAIL\s|^=== FAIL: `)},
+	{"panic", regexp.MustCompile(`^panic: |^fatal error: |^goroutine \d+ \[running\]`)},
+	{"compiler error", regexp.MustCompile(`^[\w./-]+\.\w+:\d+:\d+: |error\[E\d+\]|(?:: | - )error TS\d+:|^error: `)},
+	{"actions error", regexp.MustCompile(`^##\[error\]|^Error: `)},
+	{"python traceback", regexp.MustCompile(`^Traceback \(most recent call last\)|^E\s+\w*(Error|Exception)`)},
+	{"test failure", regexp.MustCompile(`(?i)^\s*(FAILED|FAILURES?|✕|✗|×)\b|AssertionError|\bexpected\b.*\bgot\b`)},
+	{"npm error", regexp.MustCompile(`^npm ERR!`)},
+	{"lint error", regexp.MustCompile(`^\s+\d+:\d+\s+error\s|^✖ \d+ problems? \(`)},
+	{"exception", regexp.MustCompile(`^\s*(Exception|Caused by:|\w+(\.\w+)+Exception)`)},
+}
+
+var (
+	timestampRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z ?`)
+	ansiRe      = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+)
+
+// Clean strips GitHub Actions timestamps, ANSI escapes and carriage returns.
+func Clean(log string) []string {
+	lines := strings.Split(strings.ReplaceAll(log, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		l = timestampRe.ReplaceAllString(l, "")
+		l = ansiRe.ReplaceAllString(l, "")
+		if j := strings.LastIndex(l, "\r"); j >= 0 {
+			l = l[j+1:]
+		}
+		lines[i] = l
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Extract returns the regions of log around failure markers, merged when
+// they overlap. Without any marker the last opts.Tail lines are returned.
+func Extract(log string, opts Options) []Region {
+	lines := Clean(log)
+	if len(lines) == 0 {
+		return nil
+	}
+	var regions []Region
+	end := -1 // exclusive end of the last region
+	for i, l := range lines {
+		reason := match(l)
+		if reason == "" {
+			continue
+		}
+		from, to := max(0, i-opts.Before), min(len(lines), i+opts.After+1)
+		if n := len(regions); n > 0 && from <= end {
+			r := &regions[n-1]
+			if to > end {
+				r.Lines = append(r.Lines, lines[end:to]...)
+				end = to
+			}
+			if !strings.Contains(r.Reason, reason) {
+				r.Reason += ", " + reason
+			}
+			continue
+		}
+		regions = append(regions, Region{Start: from + 1, Lines: append([]string(nil), lines[from:to]...), Reason: reason})
+		end = to
+	}
+	if len(regions) == 0 {
+		from := max(0, len(lines)-opts.Tail)
+		regions = []Region{{Start: from + 1, Lines: lines[from:], Reason: "tail"}}
+	}
+	return capLines(regions, opts.MaxLines)
+}
+
+func match(line string) string {
+	for _, m := range markers {
+		if m.re.MatchString(line) {
+			return m.name
+		}
+	}
+	return ""
+}
+
+// capLines keeps whole regions from the start and truncates the one that
+// crosses max; the first failure is usually the root cause.
+func capLines(regions []Region, max int) []Region {
+	if max <= 0 {
+		return regions
+	}
+	total := 0
+	for i, r := range regions {
+		if total+len(r.Lines) > max {
+			regions[i].Lines = r.Lines[:max-total]
+			if len(regions[i].Lines) == 0 {
+				return regions[:i]
+			}
+			return regions[:i+1]
+		}
+		total += len(r.Lines)
+	}
+	return regions
+}
+
+// Format renders regions as text with line-number headers.
+func Format(regions []Region) string {
+	var b strings.Builder
+	for i, r := range regions {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "--- lines %d-%d (%s) ---\n", r.Start, r.Start+len(r.Lines)-1, r.Reason)
+		for _, l := range r.Lines {
+			b.WriteString(l + "\n")
+		}
+	}
+	return b.String()
+}
+
+const sourceExt = `\.(?:go|py|js|jsx|ts|tsx|rs|java|kt|rb|php|cs|c|cc|cpp|h|hpp|swift|scala|sh|ya?ml|json|toml)`
+
+var (
+	// fileRefRe matches path:line and tsc's path(line,col).
+	fileRefRe = regexp.MustCompile(`(?:^|[\s(\"'])((?:\.{0,2}/)?[\w.-]+(?:/[\w.-]+)*` + sourceExt + `)(?::(\d+)|\((\d+),\d+\))`)
+	// ESLint's stylish format prints the path on its own line and the
+	// line:col of each problem indented below it.
+	filePathRe    = regexp.MustCompile(`^((?:\.{0,2}/)?[\w.-]+(?:/[\w.-]+)*` + sourceExt + `)$`)
+	lintProblemRe = regexp.MustCompile(`^\s+(\d+):\d+\s+(?:error|warning)\s`)
+)
+
+// FileRef is a file:line reference found in a log.
+type FileRef struct {
+	Path string
+	Line int
+}
+
+// FileRefs returns the distinct file:line references in regions, in order.
+func FileRefs(regions []Region) []FileRef {
+	var refs []FileRef
+	seen := map[FileRef]bool{}
+	add := func(path, line string) {
+		var n int
+		fmt.Sscanf(line, "%d", &n)
+		ref := FileRef{Path: strings.TrimPrefix(path, "./"), Line: n}
+		// a path climbing out with .. cannot be a file of the repository
+		if slices.Contains(strings.Split(ref.Path, "/"), "..") {
+			return
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, r := range regions {
+		current := "" // last path printed on its own line
+		for _, l := range r.Lines {
+			if strings.TrimSpace(l) == "" {
+				current = ""
+				continue
+			}
+			if m := filePathRe.FindStringSubmatch(strings.TrimSpace(l)); m != nil {
+				current = m[1]
+				continue
+			}
+			if m := lintProblemRe.FindStringSubmatch(l); m != nil && current != "" {
+				add(current, m[1])
+				continue
+			}
+			for _, m := range fileRefRe.FindAllStringSubmatch(l, -1) {
+				add(m[1], m[2]+m[3])
+			}
+		}
+	}
+	return refs
+}
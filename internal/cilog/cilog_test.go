@@ -0,0 +1,107 @@
+package cilog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		reasons []string // Reason of each region
+		first   string   // first failure line the regions must contain
+		refs    []FileRef
+	}{
+		{
+			fixture: "gotest.log",
+			reasons: []string{"go test failure, actions error"},
+			first:   "--- FAIL: TestParseConfig (0.00s)",
+			refs:    []FileRef{{Path: "config_test.go", Line: 42}},
+		},
+		{
+			fixture: "gotestsum.log",
+			reasons: []string{"go test failure"},
+			first:   "=== FAIL: internal/config TestParseConfig (0.00s)",
+			refs:    []FileRef{{Path: "config_test.go", Line: 42}},
+		},
+		{
+			fixture: "eslint.log",
+			reasons: []string{"lint error, actions error"},
+			first:   "  12:7   error    'retries' is assigned a value but never used  @typescript-eslint/no-unused-vars",
+			refs: []FileRef{
+				{Path: "/home/runner/work/app/app/src/api/client.ts", Line: 12},
+				{Path: "/home/runner/work/app/app/src/api/client.ts", Line: 40},
+				{Path: "/home/runner/work/app/app/src/index.tsx", Line: 3},
+			},
+		},
+		{
+			fixture: "tsc.log",
+			reasons: []string{"compiler error, actions error"},
+			first:   "src/api/client.ts(27,14): error TS2322: Type 'string' is not assignable to type 'number'.",
+			refs: []FileRef{
+				{Path: "src/api/client.ts", Line: 27},
+				{Path: "src/models/user.ts", Line: 8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			regions := Extract(string(b), DefaultOptions)
+			var reasons []string
+			for _, r := range regions {
+				reasons = append(reasons, r.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("reasons = %q, want %q", reasons, tt.reasons)
+			}
+			if text := Format(regions); !strings.Contains(text, tt.first+"\n") {
+				t.Errorf("extracted text does not contain %q:\n%s", tt.first, text)
+			}
+			if refs := FileRefs(regions); !reflect.DeepEqual(refs, tt.refs) {
+				t.Errorf("refs = %+v, want %+v", refs, tt.refs)
+			}
+		})
+	}
+}
+
+func TestExtractTail(t *testing.T) {
+	var lines []string
+	for i := range 100 {
+		lines = append(lines, strings.Repeat("x", i%7))
+	}
+	regions := Extract(strings.Join(lines, "\n"), Options{Tail: 10})
+	if len(regions) != 1 || regions[0].Reason != "tail" || len(regions[0].Lines) != 10 || regions[0].Start != 91 {
+		t.Fatalf("regions = %+v, want the last 10 lines", regions)
+	}
+}
+
+func TestExtractCap(t *testing.T) {
+	log := "--- FAIL: TestA\n" + strings.Repeat("a\n", 50) + "--- FAIL: TestB\n" + strings.Repeat("b\n", 50)
+	regions := Extract(log, Options{After: 30, MaxLines: 40})
+	total := 0
+	for _, r := range regions {
+		total += len(r.Lines)
+	}
+	if total != 40 || regions[0].Lines[0] != "--- FAIL: TestA" {
+		t.Fatalf("got %d lines starting with %q, want 40 starting with the first failure", total, regions[0].Lines[0])
+	}
+}
+
+func TestFileRefsSkipParentDirs(t *testing.T) {
+	regions := []Region{{Lines: []string{
+		"../../.config/gh/hosts.yml:1",
+		"/home/runner/work/r/r/../../../../etc/passwd.go:3: boom",
+		"pkg/x.go:12: real",
+	}}}
+	refs := FileRefs(regions)
+	if len(refs) != 1 || refs[0] != (FileRef{Path: "pkg/x.go", Line: 12}) {
+		t.Errorf("FileRefs = %+v, want only pkg/x.go:12", refs)
+	}
+}
@@ -0,0 +1,305 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Store API tokens for GitHub, Jira and Linear",
+		Long: "Tokens are kept in the OS keyring. Where no keyring is available they go to an\n" +
+			"encrypted file next to config.yaml, protected by a passphrase (asked for, or\n" +
+			secrets.PassphraseEnv + "). NOJI_<SERVICE>_TOKEN, e.g. NOJI_JIRA_TOKEN, overrides a stored\n" +
+			"token. Set auth.backend to keyring or file to use only one of them.",
+	}
+	cmd.AddCommand(newAuthLoginCmd())
+	cmd.AddCommand(newAuthLogoutCmd())
+	cmd.AddCommand(newAuthStatusCmd())
+	return cmd
+}
+
+// processStore is the store of this process, so the key of an encrypted
+// file is derived once.
+var processStore *secrets.Store
+
+// secretStore returns the token store configured by auth.backend.
+func secretStore(cmd *cobra.Command) (*secrets.Store, error) {
+	if processStore != nil {
+		return processStore, nil
+	}
+	backend, err := config.GetAuthBackend()
+	if err != nil {
+		return nil, err
+	}
+	file, err := config.CredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	p := printer(cmd)
+	processStore = &secrets.Store{
+		Backend: backend,
+		File:    file,
+		Warnf:   p.Warnf,
+		Passphrase: func(confirm bool) (string, error) {
+			pass, err := readHidden(cmd, "Passphrase for "+file+": ")
+			if err != nil || !confirm {
+				return pass, err
+			}
+			again, err := readHidden(cmd, "Repeat passphrase: ")
+			if err != nil {
+				return "", err
+			}
+			if again != pass {
+				return "", errors.New("passphrases do not match")
+			}
+			return pass, nil
+		},
+	}
+	return processStore, nil
+}
+
+// tokensAnnotation marks commands that run gh, opencode or Jira; only they
+// get the stored tokens exported (see exportTokens).
+const tokensAnnotation = "noji:tokens"
+
+// usesTokens marks cmd and its subcommands with tokensAnnotation.
+func usesTokens(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[tokensAnnotation] = "true"
+	return cmd
+}
+
+func needsTokens(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[tokensAnnotation] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// exportTokens passes the stored tokens on to gh and opencode. Failures are
+// only warnings: a command may not need every token.
+func exportTokens(cmd *cobra.Command) {
+	p := printer(cmd)
+	store, err := secretStore(cmd)
+	if err == nil {
+		err = store.Export()
+	}
+	if err != nil {
+		p.Warnf("%s\n", secrets.Redact(err.Error()))
+	}
+}
+
+// readHidden prompts on stderr and reads a line from the terminal without
+// echoing it.
+func readHidden(cmd *cobra.Command, prompt string) (string, error) {
+	f, ok := cmd.InOrStdin().(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return "", fmt.Errorf("cannot ask for %q without a terminal", strings.TrimSuffix(prompt, ": "))
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), prompt)
+	b, err := term.ReadPassword(int(f.Fd()))
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func completeServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(secrets.Services))
+	for _, s := range secrets.Services {
+		names = append(names, s.Name+"\t"+s.Description)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newAuthLoginCmd() *cobra.Command {
+	var withToken bool
+
+	cmd := &cobra.Command{
+		Use:               "login <service>",
+		Short:             "Store the API token of a service",
+		Example:           "  noji auth login jira\n  echo \"$TOKEN\" | noji auth login github --with-token",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeServices,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			svc, err := secrets.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+			var token string
+			if withToken {
+				line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && !errors.Is(err, io.EOF) {
+					return err
+				}
+				token = strings.TrimSpace(line)
+			} else {
+				if !stdinIsTerminal(cmd) {
+					return errors.New("no terminal to ask for the token; pipe it with --with-token")
+				}
+				if token, err = readHidden(cmd, svc.Description+": "); err != nil {
+					return err
+				}
+			}
+			if token == "" {
+				return errors.New("no token given")
+			}
+			store, err := secretStore(cmd)
+			if err != nil {
+				return err
+			}
+			where, err := store.Set(svc.Name, token)
+			if err != nil {
+				return err
+			}
+			p.Successf("Stored the %s token in the %s.\n", svc.Name, storeLabel(where, store.File))
+			if os.Getenv(svc.Env) != "" {
+				p.Warnf("%s is set and takes precedence over the stored token.\n", svc.Env)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&withToken, "with-token", false, "Read the token from standard input")
+	return cmd
+}
+
+func storeLabel(source, file string) string {
+	switch source {
+	case secrets.SourceKeyring:
+		return "OS keyring"
+	case secrets.SourceFile:
+		return "encrypted file " + file
+	}
+	return source
+}
+
+func newAuthLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "logout <service>",
+		Short:             "Remove the stored API token of a service",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeServices,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			svc, err := secrets.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+			store, err := secretStore(cmd)
+			if err != nil {
+				return err
+			}
+			removed, err := store.Delete(svc.Name)
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				p.Infof("No stored %s token.\n", svc.Name)
+			}
+			for _, where := range removed {
+				p.Successf("Removed the %s token from the %s.\n", svc.Name, storeLabel(where, store.File))
+			}
+			if os.Getenv(svc.Env) != "" {
+				p.Warnf("%s is still set in the environment.\n", svc.Env)
+			}
+			return nil
+		},
+	}
+}
+
+// authRow is one service in `auth status`.
+type authRow struct {
+	Service string `json:"service"`
+	// Active is where the token in use comes from; empty when there is none.
+	Active       string `json:"active"`
+	Variable     string `json:"variable"`
+	Env          bool   `json:"env"`
+	Keyring      bool   `json:"keyring"`
+	File         bool   `json:"file"`
+	KeyringError string `json:"keyring_error,omitempty"`
+}
+
+func newAuthStatusCmd() *cobra.Command {
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show which services have a token and where it is stored",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			store, err := secretStore(cmd)
+			if err != nil {
+				return err
+			}
+			statuses, err := store.Status()
+			if err != nil {
+				return err
+			}
+			rows := make([]authRow, 0, len(statuses))
+			for i, st := range statuses {
+				rows = append(rows, authRow{Service: st.Service, Active: st.Active, Variable: secrets.Services[i].Env,
+					Env: st.Env, Keyring: st.Keyring, File: st.File, KeyringError: st.KeyringError})
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, rows)
+			}
+			width := 0
+			for _, r := range rows {
+				width = max(width, len(r.Service))
+			}
+			keyringErr := ""
+			for _, r := range rows {
+				if r.KeyringError != "" {
+					keyringErr = r.KeyringError
+				}
+				var where string
+				switch r.Active {
+				case secrets.SourceEnv:
+					where = p.Good("env " + r.Variable)
+				case secrets.SourceKeyring:
+					where = p.Good("OS keyring")
+				case secrets.SourceFile:
+					where = p.Good("encrypted file")
+				default:
+					where = p.Dim("not logged in")
+				}
+				// a token that the active one shadows
+				if r.Active == secrets.SourceEnv && (r.Keyring || r.File) {
+					where += p.Dim(" (also stored)")
+				}
+				p.Printf("%-*s  %s\n", width, r.Service, where)
+			}
+			if keyringErr != "" {
+				p.Warnf("OS keyring unavailable: %s\n", keyringErr)
+			}
+			return nil
+		},
+	}
+	outFlags = addOutputFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/diffpack"
+	"github.com/dennisloska/noji/internal/titlelint"
+	"github.com/spf13/cobra"
+)
+
+// hookMarker identifies the prepare-commit-msg hook written by noji, so it
+// is never overwritten or removed when it is someone else's.
+const hookMarker = "# installed by noji commit hook install"
+
+const prepareCommitMsgHook = `#!/bin/sh
+` + hookMarker + `
+# Proposes a commit message for plain ` + "`git commit`" + ` (no -m, -F, merge or amend).
+[ -z "$2" ] || exit 0
+command -v noji >/dev/null 2>&1 || exit 0
+noji commit --hook "$1" || true
+`
+
+func newCommitCmd() *cobra.Command {
+	var noEdit bool
+	var dryRun bool
+	var hookFile string
+
+	cmd := &cobra.Command{
+		Use:   "commit [-- <git commit args>]",
+		Short: "Commit the staged changes with a message proposed by opencode",
+		Long: "Reads `git diff --staged`, lets the model propose a message following the\n" +
+			"title rules (type(TICKET): subject, the ticket taken from the branch), opens it\n" +
+			"in your editor and runs `git commit -F`. Arguments after -- go to git commit.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if hookFile != "" {
+				return runCommitHook(p, hookFile)
+			}
+			msg, err := proposeCommitMessage(p)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				p.Printf("%s\n", msg)
+				return nil
+			}
+			if !noEdit {
+				if msg, err = editText(msg + "\n"); err != nil {
+					return err
+				}
+				if msg = stripCommentLines(msg); msg == "" {
+					return errors.New("aborting commit due to empty commit message")
+				}
+			}
+			tmp, err := createTempFile(msg + "\n")
+			if err != nil {
+				return fmt.Errorf("create temp file: %w", err)
+			}
+			defer os.Remove(tmp)
+			c := exec.Command("git", append([]string{"commit", "-F", tmp}, args...)...)
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, cmd.OutOrStdout(), cmd.ErrOrStderr()
+			if err := c.Run(); err != nil {
+				return fmt.Errorf("git commit failed: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&noEdit, "no-edit", false, "Commit the proposed message without opening the editor")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the proposed message and do not commit")
+	cmd.Flags().StringVar(&hookFile, "hook", "", "Write the proposed message into this file (used by the prepare-commit-msg hook)")
+	_ = cmd.Flags().MarkHidden("hook")
+	cmd.AddCommand(newCommitHookCmd())
+	return cmd
+}
+
+func newCommitHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Manage the prepare-commit-msg hook that proposes messages inside git commit",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "install",
+		Short: "Install the prepare-commit-msg hook in the current repository",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := hookPath()
+			if err != nil {
+				return err
+			}
+			if b, err := os.ReadFile(path); err == nil && !strings.Contains(string(b), hookMarker) {
+				return fmt.Errorf("%s exists and was not installed by noji; remove it or call `noji commit --hook \"$1\"` from it", path)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(prepareCommitMsgHook), 0o755); err != nil {
+				return fmt.Errorf("write hook: %w", err)
+			}
+			printer(cmd).Successf("Installed %s\n", path)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the prepare-commit-msg hook installed by noji",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := hookPath()
+			if err != nil {
+				return err
+			}
+			b, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				printer(cmd).Infof("No prepare-commit-msg hook installed.\n")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(string(b), hookMarker) {
+				return fmt.Errorf("%s was not installed by noji; leaving it alone", path)
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			printer(cmd).Successf("Removed %s\n", path)
+			return nil
+		},
+	})
+	return cmd
+}
+
+// hookPath returns the prepare-commit-msg hook of the current repository,
+// honouring core.hooksPath.
+func hookPath() (string, error) {
+	dir, err := gitOutput("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		if dir, err = filepath.Abs(dir); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, "prepare-commit-msg"), nil
+}
+
+// runCommitHook puts the proposed message above the comments git already
+// wrote to file. Failures only warn: the hook must never block a commit.
+func runCommitHook(p *output.Printer, file string) error {
+	existing, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	// with `git commit -v` the staged diff follows the scissors line
+	if stripCommentLines(cutScissors(string(existing))) != "" {
+		return nil
+	}
+	msg, err := proposeCommitMessage(p)
+	if err != nil {
+		p.Warnf("noji: no commit message proposed: %v\n", err)
+		return nil
+	}
+	return os.WriteFile(file, []byte(msg+"\n"+string(existing)), 0o644)
+}
+
+// proposeCommitMessage asks the commit model for a message for the staged
+// diff and repairs its subject to follow the title rules.
+func proposeCommitMessage(p *output.Printer) (string, error) {
+	out, err := exec.Command("git", "diff", "--staged", "--no-color", "--no-ext-diff").Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff --staged failed: %w", err)
+	}
+	runner, err := newModelRunner(config.TaskCommit, p.Warnf)
+	if err != nil {
+		return "", err
+	}
+	var files []diffpack.File
+	for _, f := range diffpack.Parse(string(out)) {
+		if runner.excluded(f.Path) {
+			p.Warnf("Leaving %s out of the prompt (redact.exclude_paths).\n", f.Path)
+			continue
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return "", errors.New("nothing staged to commit")
+	}
+	opts, err := config.GetDiffOptions()
+	if err != nil {
+		return "", err
+	}
+	packed := diffpack.Pack(files, opts)
+	if report := packed.Report(); report != "" {
+		p.Infof("Diff packed: %s\n", report)
+	}
+	settings, err := config.GetTitleSettings()
+	if err != nil {
+		return "", err
+	}
+	branch, _ := getCurrentBranch()
+	ticket := settings.Rules.TicketFrom(branch)
+	template, err := readPrompt("commit.txt")
+	if err != nil {
+		return "", err
+	}
+	prompt := fmt.Sprintf("%s\n\nBranch: %s\nConvention: %s\n\nStaged diff:\n%s",
+		template, branch, commitConvention(settings.Rules, ticket), packed.Text)
+
+	p.Infof("Proposing a commit message with model %s...\n", runner.Model())
+	answer, err := runner.capture(prompt)
+	if err != nil {
+		return "", err
+	}
+	msg := stripCodeFence(strings.TrimSpace(answer))
+	if msg == "" {
+		return "", errors.New("the model returned an empty message")
+	}
+	subject, body, _ := strings.Cut(msg, "\n")
+	if problems := settings.Rules.Check(subject, ticket); len(problems) > 0 {
+		if fixed := settings.Rules.Fix(subject, ticket); fixed != "" {
+			subject = fixed
+		} else {
+			p.Warnf("Proposed subject does not follow the title rules: %s\n", strings.Join(problems, "; "))
+		}
+	}
+	if body = strings.TrimSpace(body); body != "" {
+		return subject + "\n\n" + body, nil
+	}
+	return subject, nil
+}
+
+// commitConvention describes the title rules to the model.
+func commitConvention(r titlelint.Rules, ticket string) string {
+	if r.Pattern != "" {
+		return fmt.Sprintf("the subject must match the regular expression %s", r.Pattern)
+	}
+	var b strings.Builder
+	b.WriteString("conventional commits, type(scope): subject")
+	if len(r.Types) > 0 {
+		fmt.Fprintf(&b, "; type is one of %s", strings.Join(r.Types, ", "))
+	}
+	switch r.Scope {
+	case titlelint.ScopeTicket:
+		if ticket != "" {
+			fmt.Fprintf(&b, "; the scope is the ticket key %s", ticket)
+		} else {
+			b.WriteString("; the scope is the ticket key (none found in the branch name, leave it out)")
+		}
+	case titlelint.ScopeRequired:
+		b.WriteString("; a scope naming the affected area is required")
+	case titlelint.ScopeNone:
+		b.WriteString("; no scope")
+	}
+	if r.MaxLength > 0 {
+		fmt.Fprintf(&b, "; the subject line has at most %d characters", r.MaxLength)
+	}
+	return b.String()
+}
+
+// scissorsLine is the line below which git ignores the commit message
+// (git commit -v, commit.cleanup=scissors).
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// cutScissors returns msg up to the scissors line.
+func cutScissors(msg string) string {
+	for i := 0; i < len(msg); {
+		line, _, _ := strings.Cut(msg[i:], "\n")
+		if line == scissorsLine {
+			return msg[:i]
+		}
+		i += len(line) + 1
+	}
+	return msg
+}
+
+// stripCommentLines drops git's # comment lines and surrounding blank lines.
+func stripCommentLines(msg string) string {
+	var kept []string
+	for _, line := range strings.Split(msg, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
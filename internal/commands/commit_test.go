@@ -0,0 +1,32 @@
+package commands
+
+import "testing"
+
+func TestCommitMessageBeforeHook(t *testing.T) {
+	verbose := `
+# Please enter the commit message for your changes. Lines starting
+# with '#' will be ignored, and an empty message aborts the commit.
+#
+# On branch main
+# ------------------------ >8 ------------------------
+# Do not modify or remove the line above.
+# Everything below it will be ignored.
+diff --git a/x.go b/x.go
++added line
+`
+	tests := []struct {
+		name, file, want string
+	}{
+		{"plain template", "\n# Please enter the commit message\n#\n", ""},
+		{"git commit -v", verbose, ""},
+		{"message above the scissors", "fix: retry\n" + verbose, "fix: retry"},
+		{"no scissors", "feat: add x\n\nbody\n# comment\n", "feat: add x\n\nbody"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripCommentLines(cutScissors(tt.file)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/dennisloska/noji/internal/commands/output"
 	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
@@ -10,6 +15,11 @@ func newConfigCmd() *cobra.Command {
 	cmd := &cobra.Command{Use: "config", Short: "Manage configuration"}
 	cmd.AddCommand(newConfigPathCmd())
 	cmd.AddCommand(newConfigSetEditorCmd())
+	cmd.AddCommand(newConfigListCmd())
+	cmd.AddCommand(newConfigGetCmd())
+	cmd.AddCommand(newConfigSetCmd())
+	cmd.AddCommand(newConfigUnsetCmd())
+	cmd.AddCommand(newConfigEditCmd())
 	return cmd
 }
 
@@ -18,12 +28,16 @@ func newConfigPathCmd() *cobra.Command {
 		Use:   "path",
 		Short: "Print config and prompts paths",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
 			cfg, prompts, err := config.EnsureConfig()
 			if err != nil {
 				return err
 			}
-			output.Infof(output.ModeAuto, "config: %s\n", cfg)
-			output.Infof(output.ModeAuto, "prompts: %s\n", prompts)
+			p.Infof("config: %s\n", cfg)
+			p.Infof("prompts: %s\n", prompts)
+			if repo := config.RepoConfigPath(); repo != "" {
+				p.Infof("repo config: %s\n", repo)
+			}
 			return nil
 		},
 	}
@@ -35,12 +49,288 @@ func newConfigSetEditorCmd() *cobra.Command {
 		Short: "Set the preferred editor in config (e.g. vim, vi, nvim, 'code -w')",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
 			ed := args[0]
 			if err := config.SetEditor(ed); err != nil {
 				return err
 			}
-			output.Successf(output.ModeAuto, "Editor set to: %s\n", ed)
+			p.Successf("Editor set to: %s\n", ed)
+			return nil
+		},
+	}
+}
+
+// configRow is one setting in `config list`.
+type configRow struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Value       any    `json:"value"`
+	Source      string `json:"source,omitempty"`
+	File        string `json:"file,omitempty"`
+	Env         string `json:"env,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+	Description string `json:"description"`
+}
+
+func newConfigListCmd() *cobra.Command {
+	var outFlags *outputFlags
+	var sources bool
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"show"},
+		Short:   "List every setting with its effective value",
+		Long: "Lists every known setting. Values are layered with the precedence\n" +
+			"flag > env (NOJI_<KEY>) > repo (.noji.yaml at the git root) > active profile >\n" +
+			"user config > defaults.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			settings, err := config.Effective()
+			if err != nil {
+				return err
+			}
+			effective := map[string]config.Setting{}
+			for _, s := range settings {
+				effective[s.Key] = s
+			}
+			rows := make([]configRow, 0, len(config.Schema))
+			for _, f := range config.Schema {
+				s := effective[f.Key]
+				rows = append(rows, configRow{Key: f.Key, Type: f.Kind, Value: redactValue(s.Value), Source: s.Source,
+					File: s.File, Env: s.Env, Profile: s.Profile, Description: f.Description})
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, rows)
+			}
+			renderConfigRows(p, rows, sources)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&sources, "sources", false, "Show where each value comes from")
+	outFlags = addOutputFlags(cmd)
+	return cmd
+}
+
+func renderConfigRows(p *output.Printer, rows []configRow, sources bool) {
+	width, valueWidth := 0, 0
+	for _, r := range rows {
+		width = max(width, len(r.Key))
+		valueWidth = min(max(valueWidth, len(formatSettingValue(r.Value))), 30)
+	}
+	for _, r := range rows {
+		line := fmt.Sprintf("%-*s  %-*s", width, r.Key, valueWidth, formatSettingValue(r.Value))
+		if sources && r.Source != "" {
+			line += "  " + p.Dim(settingSource(config.Setting{Source: r.Source, File: r.File, Env: r.Env, Profile: r.Profile}))
+		}
+		if !sources {
+			line += "  " + p.Dim("# "+r.Description)
+		}
+		p.Printf("%s\n", line)
+	}
+}
+
+func formatSettingValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return `""`
+	case []any:
+		parts := make([]string, len(v))
+		for i, x := range v {
+			parts[i] = fmt.Sprint(x)
+		}
+		return strings.Join(parts, ",")
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// redactValue hides tokens in a setting, e.g. a password in a tracker URL.
+func redactValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	s := formatSettingValue(v)
+	if r := secrets.Redact(s); r != s {
+		return r
+	}
+	return v
+}
+
+func settingSource(s config.Setting) string {
+	switch {
+	case s.Profile != "":
+		return fmt.Sprintf("(%s %s: %s)", s.Source, s.Profile, s.File)
+	case s.File != "":
+		return fmt.Sprintf("(%s: %s)", s.Source, s.File)
+	case s.Env != "":
+		return fmt.Sprintf("(%s: %s)", s.Source, s.Env)
+	default:
+		return "(" + s.Source + ")"
+	}
+}
+
+func newConfigGetCmd() *cobra.Command {
+	var sources bool
+
+	cmd := &cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print the effective value of a setting",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			s, err := config.Get(args[0])
+			if err != nil {
+				return err
+			}
+			value := formatSettingValue(redactValue(s.Value))
+			if s.Value == nil {
+				value = ""
+			}
+			if sources && s.Source != "" {
+				value += "  " + p.Dim(settingSource(s))
+			}
+			p.Printf("%s\n", value)
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&sources, "sources", false, "Show where the value comes from")
+	return cmd
+}
+
+func newConfigSetCmd() *cobra.Command {
+	var repo bool
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Validate and write a setting (lists are comma-separated)",
+		Args:  cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeConfigKeys(cmd, args, toComplete)
+			}
+			if f, ok := config.Lookup(args[0]); ok && len(args) == 1 {
+				return f.Allowed, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := config.Set(args[0], args[1], repo); err != nil {
+				return err
+			}
+			path, _ := config.FilePath(repo)
+			p.Successf("Set %s = %s in %s\n", args[0], secrets.Redact(args[1]), path)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&repo, "repo", false, "Write to the repository's .noji.yaml instead of the user config")
+	return cmd
+}
+
+func newConfigUnsetCmd() *cobra.Command {
+	var repo bool
+
+	cmd := &cobra.Command{
+		Use:               "unset <key>",
+		Short:             "Remove a setting so the next source (or the default) applies",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			found, err := config.Unset(args[0], repo)
+			if err != nil {
+				return err
+			}
+			path, _ := config.FilePath(repo)
+			if !found {
+				p.Infof("%s is not set in %s\n", args[0], path)
+				return nil
+			}
+			p.Successf("Removed %s from %s\n", args[0], path)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&repo, "repo", false, "Remove from the repository's .noji.yaml instead of the user config")
+	return cmd
+}
+
+func newConfigEditCmd() *cobra.Command {
+	var repo bool
+
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in the editor and validate it on save",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			path, err := config.FilePath(repo)
+			if err != nil {
+				return err
+			}
+			before, err := os.ReadFile(path)
+			existed := err == nil
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			ed, err := resolveEditor()
+			if err != nil {
+				return err
+			}
+			ask := newAsker(p, cmd.InOrStdin())
+			for {
+				if err := openInEditor(ed, path); err != nil {
+					return err
+				}
+				problems, err := config.CheckFile(path)
+				if err == nil && len(problems) == 0 {
+					p.Successf("%s is valid.\n", path)
+					return nil
+				}
+				if err != nil {
+					p.Errorf("%v\n", err)
+				}
+				for _, prob := range problems {
+					p.Warnf("%s\n", prob.Message)
+				}
+				ans, aerr := ask.choice("[e]dit again, [r]evert, [k]eep anyway?", "erk", "e")
+				if aerr != nil {
+					return aerr
+				}
+				switch ans {
+				case "r":
+					if !existed {
+						err = os.Remove(path)
+					} else {
+						err = os.WriteFile(path, before, 0o644)
+					}
+					if err != nil {
+						return err
+					}
+					p.Infof("Reverted %s.\n", path)
+					return nil
+				case "k":
+					return nil
+				}
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&repo, "repo", false, "Edit the repository's .noji.yaml instead of the user config")
+	return cmd
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := make([]string, 0, len(config.Schema))
+	for _, f := range config.Schema {
+		keys = append(keys, f.Key+"\t"+f.Description)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
 }
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+)
+
+// asker reads interactive answers from the command's input.
+type asker struct {
+	p  *output.Printer
+	in *bufio.Reader
+}
+
+func newAsker(p *output.Printer, in io.Reader) *asker {
+	return &asker{p: p, in: bufio.NewReader(in)}
+}
+
+// choice asks question until the answer is one of the single-letter keys.
+// An empty answer selects def when def is non-empty.
+func (a *asker) choice(question, keys, def string) (string, error) {
+	for {
+		a.p.Printf("%s ", question)
+		line, err := a.in.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("no answer on stdin")
+			}
+			return "", err
+		}
+		ans := strings.ToLower(strings.TrimSpace(line))
+		if ans == "" && def != "" {
+			return def, nil
+		}
+		if len(ans) == 1 && strings.Contains(keys, ans) {
+			return ans, nil
+		}
+		a.p.Warnf("Please answer one of: %s\n", strings.Join(strings.Split(keys, ""), ", "))
+	}
+}
+
+// yesNo asks a y/N question; the default is no.
+func (a *asker) yesNo(question string) (bool, error) {
+	ans, err := a.choice(fmt.Sprintf("%s [y/N]", question), "yn", "n")
+	return ans == "y", err
+}
+
+// line reads a free-form answer.
+func (a *asker) line(question string) (string, error) {
+	a.p.Printf("%s ", question)
+	s, err := a.in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
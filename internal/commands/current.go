@@ -1,22 +1,70 @@
 package commands
 
 import (
-	"github.com/dennisloska/noji/internal/commands/output"
+	"strings"
+
 	"github.com/dennisloska/noji/internal/config"
 	"github.com/spf13/cobra"
 )
 
+// currentRow is the resolved model of one task.
+type currentRow struct {
+	Task      string   `json:"task"`
+	Model     string   `json:"model"`
+	Source    string   `json:"source"`
+	Key       string   `json:"key"`
+	Fallbacks []string `json:"fallbacks,omitempty"`
+}
+
 func newCurrentCmd() *cobra.Command {
-	return &cobra.Command{
+	var outFlags *outputFlags
+	cmd := &cobra.Command{
 		Use:   "current",
-		Short: "Show the currently selected model",
+		Short: "Show the model each task uses",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			rows := make([]currentRow, 0, len(config.ModelTasks))
+			for _, task := range config.ModelTasks {
+				c, err := config.ResolveModel(task)
+				if err != nil {
+					return err
+				}
+				rows = append(rows, currentRow{Task: c.Task, Model: c.Model, Source: c.Source, Key: c.Key, Fallbacks: c.Fallbacks})
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, rows)
+			}
 			model, err := config.GetModel()
 			if err != nil {
 				return err
 			}
-			output.Infof(output.ModeAuto, "%s\n", model)
+			p.Infof("%s\n", model)
+			width := 0
+			for _, r := range rows {
+				width = max(width, len(r.Task))
+			}
+			for _, r := range rows {
+				// tasks with their own model stand out
+				name, where := r.Model, r.Source
+				if r.Key != "model" {
+					name, where = p.Good(r.Model), r.Key+", "+r.Source
+				}
+				p.Printf("  %-*s  %s %s\n", width, r.Task, name, p.Dim("("+where+")"))
+			}
+			fallbacks, err := config.GetList("models.fallbacks")
+			if err != nil {
+				return err
+			}
+			if len(fallbacks) > 0 {
+				p.Printf("fallbacks: %s\n", strings.Join(fallbacks, ", "))
+			}
 			return nil
 		},
 	}
+	outFlags = addOutputFlags(cmd)
+	return cmd
 }
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dennisloska/noji/internal/commands/dash"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// dashThread is the payload of an item in the comments tab.
+type dashThread struct {
+	Repo     string
+	Number   int
+	PRTitle  string
+	PRURL    string
+	Comments []classifiedComment
+}
+
+func newDashCmd() *cobra.Command {
+	var interval time.Duration
+	var org string
+
+	cmd := &cobra.Command{
+		Use:   "dash",
+		Short: "Interactive dashboard for review requests, PR comments and tickets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			if !printer(cmd).TTY {
+				return errors.New("noji dash needs an interactive terminal")
+			}
+			tabs := []dash.Tab{
+				{
+					Title:   "Review requested",
+					Load:    func() ([]dash.Item, error) { return loadDashReviews(org) },
+					Actions: []dash.Action{dashOpenAction(), dashCheckoutAction()},
+				},
+				{
+					Title: "My PRs with comments",
+					Load:  loadDashThreads,
+					Actions: []dash.Action{
+						dashOpenAction(),
+						dashCheckoutAction(),
+						{Key: "R", Help: "reply", Run: dashReply},
+						{Key: "s", Help: "re-classify", Run: dashReclassify},
+					},
+				},
+				{
+					Title:   "My tickets",
+					Load:    loadDashTickets,
+					Actions: []dash.Action{dashOpenAction()},
+				},
+			}
+			return dash.Run(tabs, interval)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "Background refresh interval (0 disables)")
+	cmd.Flags().StringVar(&org, "org", "", "Limit review requests to a GitHub organization")
+	return cmd
+}
+
+func loadDashReviews(org string) ([]dash.Item, error) {
+	orgs, err := config.GetGitHubOrgs()
+	if err != nil {
+		return nil, err
+	}
+	items, err := fetchReviewRequests(reviewQuery{Org: org, Orgs: orgs, InferOrgs: true, NoBots: true})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dash.Item, 0, len(items))
+	for _, it := range items {
+		repo, _ := repoFromPRURL(it.HTMLURL)
+		author := "unknown"
+		if it.User != nil && it.User.Login != "" {
+			author = it.User.Login
+		}
+		var md strings.Builder
+		fmt.Fprintf(&md, "# #%d %s\n\n", it.Number, safeOneLine(it.Title))
+		fmt.Fprintf(&md, "- **Repo:** %s\n- **Author:** @%s\n- **Created:** %s\n\n%s\n", repo, author, it.CreatedAt, it.HTMLURL)
+		out = append(out, dash.Item{
+			ID:       it.HTMLURL,
+			Title:    fmt.Sprintf("#%d %s", it.Number, safeOneLine(it.Title)),
+			Subtitle: fmt.Sprintf("%s · @%s", repo, author),
+			Preview:  md.String(),
+			URL:      it.HTMLURL,
+			Data:     &dashThread{Repo: repo, Number: it.Number, PRTitle: it.Title, PRURL: it.HTMLURL},
+		})
+	}
+	return out, nil
+}
+
+func loadDashThreads() ([]dash.Item, error) {
+	results, err := collectPRComments(commentsQuery{State: "open", ExcludeBots: true, IncludeDrafts: true})
+	if err != nil {
+		return nil, err
+	}
+	var out []dash.Item
+	for _, r := range results {
+		for _, thread := range commentThreads(r.Comments) {
+			t := &dashThread{Repo: r.Repo, Number: r.Number, PRTitle: r.Title, PRURL: r.URL, Comments: thread}
+			out = append(out, threadItem(t))
+		}
+	}
+	return out, nil
+}
+
+func threadItem(t *dashThread) dash.Item {
+	root := t.Comments[0]
+	last := t.Comments[len(t.Comments)-1]
+	subtitle := fmt.Sprintf("%s#%d · @%s", t.Repo, t.Number, root.Author)
+	if n := len(t.Comments); n > 1 {
+		subtitle += fmt.Sprintf(" · %d replies", n-1)
+	}
+	if root.Path != "" {
+		subtitle += " · " + root.Path
+	}
+	return dash.Item{
+		ID:       fmt.Sprintf("%s/%d", root.Kind, root.ID),
+		Title:    fmt.Sprintf("%s%s", severityTag(t.Comments), oneLiner(root.Body)),
+		Subtitle: subtitle,
+		Preview:  threadMarkdown(t),
+		URL:      last.URL,
+		Data:     t,
+	}
+}
+
+func severityTag(comments []classifiedComment) string {
+	for _, c := range comments {
+		if c.Severity != "" {
+			return "[" + derivePriority(comments) + "] "
+		}
+	}
+	return ""
+}
+
+func threadMarkdown(t *dashThread) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# #%d %s\n\n", t.Number, safeOneLine(t.PRTitle))
+	if p := t.Comments[0].Path; p != "" {
+		fmt.Fprintf(&md, "`%s`\n\n", p)
+	}
+	for _, c := range t.Comments {
+		fmt.Fprintf(&md, "**@%s** · %s", c.Author, c.CreatedAt)
+		if c.State != "" {
+			fmt.Fprintf(&md, " · %s", c.State)
+		}
+		if c.Severity != "" {
+			fmt.Fprintf(&md, " · %s", c.Severity)
+		}
+		fmt.Fprintf(&md, "\n\n%s\n\n---\n\n", strings.TrimSpace(c.Body))
+	}
+	return md.String()
+}
+
+func loadDashTickets() ([]dash.Item, error) {
+	rows, err := listMyTickets()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dash.Item, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, dash.Item{
+			ID:       r.Key,
+			Title:    fmt.Sprintf("%s %s", r.Key, r.Summary),
+			Subtitle: r.Status,
+			Preview:  fmt.Sprintf("# %s\n\n%s\n\n- **Status:** %s\n\n%s\n", r.Key, r.Summary, r.Status, r.URL),
+			URL:      r.URL,
+			Data:     r,
+		})
+	}
+	return out, nil
+}
+
+func dashOpenAction() dash.Action {
+	return dash.Action{Key: "o", Help: "open", Run: func(it dash.Item) tea.Cmd {
+		return func() tea.Msg {
+			if t, ok := it.Data.(ticketRow); ok && it.URL == "" {
+				return dash.ResultMsg{Status: "Opened " + t.Key, Err: openTicketInBrowser(t.Key)}
+			}
+			if it.URL == "" {
+				return dash.ResultMsg{Err: errors.New("no URL for this item")}
+			}
+			return dash.ResultMsg{Status: "Opened " + it.URL, Err: openURL(it.URL)}
+		}
+	}}
+}
+
+func dashCheckoutAction() dash.Action {
+	return dash.Action{Key: "c", Help: "checkout", Run: func(it dash.Item) tea.Cmd {
+		t, ok := it.Data.(*dashThread)
+		if !ok {
+			return nil
+		}
+		c := exec.Command("gh", "pr", "checkout", fmt.Sprintf("%d", t.Number), "--repo", t.Repo)
+		return tea.ExecProcess(c, func(err error) tea.Msg {
+			return dash.ResultMsg{Status: fmt.Sprintf("Checked out %s#%d", t.Repo, t.Number), Err: err}
+		})
+	}}
+}
+
+func dashReply(it dash.Item) tea.Cmd {
+	t, ok := it.Data.(*dashThread)
+	if !ok || len(t.Comments) == 0 {
+		return nil
+	}
+	target := t.Comments[len(t.Comments)-1]
+	seed := quoteReply(target)
+	tmp, err := createTempFile(seed)
+	if err != nil {
+		return dash.Result(dash.ResultMsg{Err: err})
+	}
+	ed, err := resolveEditor()
+	if err != nil {
+		os.Remove(tmp)
+		return dash.Result(dash.ResultMsg{Err: err})
+	}
+	return tea.ExecProcess(editorCommand(ed, tmp), func(err error) tea.Msg {
+		defer os.Remove(tmp)
+		if err != nil {
+			return dash.ResultMsg{Err: err}
+		}
+		b, err := os.ReadFile(tmp)
+		if err != nil {
+			return dash.ResultMsg{Err: err}
+		}
+		body := strings.TrimSpace(string(b))
+		if body == "" || body == strings.TrimSpace(seed) {
+			return dash.ResultMsg{Status: "Reply cancelled (no changes)."}
+		}
+		if _, err := postCommentReply(t.Repo, t.Number, target, body); err != nil {
+			return dash.ResultMsg{Err: err}
+		}
+		return dash.ResultMsg{Status: "Reply posted.", Tab: it.Tab, Reload: true}
+	})
+}
+
+func dashReclassify(it dash.Item) tea.Cmd {
+	t, ok := it.Data.(*dashThread)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		runner, err := newModelRunner(config.TaskClassify, nil)
+		if err != nil {
+			return dash.ResultMsg{Err: err}
+		}
+		updated := *t
+		updated.Comments = append([]classifiedComment(nil), t.Comments...)
+		for i := range updated.Comments {
+			sev, _ := classifyComment(runner, updated.Comments[i].Body)
+			updated.Comments[i].Severity = sev
+		}
+		item := threadItem(&updated)
+		return dash.ResultMsg{Status: "Classified as " + derivePriority(updated.Comments), Tab: it.Tab, Updated: &item}
+	}
+}
@@ -0,0 +1,407 @@
+// Package dash implements the full-screen dashboard behind `noji dash`.
+// It only knows about tabs, items and key-bound actions; the commands
+// package supplies the data sources and what each action does.
+package dash
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Item is a single row in a tab.
+type Item struct {
+	ID       string
+	Title    string
+	Subtitle string
+	Preview  string // Markdown shown in the preview pane
+	URL      string
+	Data     any
+	// Tab is the index of the tab the item is listed in; the dashboard
+	// sets it when the tab loads.
+	Tab int
+}
+
+// Action is a key-bound operation on the selected item.
+type Action struct {
+	Key  string
+	Help string
+	// Run returns a command producing a ResultMsg. Use tea.ExecProcess for
+	// actions that need the terminal (editors, git).
+	Run func(it Item) tea.Cmd
+}
+
+// Tab is one data source of the dashboard.
+type Tab struct {
+	Title   string
+	Load    func() ([]Item, error)
+	Actions []Action
+}
+
+// ResultMsg reports the outcome of an action.
+type ResultMsg struct {
+	Status string
+	Err    error
+	// Tab is the tab the action ran in (Item.Tab); Reload and Updated apply
+	// to it even when the user switched tabs meanwhile.
+	Tab     int
+	Reload  bool  // reload the tab
+	Updated *Item // replaces the item with the same ID in the tab
+}
+
+// Result wraps a ResultMsg as a tea.Cmd.
+func Result(msg ResultMsg) tea.Cmd {
+	return func() tea.Msg { return msg }
+}
+
+type loadedMsg struct {
+	tab   int
+	items []Item
+	err   error
+}
+
+type tickMsg time.Time
+
+type tabState struct {
+	items    []Item
+	cursor   int
+	err      error
+	loading  bool
+	loadedAt time.Time
+}
+
+// Model is the bubbletea model of the dashboard.
+type Model struct {
+	tabs     []Tab
+	state    []tabState
+	active   int
+	interval time.Duration
+
+	width, height int
+	preview       viewport.Model
+	status        string
+	renderer      *glamour.TermRenderer
+	rendererWidth int
+	mdStyle       string
+}
+
+var (
+	activeTabStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")).Underline(true).Padding(0, 1)
+	inactiveTabStyle = lipgloss.NewStyle().Faint(true).Padding(0, 1)
+	selectedStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10"))
+	subtitleStyle    = lipgloss.NewStyle().Faint(true)
+	statusStyle      = lipgloss.NewStyle().Faint(true)
+	errorStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	paneStyle        = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+)
+
+// New builds a dashboard that refreshes every interval (0 disables refresh).
+func New(tabs []Tab, interval time.Duration) *Model {
+	return &Model{
+		tabs:     tabs,
+		state:    make([]tabState, len(tabs)),
+		interval: interval,
+		preview:  viewport.New(0, 0),
+		mdStyle:  "dark",
+	}
+}
+
+// Run starts the dashboard on the alternate screen and blocks until quit.
+func Run(tabs []Tab, interval time.Duration) error {
+	m := New(tabs, interval)
+	// Query the terminal background now; doing it once the program owns
+	// stdin would swallow the reply.
+	if !lipgloss.HasDarkBackground() {
+		m.mdStyle = "light"
+	}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m *Model) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(m.tabs)+1)
+	for i := range m.tabs {
+		cmds = append(cmds, m.load(i))
+	}
+	cmds = append(cmds, m.tick())
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) load(i int) tea.Cmd {
+	m.state[i].loading = true
+	load := m.tabs[i].Load
+	return func() tea.Msg {
+		items, err := load()
+		return loadedMsg{tab: i, items: items, err: err}
+	}
+}
+
+func (m *Model) tick() tea.Cmd {
+	if m.interval <= 0 {
+		return nil
+	}
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+	case loadedMsg:
+		st := &m.state[msg.tab]
+		st.loading = false
+		st.err = msg.err
+		if msg.err == nil {
+			for i := range msg.items {
+				msg.items[i].Tab = msg.tab
+			}
+			st.items = msg.items
+			st.loadedAt = time.Now()
+			if st.cursor >= len(st.items) {
+				st.cursor = max(0, len(st.items)-1)
+			}
+		}
+		if msg.tab == m.active {
+			m.refreshPreview()
+		}
+		return m, nil
+	case tickMsg:
+		cmds := []tea.Cmd{m.tick()}
+		for i := range m.tabs {
+			if !m.state[i].loading {
+				cmds = append(cmds, m.load(i))
+			}
+		}
+		return m, tea.Batch(cmds...)
+	case ResultMsg:
+		if msg.Err != nil {
+			m.status = errorStyle.Render(msg.Err.Error())
+		} else {
+			m.status = msg.Status
+		}
+		if msg.Tab < 0 || msg.Tab >= len(m.tabs) {
+			return m, nil
+		}
+		if msg.Updated != nil {
+			st := &m.state[msg.Tab]
+			for i := range st.items {
+				if st.items[i].ID == msg.Updated.ID {
+					st.items[i] = *msg.Updated
+					st.items[i].Tab = msg.Tab
+				}
+			}
+			if msg.Tab == m.active {
+				m.refreshPreview()
+			}
+		}
+		if msg.Reload {
+			return m, m.load(msg.Tab)
+		}
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := &m.state[m.active]
+	switch key := msg.String(); key {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "tab", "right", "l":
+		m.switchTab((m.active + 1) % len(m.tabs))
+	case "shift+tab", "left", "h":
+		m.switchTab((m.active + len(m.tabs) - 1) % len(m.tabs))
+	case "down", "j":
+		if st.cursor < len(st.items)-1 {
+			st.cursor++
+			m.refreshPreview()
+		}
+	case "up", "k":
+		if st.cursor > 0 {
+			st.cursor--
+			m.refreshPreview()
+		}
+	case "g", "home":
+		st.cursor = 0
+		m.refreshPreview()
+	case "G", "end":
+		st.cursor = max(0, len(st.items)-1)
+		m.refreshPreview()
+	case "pgdown", "J", " ":
+		m.preview.HalfViewDown()
+	case "pgup", "K":
+		m.preview.HalfViewUp()
+	case "r":
+		m.status = "Refreshing…"
+		return m, m.load(m.active)
+	default:
+		if n := int(key[0] - '1'); len(key) == 1 && n >= 0 && n < len(m.tabs) {
+			m.switchTab(n)
+			return m, nil
+		}
+		it, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		for _, a := range m.tabs[m.active].Actions {
+			if a.Key == key {
+				m.status = a.Help + "…"
+				return m, a.Run(it)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) switchTab(i int) {
+	m.active = i
+	m.status = ""
+	m.refreshPreview()
+}
+
+func (m *Model) selected() (Item, bool) {
+	st := m.state[m.active]
+	if st.cursor < 0 || st.cursor >= len(st.items) {
+		return Item{}, false
+	}
+	return st.items[st.cursor], true
+}
+
+func (m *Model) listWidth() int {
+	return max(20, m.width*2/5)
+}
+
+func (m *Model) bodyHeight() int {
+	// tab bar, blank line, status line, help line
+	return max(3, m.height-4)
+}
+
+func (m *Model) layout() {
+	m.preview.Width = max(10, m.width-m.listWidth()-4)
+	m.preview.Height = max(1, m.bodyHeight()-2)
+	m.refreshPreview()
+}
+
+func (m *Model) refreshPreview() {
+	it, ok := m.selected()
+	if !ok {
+		m.preview.SetContent("")
+		return
+	}
+	m.preview.SetContent(m.renderMarkdown(it.Preview))
+	m.preview.GotoTop()
+}
+
+func (m *Model) renderMarkdown(s string) string {
+	if m.renderer == nil || m.rendererWidth != m.preview.Width {
+		r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(m.mdStyle), glamour.WithWordWrap(max(10, m.preview.Width-2)))
+		if err != nil {
+			return s
+		}
+		m.renderer, m.rendererWidth = r, m.preview.Width
+	}
+	out, err := m.renderer.Render(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+func (m *Model) View() string {
+	if m.width == 0 {
+		return "Loading…"
+	}
+	var tabs []string
+	for i, t := range m.tabs {
+		label := fmt.Sprintf("%d %s (%d)", i+1, t.Title, len(m.state[i].items))
+		if i == m.active {
+			tabs = append(tabs, activeTabStyle.Render(label))
+		} else {
+			tabs = append(tabs, inactiveTabStyle.Render(label))
+		}
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+
+	list := paneStyle.Width(m.listWidth()).Height(m.bodyHeight() - 2).Render(m.listView())
+	preview := paneStyle.Width(m.preview.Width).Height(m.bodyHeight() - 2).Render(m.preview.View())
+	body := lipgloss.JoinHorizontal(lipgloss.Top, list, preview)
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, m.statusLine(), m.helpLine())
+}
+
+func (m *Model) listView() string {
+	st := m.state[m.active]
+	switch {
+	case st.err != nil && len(st.items) == 0:
+		return errorStyle.Render(st.err.Error())
+	case st.loading && len(st.items) == 0:
+		return "Loading…"
+	case len(st.items) == 0:
+		return subtitleStyle.Render("Nothing here.")
+	}
+	// Each item uses two lines; keep the cursor visible.
+	visible := max(1, (m.bodyHeight()-2)/2)
+	start := 0
+	if st.cursor >= visible {
+		start = st.cursor - visible + 1
+	}
+	end := min(len(st.items), start+visible)
+	width := m.listWidth() - 2
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		it := st.items[i]
+		title := truncate(it.Title, width-2)
+		if i == st.cursor {
+			b.WriteString(selectedStyle.Render("> " + title))
+		} else {
+			b.WriteString("  " + title)
+		}
+		b.WriteString("\n  " + subtitleStyle.Render(truncate(it.Subtitle, width-2)) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (m *Model) statusLine() string {
+	st := m.state[m.active]
+	s := m.status
+	if st.err != nil && len(st.items) > 0 {
+		s = errorStyle.Render("refresh failed: " + st.err.Error())
+	}
+	if !st.loadedAt.IsZero() {
+		s = strings.TrimSpace(s + "  updated " + st.loadedAt.Format("15:04:05"))
+	}
+	if st.loading {
+		s = strings.TrimSpace(s + "  loading…")
+	}
+	return statusStyle.Render(s)
+}
+
+func (m *Model) helpLine() string {
+	parts := []string{"tab/1-9 switch", "j/k move", "J/K scroll", "r refresh"}
+	for _, a := range m.tabs[m.active].Actions {
+		parts = append(parts, a.Key+" "+a.Help)
+	}
+	parts = append(parts, "q quit")
+	return statusStyle.Render(strings.Join(parts, " • "))
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if n <= 1 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,34 @@
+package dash
+
+import "testing"
+
+func TestResultGoesToSourceTab(t *testing.T) {
+	loads := 0
+	load := func() ([]Item, error) { loads++; return nil, nil }
+	m := New([]Tab{{Title: "a", Load: load}, {Title: "b", Load: load}}, 0)
+	m.Update(loadedMsg{tab: 0, items: []Item{{ID: "1", Title: "old"}}})
+	m.Update(loadedMsg{tab: 1, items: []Item{{ID: "1", Title: "other"}}})
+	if got := m.state[0].items[0].Tab; got != 0 {
+		t.Fatalf("item tab = %d", got)
+	}
+	m.switchTab(1)
+
+	m.Update(ResultMsg{Tab: 0, Updated: &Item{ID: "1", Title: "new"}})
+	if got := m.state[0].items[0].Title; got != "new" {
+		t.Errorf("source tab item = %q, want new", got)
+	}
+	if got := m.state[1].items[0].Title; got != "other" {
+		t.Errorf("active tab item = %q, want other", got)
+	}
+
+	_, cmd := m.Update(ResultMsg{Tab: 0, Reload: true})
+	if !m.state[0].loading || m.state[1].loading {
+		t.Errorf("reloading = %v, %v; want the source tab only", m.state[0].loading, m.state[1].loading)
+	}
+	if cmd != nil {
+		cmd()
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+}
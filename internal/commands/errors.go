@@ -0,0 +1,17 @@
+package commands
+
+// ExitError makes main exit with Code. Err is printed when non-nil; a nil
+// Err means the command already reported the outcome itself.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/opencode"
+	"github.com/dennisloska/noji/internal/redact"
+	"github.com/dennisloska/noji/internal/secrets"
+)
+
+// modelRunner runs a task's prompts on its model, falling back to
+// models.fallbacks when a model fails or opencode does not list it. Only
+// captured runs fall back: an interactive session may already have changed
+// something when it fails. Every prompt is redacted first (see the redact
+// settings). Runs may be concurrent.
+type modelRunner struct {
+	mu       sync.Mutex
+	models   []string
+	warnf    func(format string, a ...any)
+	redactor *redact.Redactor
+	redact   config.RedactSettings
+}
+
+// newModelRunner resolves the model chain of task. warnf receives skipped
+// and failed models; nil keeps quiet (full-screen callers).
+func newModelRunner(task string, warnf func(format string, a ...any)) (*modelRunner, error) {
+	choice, err := config.ResolveModel(task)
+	if err != nil {
+		return nil, err
+	}
+	if warnf == nil {
+		warnf = func(string, ...any) {}
+	}
+	chain := choice.Chain()
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no model configured for %s; select one with `noji use <model>`", task)
+	}
+	if listed := listedModels(); listed != nil {
+		var available []string
+		for _, m := range chain {
+			if slices.Contains(listed, m) {
+				available = append(available, m)
+				continue
+			}
+			warnf("Model %s is not listed by opencode, skipping it.\n", m)
+		}
+		if len(available) == 0 {
+			return nil, fmt.Errorf("no available model for %s (tried %s)", task, strings.Join(chain, ", "))
+		}
+		chain = available
+	}
+	r := &modelRunner{models: chain, warnf: warnf}
+	if r.redact, err = config.GetRedactSettings(); err != nil {
+		return nil, err
+	}
+	if r.redact.Enabled {
+		rules := r.redact.Rules
+		rules.Literals = secrets.Known()
+		if r.redactor, err = redact.New(rules); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+var (
+	listModelsOnce sync.Once
+	opencodeModels []string
+)
+
+// listedModels returns the models opencode offers, or nil when they cannot
+// be listed (then every configured model is tried).
+func listedModels() []string {
+	listModelsOnce.Do(func() {
+		opencodeModels, _ = opencode.ListModels()
+	})
+	return opencodeModels
+}
+
+// Model is the model the next run starts with.
+func (r *modelRunner) Model() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.models[0]
+}
+
+// prepare redacts prompt. In strict mode a prompt with secrets is refused;
+// personal data such as emails is only masked.
+func (r *modelRunner) prepare(prompt string) (string, error) {
+	if r.redactor == nil {
+		return prompt, nil
+	}
+	res := r.redactor.Apply(prompt)
+	if r.redact.Strict && len(res.Secrets()) > 0 {
+		return "", &redact.StrictError{Result: res}
+	}
+	if r.redact.Show && !res.Clean() {
+		r.warnf("Redacted from the prompt: %s\n", res.Summary())
+	}
+	return res.Text, nil
+}
+
+// checkVerbatim scans text that is sent without masking. In strict mode text
+// with secrets is refused; otherwise findings are only reported.
+func (r *modelRunner) checkVerbatim(text string) error {
+	if r.redactor == nil {
+		return nil
+	}
+	res := r.redactor.Apply(text)
+	if r.redact.Strict && len(res.Secrets()) > 0 {
+		return &redact.StrictError{Result: res}
+	}
+	if r.redact.Show && !res.Clean() {
+		r.warnf("Sent unmasked with your text: %s\n", res.Summary())
+	}
+	return nil
+}
+
+// excluded reports whether changes to file must not be sent at all.
+func (r *modelRunner) excluded(file string) bool {
+	return r.redactor != nil && r.redactor.Excluded(file)
+}
+
+// run calls fn with each model of the chain until one succeeds. Models that
+// failed are dropped, so later runs start with the one that worked.
+func (r *modelRunner) run(fn func(model string) error) error {
+	model := r.Model()
+	for {
+		err := fn(model)
+		if err == nil {
+			return err
+		}
+		r.mu.Lock()
+		// a concurrent run may have dropped the model already
+		if r.models[0] == model && len(r.models) > 1 {
+			r.models = r.models[1:]
+			r.warnf("Model %s failed (%v), trying %s.\n", model, err, r.models[0])
+		}
+		next := r.models[0]
+		r.mu.Unlock()
+		if next == model {
+			return err
+		}
+		model = next
+	}
+}
+
+// runOnce calls fn with the first model only. Interactive sessions act
+// (create PRs, update tickets) while they run, so a failed one is not
+// repeated on the next model behind the user's back.
+func (r *modelRunner) runOnce(fn func(model string) error) error {
+	r.mu.Lock()
+	model, rest := r.models[0], r.models[1:]
+	r.mu.Unlock()
+	err := fn(model)
+	if err == nil || len(rest) == 0 {
+		return err
+	}
+	return fmt.Errorf("model %s failed: %w; not retrying with %s because the session may already have made changes, re-run with --model %s to try it", model, err, rest[0], rest[0])
+}
+
+// capture runs prompt and returns its output (see runOpencodeCapture).
+func (r *modelRunner) capture(prompt string) (string, error) {
+	prompt, err := r.prepare(prompt)
+	if err != nil {
+		return "", err
+	}
+	var out string
+	err = r.run(func(model string) error {
+		var err error
+		out, err = runOpencodeCapture(model, prompt)
+		return err
+	})
+	return out, err
+}
+
+// quiet runs prompt and returns its output (see runOpencodeQuiet).
+func (r *modelRunner) quiet(prompt string) (string, error) {
+	prompt, err := r.prepare(prompt)
+	if err != nil {
+		return "", err
+	}
+	var out string
+	err = r.run(func(model string) error {
+		var err error
+		out, err = runOpencodeQuiet(model, prompt)
+		return err
+	})
+	return out, err
+}
+
+// streamVerbatim is stream for prompts that ask the model to write text
+// back exactly, such as a description the user just edited. Only prompt is
+// redacted: masking the user's own text would save the placeholders, so
+// text is only checked.
+func (r *modelRunner) streamVerbatim(prompt, text string) error {
+	prompt, err := r.prepare(prompt)
+	if err != nil {
+		return err
+	}
+	if err := r.checkVerbatim(text); err != nil {
+		return err
+	}
+	return r.runOnce(func(model string) error {
+		return opencode.RunWithPrompt(model, strings.Replace(prompt, verbatimPlaceholder, text, 1))
+	})
+}
+
+// verbatimPlaceholder marks where streamVerbatim inserts the text.
+const verbatimPlaceholder = "{{noji:verbatim}}"
+
+// stream runs prompt interactively on the terminal.
+func (r *modelRunner) stream(prompt string) error {
+	prompt, err := r.prepare(prompt)
+	if err != nil {
+		return err
+	}
+	return r.runOnce(func(model string) error {
+		return opencode.RunWithPrompt(model, prompt)
+	})
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/redact"
+)
+
+func TestStrictRefusesVerbatimText(t *testing.T) {
+	red, err := redact.New(redact.Rules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	r := &modelRunner{
+		models:   []string{"m"},
+		redactor: red,
+		redact:   config.RedactSettings{Enabled: true, Strict: true, Show: true},
+		warnf:    func(format string, a ...any) { warnings = append(warnings, fmt.Sprintf(format, a...)) },
+	}
+	secret := "token ghp_" + strings.Repeat("A", 36)
+	var strict *redact.StrictError
+	if _, err := r.prepare("prompt " + secret); !errors.As(err, &strict) {
+		t.Errorf("prepare: %v, want a StrictError", err)
+	}
+	if err := r.streamVerbatim("prompt "+verbatimPlaceholder, secret); !errors.As(err, &strict) {
+		t.Errorf("streamVerbatim: %v, want a StrictError", err)
+	}
+
+	r.redact.Strict = false
+	if err := r.checkVerbatim("mail a@b.io"); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "email") {
+		t.Errorf("warnings = %q, want the email reported", warnings)
+	}
+}
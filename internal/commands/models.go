@@ -1,31 +1,49 @@
 package commands
 
 import (
-	"github.com/dennisloska/noji/internal/commands/output"
 	"github.com/dennisloska/noji/internal/config"
 	"github.com/dennisloska/noji/internal/opencode"
 	"github.com/spf13/cobra"
 )
 
+type modelRow struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
 func newModelsCmd() *cobra.Command {
+	var outFlags *outputFlags
 	cmd := &cobra.Command{
 		Use:   "models",
 		Short: "List available models",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
 			models, err := opencode.ListModels()
 			if err != nil {
 				return err
 			}
 			current, _ := config.GetModel()
+			if !outOpts.Human() {
+				rows := make([]modelRow, 0, len(models))
+				for _, m := range models {
+					rows = append(rows, modelRow{Name: m, Current: m == current && m != ""})
+				}
+				return p.Render(outOpts, rows)
+			}
 			for _, m := range models {
 				if m == current && m != "" {
-					output.Successf(output.ModeAuto, "%s\n", m)
+					p.Successf("%s\n", m)
 				} else {
-					output.Printf(output.ModeAuto, "%s\n", m)
+					p.Printf("%s\n", m)
 				}
 			}
 			return nil
 		},
 	}
+	outFlags = addOutputFlags(cmd)
 	return cmd
 }
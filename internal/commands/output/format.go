@@ -0,0 +1,411 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/itchyny/gojq"
+	"gopkg.in/yaml.v3"
+)
+
+// Format selects how listing commands render their results.
+type Format string
+
+const (
+	FormatHuman    Format = ""
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatCSV      Format = "csv"
+	FormatTSV      Format = "tsv"
+	FormatTable    Format = "table"
+	FormatURLs     Format = "urls"
+	FormatTemplate Format = "template"
+)
+
+// Formats lists the accepted --output values (used for validation and completion).
+var Formats = []string{"json", "yaml", "csv", "tsv", "table", "urls", "template"}
+
+// FormatOptions carries the user's output selection for a listing command.
+type FormatOptions struct {
+	Format   Format
+	Template string // Go template applied to each item
+	JQ       string // jq filter applied to the JSON representation
+}
+
+// ParseFormat validates a --output value. The empty string selects the
+// command's own human-readable output.
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "human" || s == "text" {
+		return FormatHuman, nil
+	}
+	for _, f := range Formats {
+		if s == f {
+			return Format(s), nil
+		}
+	}
+	return FormatHuman, fmt.Errorf("invalid output format: %s (want %s)", s, strings.Join(Formats, "|"))
+}
+
+// Human reports whether the command should print its own human-readable output.
+func (o FormatOptions) Human() bool {
+	return o.Format == FormatHuman && o.Template == "" && o.JQ == ""
+}
+
+// Render writes items (normally a slice of structs) to w using opts.
+// JSON field names follow the struct's json tags; templates see the Go values.
+func Render(w io.Writer, opts FormatOptions, items any) error {
+	if opts.JQ != "" {
+		if opts.Format != FormatHuman && opts.Format != FormatJSON {
+			return errors.New("--jq can only be combined with --output json")
+		}
+		return renderJQ(w, opts.JQ, items)
+	}
+	if opts.Template != "" {
+		if opts.Format != FormatHuman && opts.Format != FormatTemplate {
+			return errors.New("--template can only be combined with --output template")
+		}
+		return renderTemplate(w, opts.Template, items)
+	}
+	switch opts.Format {
+	case FormatHuman, FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	case FormatYAML:
+		return renderYAML(w, items)
+	case FormatCSV:
+		return renderDelimited(w, ',', items)
+	case FormatTSV:
+		return renderDelimited(w, '\t', items)
+	case FormatTable:
+		return renderTable(w, items)
+	case FormatURLs:
+		return renderURLs(w, items)
+	case FormatTemplate:
+		return errors.New("--output template requires --template")
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.Format)
+	}
+}
+
+func renderJQ(w io.Writer, filter string, items any) error {
+	q, err := gojq.Parse(filter)
+	if err != nil {
+		return fmt.Errorf("parse jq filter: %w", err)
+	}
+	input, err := toJSONValue(items)
+	if err != nil {
+		return err
+	}
+	iter := q.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, isErr := v.(error); isErr {
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				return nil
+			}
+			return fmt.Errorf("jq: %w", err)
+		}
+		// Like gh --jq: strings are printed raw, everything else as JSON.
+		if s, isStr := v.(string); isStr {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := gojq.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(b)); err != nil {
+			return err
+		}
+	}
+}
+
+func renderTemplate(w io.Writer, text string, items any) error {
+	funcs := template.FuncMap{
+		"join":    strings.Join,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"oneline": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	tmpl, err := template.New("output").Funcs(funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+	for _, it := range elements(items) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, it.Interface()); err != nil {
+			return fmt.Errorf("execute template: %w", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderYAML(w io.Writer, items any) error {
+	// Round-trip through JSON so keys and their order match the JSON output.
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	resetYAMLStyle(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func resetYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		resetYAMLStyle(c)
+	}
+}
+
+func renderDelimited(w io.Writer, sep rune, items any) error {
+	cols, rows := tabulate(items)
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(r); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func renderTable(w io.Writer, items any) error {
+	cols, rows := tabulate(items)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = strings.ToUpper(c)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		cells := make([]string, len(r))
+		for i, c := range r {
+			cells[i] = strings.Join(strings.Fields(c), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderURLs(w io.Writer, items any) error {
+	for _, it := range elements(items) {
+		u, ok := findURL(it)
+		if !ok {
+			return errors.New("--output urls is not supported for this command")
+		}
+		if strings.TrimSpace(u) != "" {
+			if _, err := fmt.Fprintln(w, u); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toJSONValue converts v into the generic form gojq expects.
+func toJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// elements returns the items of a slice or array, or v itself otherwise.
+func elements(v any) []reflect.Value {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []reflect.Value{rv}
+	}
+	out := make([]reflect.Value, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i)
+	}
+	return out
+}
+
+// tabulate flattens items into a header and rows. Nested structs become
+// dotted columns, slices of structs are reported by length.
+func tabulate(items any) ([]string, [][]string) {
+	elems := elements(items)
+	var cols []string
+	var rows [][]string
+	for i, e := range elems {
+		var names, values []string
+		flatten("", e, &names, &values)
+		if i == 0 {
+			cols = names
+		}
+		rows = append(rows, values)
+	}
+	if cols == nil && len(elems) == 0 {
+		// Derive the header from the element type so empty results still have one.
+		t := reflect.TypeOf(items)
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			var values []string
+			flatten("", reflect.New(t.Elem()).Elem(), &cols, &values)
+		}
+	}
+	return cols, rows
+}
+
+func flatten(prefix string, v reflect.Value, names, values *[]string) {
+	t := v.Type()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsNil() {
+			v = reflect.Zero(t)
+		} else {
+			v = v.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct {
+		name := prefix
+		if name == "" {
+			name = "value"
+		}
+		*names = append(*names, name)
+		*values = append(*values, cell(v))
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// Embedded structs without a json name are inlined, like encoding/json does.
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			flatten(prefix, v.Field(i), names, values)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, skip := fieldName(f)
+		if skip {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		fv := v.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			flatten(name, fv, names, values)
+			continue
+		}
+		*names = append(*names, name)
+		*values = append(*values, cell(fv))
+	}
+}
+
+func fieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, false
+	}
+	return f.Name, false
+}
+
+func cell(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		et := v.Type().Elem()
+		for et.Kind() == reflect.Pointer {
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Struct || et.Kind() == reflect.Map {
+			return fmt.Sprintf("%d", v.Len())
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = cell(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		return fmt.Sprintf("%d", v.Len())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// findURL looks for a URL-like field on a struct item.
+func findURL(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	t := v.Type()
+	for _, want := range []string{"URL", "HTMLURL", "Url"} {
+		if f, ok := t.FieldByName(want); ok && f.Type.Kind() == reflect.String {
+			return v.FieldByIndex(f.Index).String(), true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name, _ := fieldName(t.Field(i))
+		if (name == "url" || name == "html_url") && t.Field(i).Type.Kind() == reflect.String {
+			return v.Field(i).String(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+type author struct {
+	Login string `json:"login"`
+}
+
+type listItem struct {
+	Number int      `json:"number"`
+	Title  string   `json:"title"`
+	Author author   `json:"author"`
+	Labels []string `json:"labels"`
+	URL    string   `json:"url"`
+	Draft  bool     `json:"draft"`
+}
+
+var listItems = []listItem{
+	{Number: 12, Title: "Add retry to the client", Author: author{"alice"}, Labels: []string{"bug", "api"}, URL: "https://github.com/acme/app/pull/12"},
+	{Number: 7, Title: "Docs: \"quoted\", with comma\nand a newline", Author: author{"bob"}, URL: "https://github.com/acme/app/pull/7", Draft: true},
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FormatOptions
+	}{
+		{"json", FormatOptions{Format: FormatJSON}},
+		{"yaml", FormatOptions{Format: FormatYAML}},
+		{"csv", FormatOptions{Format: FormatCSV}},
+		{"tsv", FormatOptions{Format: FormatTSV}},
+		{"table", FormatOptions{Format: FormatTable}},
+		{"urls", FormatOptions{Format: FormatURLs}},
+		{"template", FormatOptions{Template: "#{{.Number}} {{oneline .Title}} by {{.Author.Login}} [{{join .Labels \",\"}}]"}},
+		{"jq", FormatOptions{JQ: ".[] | select(.draft | not) | .title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, tt.opts, listItems); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("output differs from %s:\n--- got ---\n%s--- want ---\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestRenderEmptyTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatOptions{Format: FormatTable}, []listItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "NUMBER  TITLE  AUTHOR.LOGIN  LABELS  URL  DRAFT\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, opts := range []FormatOptions{
+		{Format: FormatTemplate},
+		{Format: FormatYAML, JQ: ".[]"},
+		{Format: FormatJSON, Template: "{{.Name}}"},
+	} {
+		if err := Render(&bytes.Buffer{}, opts, listItems); err == nil {
+			t.Errorf("Render(%+v) succeeded, want an error", opts)
+		}
+	}
+	if err := Render(&bytes.Buffer{}, FormatOptions{Format: FormatURLs}, []author{{"alice"}}); err == nil {
+		t.Error("--output urls on items without a URL succeeded, want an error")
+	}
+}
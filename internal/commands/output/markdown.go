@@ -7,6 +7,12 @@ import (
 // RenderMarkdown renders GitHub-flavored Markdown to ANSI, with a compact theme.
 // If rendering fails, it returns the original input.
 func RenderMarkdown(s string) string {
+	return std.RenderMarkdown(s)
+}
+
+// RenderMarkdown renders Markdown for this Printer, wrapping to its width and
+// falling back to the plain "notty" style when colour is disabled.
+func (p *Printer) RenderMarkdown(s string) string {
 	if s == "" {
 		return s
 	}
@@ -14,11 +20,17 @@ func RenderMarkdown(s string) string {
 	// glamour supports built-in styles: dark, light, notty, etc. There isn't an official
 	// tokyonight style bundled. We'll pick dark and keep it readable.
 	// If a tokyonight theme becomes available, swap style here.
-	r, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithEnvironmentConfig(),
-		glamour.WithWordWrap(100),
-	)
+	wrap := 100
+	if p.Width > 0 && p.Width < wrap {
+		wrap = p.Width
+	}
+	opts := []glamour.TermRendererOption{glamour.WithWordWrap(wrap)}
+	if p.Color {
+		opts = append(opts, glamour.WithAutoStyle(), glamour.WithEnvironmentConfig())
+	} else {
+		opts = append(opts, glamour.WithStandardStyle("notty"))
+	}
+	r, err := glamour.NewTermRenderer(opts...)
 	if err != nil {
 		return s
 	}
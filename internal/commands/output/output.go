@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
-	"golang.org/x/term"
 )
 
 type Mode int
@@ -18,20 +17,24 @@ const (
 )
 
 var (
-	stdout = os.Stdout
-	stderr = os.Stderr
-
-	cInfo    = color.New(color.FgCyan, color.Bold)
-	cSuccess = color.New(color.FgGreen, color.Bold)
-	cWarn    = color.New(color.FgYellow, color.Bold)
-	cError   = color.New(color.FgRed, color.Bold)
-	cAuthor  = color.New(color.FgHiBlue, color.Bold)
-
-	currentMode = ModeAuto
+	cInfo    = style(color.FgCyan, color.Bold)
+	cSuccess = style(color.FgGreen, color.Bold)
+	cWarn    = style(color.FgYellow, color.Bold)
+	cError   = style(color.FgRed, color.Bold)
+	cAuthor  = style(color.FgHiBlue, color.Bold)
+	cDim     = style(color.Faint)
+
+	std = NewPrinter(os.Stdout, os.Stderr, ModeAuto)
 )
 
-// Init stores the chosen mode for later prints.
-func Init(mode Mode) { currentMode = mode }
+// style returns a colour that is always emitted when used. Whether to use
+// it at all is decided per Printer, so fatih/color's own detection, which
+// only looks at os.Stdout, must not disable it; color.NoColor is left alone.
+func style(attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	c.EnableColor()
+	return c
+}
 
 func parseMode(s string) (Mode, error) {
 	s = strings.ToLower(strings.TrimSpace(s))
@@ -47,8 +50,8 @@ func parseMode(s string) (Mode, error) {
 	}
 }
 
-// resolveAuto decides if color should be enabled for a given writer.
-func resolveAuto(w *os.File) bool {
+// resolveAuto decides if color should be enabled for a writer with the given TTY state.
+func resolveAuto(tty bool) bool {
 	if force := os.Getenv("CLICOLOR_FORCE"); force == "1" {
 		return true
 	}
@@ -58,57 +61,20 @@ func resolveAuto(w *os.File) bool {
 	if clicolor := os.Getenv("CLICOLOR"); clicolor == "0" {
 		return false
 	}
-	return term.IsTerminal(int(w.Fd()))
+	return tty
 }
 
-func colorEnabledFor(mode Mode, w *os.File) bool {
+func colorEnabledFor(mode Mode, tty bool) bool {
 	switch mode {
 	case ModeAlways:
 		return true
 	case ModeNever:
 		return false
 	default:
-		return resolveAuto(w)
+		return resolveAuto(tty)
 	}
 }
 
-// Writers allow tests to swap out dests.
-func SetWriters(out, err *os.File) { stdout, stderr = out, err }
-
-func Infof(_ Mode, format string, a ...any) { // mode param kept for backward compat, ignored
-	printWith(stdout, cInfo, format, a...)
-}
-
-func Successf(_ Mode, format string, a ...any) {
-	printWith(stdout, cSuccess, format, a...)
-}
-
-func Warnf(_ Mode, format string, a ...any) {
-	printWith(stderr, cWarn, format, a...)
-}
-
-func Errorf(_ Mode, format string, a ...any) {
-	printWith(stderr, cError, format, a...)
-}
-
-func Printf(_ Mode, format string, a ...any) {
-	printWith(stdout, color.New(), format, a...)
-}
-
-// Author color helpers
-func AuthorColorEnabled() bool { return colorEnabledFor(currentMode, stdout) }
-func ColorizeAuthor(name string) string {
-	if !AuthorColorEnabled() {
-		return name
-	}
-	return cAuthor.Sprintf("%s", name)
-}
-
-func printWith(w *os.File, c *color.Color, format string, a ...any) {
-	msg := fmt.Sprintf(format, a...)
-	if colorEnabledFor(currentMode, w) {
-		_, _ = c.Fprintf(w, msg)
-	} else {
-		_, _ = fmt.Fprint(w, msg)
-	}
-}
+// SetDefault replaces the Printer FromContext returns for a context without
+// one.
+func SetDefault(p *Printer) { std = p }
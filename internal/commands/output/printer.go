@@ -0,0 +1,137 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// Printer writes styled output to an io.Writer pair. Terminal capabilities are
+// explicit fields so commands can target any writer and tests can use buffers.
+type Printer struct {
+	Out io.Writer
+	Err io.Writer
+
+	TTY      bool // Out is an interactive terminal
+	Width    int  // terminal width in columns, 0 if unknown
+	Color    bool // emit ANSI colour on Out
+	ErrColor bool // emit ANSI colour on Err
+}
+
+// NewPrinter builds a Printer for out/err, detecting TTY and width when the
+// writers are terminals and resolving colour according to mode.
+func NewPrinter(out, err io.Writer, mode Mode) *Printer {
+	p := &Printer{Out: out, Err: err}
+	if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.TTY = true
+		if w, _, serr := term.GetSize(int(f.Fd())); serr == nil {
+			p.Width = w
+		}
+	}
+	errTTY := false
+	if f, ok := err.(*os.File); ok {
+		errTTY = term.IsTerminal(int(f.Fd()))
+	}
+	p.Color = colorEnabledFor(mode, p.TTY)
+	p.ErrColor = colorEnabledFor(mode, errTTY)
+	return p
+}
+
+func (p *Printer) Infof(format string, a ...any) {
+	printWith(p.Out, p.Color, cInfo, format, a...)
+}
+
+func (p *Printer) Successf(format string, a ...any) {
+	printWith(p.Out, p.Color, cSuccess, format, a...)
+}
+
+func (p *Printer) Warnf(format string, a ...any) {
+	printWith(p.Err, p.ErrColor, cWarn, format, a...)
+}
+
+func (p *Printer) Errorf(format string, a ...any) {
+	printWith(p.Err, p.ErrColor, cError, format, a...)
+}
+
+func (p *Printer) Printf(format string, a ...any) {
+	printWith(p.Out, false, nil, format, a...)
+}
+
+// ColorizeAuthor highlights a login when colour is enabled.
+func (p *Printer) ColorizeAuthor(name string) string {
+	if !p.Color {
+		return name
+	}
+	return cAuthor.Sprintf("%s", name)
+}
+
+// Good highlights a positive status (e.g. an approval) when colour is enabled.
+func (p *Printer) Good(s string) string {
+	if !p.Color {
+		return s
+	}
+	return cSuccess.Sprint(s)
+}
+
+// Bad highlights a blocking status (e.g. requested changes) when colour is enabled.
+func (p *Printer) Bad(s string) string {
+	if !p.Color {
+		return s
+	}
+	return cError.Sprint(s)
+}
+
+// Caution highlights a status that needs attention but does not block.
+func (p *Printer) Caution(s string) string {
+	if !p.Color {
+		return s
+	}
+	return cWarn.Sprint(s)
+}
+
+// Dim de-emphasises secondary text such as annotations.
+func (p *Printer) Dim(s string) string {
+	if !p.Color {
+		return s
+	}
+	return cDim.Sprint(s)
+}
+
+// Render writes items to Out using the selected format.
+func (p *Printer) Render(opts FormatOptions, items any) error {
+	return Render(p.Out, opts, items)
+}
+
+func printWith(w io.Writer, enabled bool, c *color.Color, format string, a ...any) {
+	msg := fmt.Sprintf(format, a...)
+	if enabled {
+		// Sprint, unlike Fprint, honours EnableColor for the reset code too.
+		_, _ = fmt.Fprint(w, c.Sprint(msg))
+	} else {
+		_, _ = fmt.Fprint(w, msg)
+	}
+}
+
+type printerKey struct{}
+
+// WithPrinter returns a context carrying p.
+func WithPrinter(ctx context.Context, p *Printer) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, printerKey{}, p)
+}
+
+// FromContext returns the Printer stored in ctx, or the package default.
+func FromContext(ctx context.Context) *Printer {
+	if ctx != nil {
+		if p, ok := ctx.Value(printerKey{}).(*Printer); ok && p != nil {
+			return p
+		}
+	}
+	return std
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestPrinterColor(t *testing.T) {
+	// fatih/color turns itself off when os.Stdout is not a terminal, as
+	// under go test; the Printer's own decision must win either way.
+	saved := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = saved })
+
+	for _, enabled := range []bool{true, false} {
+		var out, errOut bytes.Buffer
+		p := &Printer{Out: &out, Err: &errOut, Color: enabled, ErrColor: enabled}
+		p.Infof("info\n")
+		p.Warnf("warn\n")
+		p.Printf("plain\n")
+		got := out.String() + errOut.String() + p.Good("ok") + p.Dim("dim")
+		if hasANSI := strings.Contains(got, "\x1b["); hasANSI != enabled {
+			t.Errorf("Color=%v: output %q has ANSI escapes = %v", enabled, got, hasANSI)
+		}
+		want := "info\nplain\n"
+		if enabled {
+			want = "\x1b[36;1minfo\n\x1b[0mplain\n"
+		}
+		if out.String() != want {
+			t.Errorf("Color=%v: output %q, want %q", enabled, out.String(), want)
+		}
+	}
+}
+
+func TestColorEnabledFor(t *testing.T) {
+	t.Setenv("CLICOLOR_FORCE", "")
+	t.Setenv("CLICOLOR", "")
+	t.Setenv("NO_COLOR", "")
+	tests := []struct {
+		mode Mode
+		tty  bool
+		env  map[string]string
+		want bool
+	}{
+		{ModeAlways, false, nil, true},
+		{ModeNever, true, nil, false},
+		{ModeAuto, true, nil, true},
+		{ModeAuto, false, nil, false},
+		{ModeAuto, true, map[string]string{"NO_COLOR": "1"}, false},
+		{ModeAuto, true, map[string]string{"CLICOLOR": "0"}, false},
+		{ModeAuto, false, map[string]string{"CLICOLOR_FORCE": "1"}, true},
+	}
+	for _, tt := range tests {
+		for k, v := range tt.env {
+			t.Setenv(k, v)
+		}
+		if got := colorEnabledFor(tt.mode, tt.tty); got != tt.want {
+			t.Errorf("colorEnabledFor(%v, tty=%v) with %v = %v, want %v", tt.mode, tt.tty, tt.env, got, tt.want)
+		}
+		for k := range tt.env {
+			t.Setenv(k, "")
+		}
+	}
+}
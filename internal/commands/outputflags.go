@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/spf13/cobra"
+)
+
+// outputFlags holds the shared --output/--template/--jq flags of listing commands.
+type outputFlags struct {
+	format   string
+	template string
+	jq       string
+	// legacy shorthands kept for scripts written against older releases
+	json bool
+	urls bool
+}
+
+func addOutputFlags(cmd *cobra.Command) *outputFlags {
+	f := &outputFlags{}
+	cmd.Flags().StringVarP(&f.format, "output", "o", "", "Output format: json|yaml|csv|tsv|table|urls|template")
+	cmd.Flags().StringVar(&f.template, "template", "", "Format each item with a Go template, e.g. '{{.Number}} {{.Title}}'")
+	cmd.Flags().StringVar(&f.jq, "jq", "", "Filter JSON output using a jq expression")
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return output.Formats, cobra.ShellCompDirectiveNoFileComp
+	})
+	return f
+}
+
+// addLegacy registers the deprecated --json and --urls flags.
+func (f *outputFlags) addLegacy(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.json, "json", false, "Output JSON")
+	cmd.Flags().BoolVar(&f.urls, "urls", false, "Print only PR URLs (one per line)")
+	_ = cmd.Flags().MarkDeprecated("json", "use --output json")
+	_ = cmd.Flags().MarkDeprecated("urls", "use --output urls")
+}
+
+// addJSON registers --json as a plain shorthand for --output json, for
+// commands that are mainly used from scripts.
+func (f *outputFlags) addJSON(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.json, "json", false, "Output JSON (same as --output json)")
+}
+
+func (f *outputFlags) options() (output.FormatOptions, error) {
+	format, err := output.ParseFormat(f.format)
+	if err != nil {
+		return output.FormatOptions{}, err
+	}
+	if format == output.FormatHuman {
+		switch {
+		case f.json:
+			format = output.FormatJSON
+		case f.urls:
+			format = output.FormatURLs
+		}
+	}
+	return output.FormatOptions{Format: format, Template: f.template, JQ: f.jq}, nil
+}
@@ -7,12 +7,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/dennisloska/noji/internal/commands/output"
 	"github.com/dennisloska/noji/internal/config"
-	"github.com/dennisloska/noji/internal/opencode"
+	"github.com/dennisloska/noji/internal/diffpack"
 	"github.com/spf13/cobra"
 )
 
@@ -32,6 +31,16 @@ func newPRCmd() *cobra.Command {
 	cmd.AddCommand(newPRUpdateCmd())
 	cmd.AddCommand(newPRCommentsCmd())
 	cmd.AddCommand(newReviewsPRCmd())
+	cmd.AddCommand(newPRReplyCmd())
+	cmd.AddCommand(newPRResolveCmd())
+	cmd.AddCommand(newPRRespondCmd())
+	cmd.AddCommand(newPRSuggestionsCmd())
+	cmd.AddCommand(newPRReviewCmd())
+	cmd.AddCommand(newPRCheckoutCmd())
+	cmd.AddCommand(newPRWorktreesCmd())
+	cmd.AddCommand(newPRStatusCmd())
+	cmd.AddCommand(newPRCICmd())
+	cmd.AddCommand(newPRLintCmd())
 	return cmd
 }
 
@@ -40,9 +49,16 @@ func newPRCreateCmd() *cobra.Command {
 		Use:   "create",
 		Short: "Create a PR using opencode",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			output.Infof(output.ModeAuto, "Creating PR with model %s...\n", mustModel())
-			defer output.Successf(output.ModeAuto, "Done.\n")
-			return runPrompt("pr_create.txt")
+			p := printer(cmd)
+			p.Infof("Creating PR with model %s...\n", mustModel(config.TaskPRCreate))
+			if err := runPrompt(p, "pr_create.txt"); err != nil {
+				return err
+			}
+			if err := lintCurrentPRTitle(cmd); err != nil {
+				return err
+			}
+			p.Successf("Done.\n")
+			return nil
 		},
 	}
 }
@@ -52,9 +68,10 @@ func newPREditCmd() *cobra.Command {
 		Use:   "edit",
 		Short: "Edit PR fields for current branch",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			output.Infof(output.ModeAuto, "Editing PR description...\n")
-			defer output.Successf(output.ModeAuto, "Done.\n")
-			return runPREdit()
+			p := printer(cmd)
+			p.Infof("Editing PR description...\n")
+			defer p.Successf("Done.\n")
+			return runPREditBody(p)
 		},
 	}
 	cmd.AddCommand(newPREditTitleSubCmd())
@@ -67,9 +84,16 @@ func newPRUpdateCmd() *cobra.Command {
 		Use:   "update",
 		Short: "Update a PR using opencode",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			output.Infof(output.ModeAuto, "Updating PR with model %s...\n", mustModel())
-			defer output.Successf(output.ModeAuto, "Done.\n")
-			return runPrompt("pr_update.txt")
+			p := printer(cmd)
+			p.Infof("Updating PR with model %s...\n", mustModel(config.TaskPRUpdate))
+			if err := runPrompt(p, "pr_update.txt", reviewThreadsPrompt); err != nil {
+				return err
+			}
+			if err := lintCurrentPRTitle(cmd); err != nil {
+				return err
+			}
+			p.Successf("Done.\n")
+			return nil
 		},
 	}
 }
@@ -79,9 +103,10 @@ func newPREditTitleSubCmd() *cobra.Command {
 		Use:   "title",
 		Short: "Edit PR title for current branch",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			output.Infof(output.ModeAuto, "Editing PR title...\n")
-			defer output.Successf(output.ModeAuto, "Done.\n")
-			return runPREditTitle()
+			p := printer(cmd)
+			p.Infof("Editing PR title...\n")
+			defer p.Successf("Done.\n")
+			return runPREditTitle(cmd)
 		},
 	}
 }
@@ -91,9 +116,10 @@ func newPREditBodySubCmd() *cobra.Command {
 		Use:   "body",
 		Short: "Edit PR body for current branch",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			output.Infof(output.ModeAuto, "Editing PR body...\n")
-			defer output.Successf(output.ModeAuto, "Done.\n")
-			return runPREditBody()
+			p := printer(cmd)
+			p.Infof("Editing PR body...\n")
+			defer p.Successf("Done.\n")
+			return runPREditBody(p)
 		},
 	}
 }
@@ -105,21 +131,75 @@ func ensureGh() error {
 	return nil
 }
 
-func runPrompt(promptFile string) error {
-	model, err := config.GetModel()
+// runPrompt runs a prompt file interactively with the diff of the current
+// branch and any extra sections appended; the file name without .txt is the
+// task whose model is used.
+func runPrompt(p *output.Printer, promptFile string, sections ...func(p *output.Printer) string) error {
+	runner, err := newModelRunner(strings.TrimSuffix(promptFile, ".txt"), p.Warnf)
 	if err != nil {
 		return err
 	}
-	promptsDir, err := config.PromptsDir()
+	prompt, err := readPrompt(promptFile)
 	if err != nil {
 		return err
 	}
-	p := filepath.Join(promptsDir, promptFile)
+	prompt += branchDiffPrompt(p)
+	for _, section := range sections {
+		prompt += section(p)
+	}
+	return runner.stream(prompt)
+}
+
+// branchDiffPrompt returns the diff of the current branch against the
+// default branch, packed into diff.budget, as a prompt section. It returns ""
+// when there is no diff.
+func branchDiffPrompt(p *output.Printer) string {
+	base := defaultBranch()
+	out, err := exec.Command("git", "diff", "--no-color", "--no-ext-diff", "origin/"+base+"...HEAD").Output()
+	if err != nil {
+		if out, err = exec.Command("git", "diff", "--no-color", "--no-ext-diff", base+"...HEAD").Output(); err != nil {
+			p.Warnf("Could not diff against %s; the model has to look at the changes itself.\n", base)
+			return ""
+		}
+	}
+	files := diffpack.Parse(string(out))
+	if len(files) == 0 {
+		return ""
+	}
+	opts, err := config.GetDiffOptions()
+	if err != nil {
+		p.Warnf("%v\n", err)
+		return ""
+	}
+	packed := diffpack.Pack(files, opts)
+	if len(packed.LeftOut()) > 0 {
+		section, err := summarizedDiffPrompt(p, files, opts, base)
+		if section != "" {
+			return section
+		}
+		if err != nil {
+			p.Warnf("Summarising the diff failed, packing it instead: %v\n", err)
+		}
+	}
+	if report := packed.Report(); report != "" {
+		p.Infof("Diff packed: %s\n", report)
+	}
+	return fmt.Sprintf("\n\nDiff of the current branch against %s (%d files; files listed after the diff were left out to fit the context and show name and line counts only):\n%s",
+		base, len(files), packed.Text)
+}
+
+// readPrompt returns the contents of a prompt file, preferring the
+// repository's .noji/prompts over the user prompts dir.
+func readPrompt(promptFile string) (string, error) {
+	p, err := config.PromptPath(promptFile)
+	if err != nil {
+		return "", err
+	}
 	b, err := os.ReadFile(p)
 	if err != nil {
-		return fmt.Errorf("read prompt file %s: %w", p, err)
+		return "", fmt.Errorf("read prompt file %s: %w", p, err)
 	}
-	return opencode.RunWithPrompt(model, string(b))
+	return string(b), nil
 }
 
 func getPRForCurrentBranch(branch string) (*ghViewPR, error) {
@@ -170,19 +250,15 @@ func updatePRBody(number int, body string) error {
 	return nil
 }
 
-func mustModel() string {
-	m, err := config.GetModel()
-	if err != nil || m == "" {
+func mustModel(task string) string {
+	c, err := config.ResolveModel(task)
+	if err != nil || c.Model == "" {
 		return "<unknown>"
 	}
-	return m
-}
-
-func runPREdit() error {
-	return runPREditBody()
+	return c.Model
 }
 
-func runPREditBody() error {
+func runPREditBody(p *output.Printer) error {
 	// Ensure gh is available
 	if err := ensureGh(); err != nil {
 		return err
@@ -196,7 +272,7 @@ func runPREditBody() error {
 	if branch == "" {
 		return errors.New("could not determine current branch")
 	}
-	output.Infof(output.ModeAuto, "Current branch: %s\n", branch)
+	p.Infof("Current branch: %s\n", branch)
 
 	// Fetch PR for current branch
 	pr, err := getPRForCurrentBranch(branch)
@@ -216,13 +292,10 @@ func runPREditBody() error {
 	defer os.Remove(tmpFile)
 
 	// Resolve editor from config or override flag
-	ed, err := config.GetEditor()
+	ed, err := resolveEditor()
 	if err != nil {
 		return err
 	}
-	if ov := os.Getenv("NOJI_EDITOR_OVERRIDE"); strings.TrimSpace(ov) != "" {
-		ed = ov
-	}
 	// Open in editor
 	if err := openInEditor(ed, tmpFile); err != nil {
 		return err
@@ -237,7 +310,7 @@ func runPREditBody() error {
 
 	// If body unchanged, exit early
 	if newBody == pr.Body {
-		output.Infof(output.ModeAuto, "No changes detected.\n")
+		p.Infof("No changes detected.\n")
 		return nil
 	}
 
@@ -246,11 +319,12 @@ func runPREditBody() error {
 		return err
 	}
 
-	output.Successf(output.ModeAuto, "PR #%d updated.\n", pr.Number)
+	p.Successf("PR #%d updated.\n", pr.Number)
 	return nil
 }
 
-func runPREditTitle() error {
+func runPREditTitle(cmd *cobra.Command) error {
+	p := printer(cmd)
 	// Ensure gh is available
 	if err := ensureGh(); err != nil {
 		return err
@@ -264,7 +338,7 @@ func runPREditTitle() error {
 	if branch == "" {
 		return errors.New("could not determine current branch")
 	}
-	output.Infof(output.ModeAuto, "Current branch: %s\n", branch)
+	p.Infof("Current branch: %s\n", branch)
 
 	// Fetch PR for current branch
 	pr, err := getPRForCurrentBranch(branch)
@@ -282,14 +356,11 @@ func runPREditTitle() error {
 	}
 	defer os.Remove(tmpFile)
 
-	// Resolve editor from config
-	ed, err := config.GetEditor()
+	// Resolve editor from config or override flag
+	ed, err := resolveEditor()
 	if err != nil {
 		return err
 	}
-	if ov := os.Getenv("NOJI_EDITOR_OVERRIDE"); strings.TrimSpace(ov) != "" {
-		ed = ov
-	}
 	if err := openInEditor(ed, tmpFile); err != nil {
 		return err
 	}
@@ -302,18 +373,21 @@ func runPREditTitle() error {
 	newTitle := strings.TrimRight(string(b), "\r\n")
 
 	if newTitle == pr.Title {
-		output.Infof(output.ModeAuto, "No changes detected.\n")
+		p.Infof("No changes detected.\n")
 		return nil
 	}
 
 	if strings.TrimSpace(newTitle) == "" {
 		return errors.New("title cannot be empty")
 	}
+	if newTitle, err = checkTitle(cmd, newTitle, branch); err != nil {
+		return err
+	}
 
 	if err := updatePRTitle(pr.Number, newTitle); err != nil {
 		return err
 	}
-	output.Successf(output.ModeAuto, "PR #%d title updated.\n", pr.Number)
+	p.Successf("PR #%d title updated.\n", pr.Number)
 	return nil
 }
 
@@ -326,6 +400,34 @@ func getCurrentBranch() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// gitRoot returns the top-level directory of the current git repository.
+func gitRoot() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// currentPR returns the open PR of the current branch or an error if there is none.
+func currentPR() (*ghViewPR, error) {
+	branch, err := getCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("get current branch: %w", err)
+	}
+	if branch == "" {
+		return nil, errors.New("could not determine current branch")
+	}
+	pr, err := getPRForCurrentBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, errors.New("no open PR found for current branch. Create one first with 'gh pr create' or 'noji pr create'")
+	}
+	return pr, nil
+}
+
 func createTempFile(content string) (string, error) {
 	tmpFile, err := os.CreateTemp("", "noji-pr-edit-*.md")
 	if err != nil {
@@ -340,6 +442,20 @@ func createTempFile(content string) (string, error) {
 	return tmpFile.Name(), nil
 }
 
+// resolveEditor returns the editor from the --editor flag or config.
+func resolveEditor() (string, error) {
+	return config.GetEditor()
+}
+
+// editorCommand builds the command for an editor setting such as "code -w".
+func editorCommand(editor, filename string) *exec.Cmd {
+	parts := strings.Fields(editor)
+	if len(parts) == 0 {
+		parts = []string{"vim"}
+	}
+	return exec.Command(parts[0], append(parts[1:], filename)...)
+}
+
 func openInEditor(editor, filename string) error {
 	// Use configured editor strictly (no env fallback here); then fallback to common ones
 	candidates := []string{}
@@ -349,7 +465,7 @@ func openInEditor(editor, filename string) error {
 	candidates = append(candidates, "vim", "vi", "nvim")
 	var lastErr error
 	for _, ed := range candidates {
-		cmd := exec.Command(ed, filename)
+		cmd := editorCommand(ed, filename)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -0,0 +1,419 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// worktreeBranchPrefix names the local branches of PR worktrees:
+// noji/pr/<OWNER>/<REPO>/<N>, so each worktree knows the repository of its PR.
+const worktreeBranchPrefix = "noji/pr/"
+
+// legacyWorktreeBranchPrefix named branches noji/pr-<N> before the
+// repository was part of the name.
+const legacyWorktreeBranchPrefix = "noji/pr-"
+
+// listedPR is an entry of the cached `pr reviews` listing, addressed as @N.
+type listedPR struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// prWorktree is a git worktree created by `noji pr checkout --worktree`.
+type prWorktree struct {
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+	// Repo is empty for worktrees of older releases; their state is not
+	// looked up, so prune leaves them alone.
+	Repo   string `json:"repo,omitempty"`
+	Number int    `json:"number"`
+	State  string `json:"state,omitempty"`
+}
+
+// Ref is OWNER/REPO#N, or #N when the repository is not known.
+func (wt prWorktree) Ref() string {
+	return fmt.Sprintf("%s#%d", wt.Repo, wt.Number)
+}
+
+func newPRCheckoutCmd() *cobra.Command {
+	var repo string
+	var worktree bool
+	var cdScript bool
+
+	cmd := &cobra.Command{
+		Use:   "checkout <number|url|@index>",
+		Short: "Check out a PR, optionally into an isolated git worktree",
+		Long: "Check out a PR by number, URL or @index from the last `noji pr reviews` listing.\n" +
+			"With --worktree the PR head is fetched (forks included) into a worktree so your\n" +
+			"current checkout stays untouched. Use `eval \"$(noji pr checkout 12 --worktree --cd)\"`\n" +
+			"to change into it.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			repo, number, err := resolvePRArg(args[0], repo)
+			if err != nil {
+				return err
+			}
+			if !worktree {
+				c := exec.Command("gh", "pr", "checkout", strconv.Itoa(number), "--repo", repo)
+				c.Stdin, c.Stdout, c.Stderr = os.Stdin, p.Out, p.Err
+				return c.Run()
+			}
+			path, reused, err := checkoutWorktree(repo, number)
+			if err != nil {
+				return err
+			}
+			if cdScript {
+				p.Printf("cd %s\n", shellQuote(path))
+				return nil
+			}
+			if reused {
+				p.Successf("Updated worktree for %s#%d:\n", repo, number)
+			} else {
+				p.Successf("Created worktree for %s#%d:\n", repo, number)
+			}
+			p.Printf("%s\n", path)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository (OWNER/REPO); defaults to the current repo")
+	cmd.Flags().BoolVar(&worktree, "worktree", false, "Check out into a git worktree instead of switching branches")
+	cmd.Flags().BoolVar(&cdScript, "cd", false, "With --worktree, print a `cd` command for eval instead of the path")
+	return cmd
+}
+
+func newPRWorktreesCmd() *cobra.Command {
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "worktrees",
+		Short: "List PR worktrees created by `noji pr checkout --worktree`",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			wts, err := listPRWorktrees(true)
+			if err != nil {
+				return err
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, wts)
+			}
+			if len(wts) == 0 {
+				p.Infof("No PR worktrees.\n")
+				return nil
+			}
+			for _, wt := range wts {
+				state := strings.ToLower(wt.State)
+				if wt.Repo == "" {
+					state = "unknown"
+				}
+				p.Printf("%-30s %-8s %s\n", wt.Ref(), state, wt.Path)
+			}
+			return nil
+		},
+	}
+	outFlags = addOutputFlags(cmd)
+	cmd.AddCommand(newPRWorktreesPruneCmd())
+	return cmd
+}
+
+func newPRWorktreesPruneCmd() *cobra.Command {
+	var dryRun bool
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove worktrees of merged or closed PRs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			wts, err := listPRWorktrees(true)
+			if err != nil {
+				return err
+			}
+			removed := 0
+			for _, wt := range wts {
+				if wt.State != "MERGED" && wt.State != "CLOSED" {
+					continue
+				}
+				if dryRun {
+					p.Printf("Would remove %s (%s %s)\n", wt.Path, wt.Ref(), strings.ToLower(wt.State))
+					continue
+				}
+				if err := removeWorktree(wt, force); err != nil {
+					p.Warnf("%v\n", err)
+					continue
+				}
+				removed++
+				p.Successf("Removed %s (%s %s)\n", wt.Path, wt.Ref(), strings.ToLower(wt.State))
+			}
+			if !dryRun && removed == 0 {
+				p.Infof("Nothing to prune.\n")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would be removed")
+	cmd.Flags().BoolVar(&force, "force", false, "Remove worktrees with uncommitted changes")
+	return cmd
+}
+
+// resolvePRArg accepts @N (index into the last `pr reviews` listing) in
+// addition to what parsePRRef accepts.
+func resolvePRArg(arg, repo string) (string, int, error) {
+	if idx, ok := strings.CutPrefix(arg, "@"); ok {
+		n, err := strconv.Atoi(idx)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid index: %s", arg)
+		}
+		listed, err := readListedPRs()
+		if err != nil {
+			return "", 0, err
+		}
+		if n < 1 || n > len(listed) {
+			return "", 0, fmt.Errorf("no PR @%d in the last `noji pr reviews` listing (%d entries)", n, len(listed))
+		}
+		return listed[n-1].Repo, listed[n-1].Number, nil
+	}
+	return parsePRRef(arg, repo)
+}
+
+func listedPRsPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "noji", "last_reviews.json"), nil
+}
+
+// writeListedPRs caches the order of a `pr reviews` listing for @N lookups.
+func writeListedPRs(items []reviewItem) error {
+	path, err := listedPRsPath()
+	if err != nil {
+		return err
+	}
+	listed := make([]listedPR, 0, len(items))
+	for _, it := range items {
+		repo, _ := repoFromPRURL(it.HTMLURL)
+		listed = append(listed, listedPR{Repo: repo, Number: it.Number, URL: it.HTMLURL})
+	}
+	b, err := json.Marshal(listed)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func readListedPRs() ([]listedPR, error) {
+	path, err := listedPRsPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("no cached listing; run `noji pr reviews` first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var listed []listedPR
+	if err := json.Unmarshal(b, &listed); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return listed, nil
+}
+
+// checkoutWorktree creates or fast-forwards the worktree of PR number. The
+// head is fetched from the base repository's pull/<N>/head ref, which also
+// covers PRs from forks.
+func checkoutWorktree(repo string, number int) (path string, reused bool, err error) {
+	root, err := gitRoot()
+	if err != nil {
+		return "", false, err
+	}
+	remote, err := remoteFor(root, repo)
+	if err != nil {
+		return "", false, err
+	}
+	path, err = worktreePath(root, repo, number)
+	if err != nil {
+		return "", false, err
+	}
+	ref := fmt.Sprintf("pull/%d/head", number)
+	branch := worktreeBranch(repo, number)
+
+	if _, statErr := os.Stat(filepath.Join(path, ".git")); statErr == nil {
+		if err := runGit(path, "fetch", remote, ref); err != nil {
+			return "", false, err
+		}
+		if err := runGit(path, "merge", "--ff-only", "FETCH_HEAD"); err != nil {
+			return "", false, fmt.Errorf("worktree %s has diverged from the PR head: %w", path, err)
+		}
+		return path, true, nil
+	}
+	if err := runGit(root, "fetch", remote, ref); err != nil {
+		return "", false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", false, err
+	}
+	if err := runGit(root, "worktree", "add", "-B", branch, path, "FETCH_HEAD"); err != nil {
+		return "", false, err
+	}
+	return path, false, nil
+}
+
+// worktreePath places worktrees under worktrees.dir/OWNER/REPO/pr-<N>, or
+// next to the main checkout in <root>-worktrees/pr-<N> when unset.
+func worktreePath(root, repo string, number int) (string, error) {
+	dir, err := config.GetWorktreesDir()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("pr-%d", number)
+	if dir == "" {
+		return filepath.Join(filepath.Dir(root), filepath.Base(root)+"-worktrees", name), nil
+	}
+	return filepath.Join(dir, filepath.FromSlash(repo), name), nil
+}
+
+// worktreeBranch is the local branch of the worktree of repo#number.
+func worktreeBranch(repo string, number int) string {
+	return fmt.Sprintf("%s%s/%d", worktreeBranchPrefix, repo, number)
+}
+
+// parseWorktreeBranch returns the repository and PR number of a worktree
+// branch; repo is "" for legacy branches.
+func parseWorktreeBranch(branch string) (repo string, number int, ok bool) {
+	if rest, found := strings.CutPrefix(branch, worktreeBranchPrefix); found {
+		i := strings.LastIndex(rest, "/")
+		if i < 0 {
+			return "", 0, false
+		}
+		n, err := strconv.Atoi(rest[i+1:])
+		return rest[:i], n, err == nil && rest[:i] != ""
+	}
+	if n, found := strings.CutPrefix(branch, legacyWorktreeBranchPrefix); found {
+		number, err := strconv.Atoi(n)
+		return "", number, err == nil
+	}
+	return "", 0, false
+}
+
+// remoteFor picks the git remote that points at repo. PR heads only exist
+// in their own repository, so a checkout without such a remote is an error.
+func remoteFor(root, repo string) (string, error) {
+	c := exec.Command("git", "remote", "-v")
+	c.Dir = root
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("git remote failed: %w", err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		u := strings.TrimSuffix(strings.TrimSuffix(fields[1], "/"), ".git")
+		if strings.HasSuffix(strings.ToLower(u), "/"+strings.ToLower(repo)) || strings.HasSuffix(strings.ToLower(u), ":"+strings.ToLower(repo)) {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no git remote of %s points at %s; add one (git remote add upstream <url>) or run this in a clone of %s", root, repo, repo)
+}
+
+// listPRWorktrees returns the worktrees on PR worktree branches, with the
+// PR state looked up in each worktree's repository when withState is set.
+func listPRWorktrees(withState bool) ([]prWorktree, error) {
+	out, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git worktree list failed: %w", err)
+	}
+	var wts []prWorktree
+	var path string
+	for _, line := range strings.Split(string(out), "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+			continue
+		}
+		branch, ok := strings.CutPrefix(line, "branch refs/heads/")
+		if !ok {
+			continue
+		}
+		repo, number, ok := parseWorktreeBranch(branch)
+		if !ok {
+			continue
+		}
+		wts = append(wts, prWorktree{Path: path, Branch: branch, Repo: repo, Number: number})
+	}
+	if !withState {
+		return wts, nil
+	}
+	for i := range wts {
+		if wts[i].Repo == "" {
+			continue
+		}
+		state, err := prState(wts[i].Repo, wts[i].Number)
+		if err != nil {
+			return nil, err
+		}
+		wts[i].State = state
+	}
+	return wts, nil
+}
+
+func prState(repo string, number int) (string, error) {
+	out, err := exec.Command("gh", "pr", "view", strconv.Itoa(number), "--repo", repo, "--json", "state", "-q", ".state").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return "", fmt.Errorf("gh pr view failed: %s", string(ee.Stderr))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func removeWorktree(wt prWorktree, force bool) error {
+	args := []string{"worktree", "remove", wt.Path}
+	if force {
+		args = []string{"worktree", "remove", "--force", wt.Path}
+	}
+	if err := runGit("", args...); err != nil {
+		return err
+	}
+	return runGit("", "branch", "-D", wt.Branch)
+}
+
+// runGit runs git in dir and includes its output in the error.
+func runGit(dir string, args ...string) error {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/cilog"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// failedJob is a failing CI job with its raw log.
+type failedJob struct {
+	Name string
+	URL  string
+	Log  string
+}
+
+var actionsJobRe = regexp.MustCompile(`/actions/runs/\d+/job/(\d+)`)
+
+func newPRCICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Work with the CI checks of the current branch's PR",
+	}
+	cmd.AddCommand(newPRCIExplainCmd())
+	return cmd
+}
+
+func newPRCIExplainCmd() *cobra.Command {
+	var logFiles []string
+	var extractOnly bool
+	var opts = cilog.DefaultOptions
+
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain failing GitHub Actions jobs of the current PR using their logs and opencode",
+		Long: "Downloads the logs of failing GitHub Actions jobs for the current branch's PR,\n" +
+			"extracts the failure region (test failures, panics, compiler errors) and asks\n" +
+			"the model for a root cause and a fix. Use --log-file to work on saved logs offline.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			var jobs []failedJob
+			if len(logFiles) > 0 {
+				for _, f := range logFiles {
+					b, err := os.ReadFile(f)
+					if err != nil {
+						return fmt.Errorf("read log file: %w", err)
+					}
+					jobs = append(jobs, failedJob{Name: filepath.Base(f), Log: string(b)})
+				}
+			} else {
+				if err := ensureGh(); err != nil {
+					return err
+				}
+				var err error
+				if jobs, err = fetchFailedJobs(p.Warnf); err != nil {
+					return err
+				}
+				if len(jobs) == 0 {
+					p.Successf("No failing GitHub Actions jobs.\n")
+					return nil
+				}
+			}
+
+			root, _ := gitRoot()
+			var template string
+			var runner *modelRunner
+			if !extractOnly {
+				var err error
+				if template, err = readPrompt("ci_explain.txt"); err != nil {
+					return err
+				}
+				if runner, err = newModelRunner(config.TaskCIExplain, p.Warnf); err != nil {
+					return err
+				}
+			}
+			for _, job := range jobs {
+				regions := cilog.Extract(job.Log, opts)
+				excerpt := cilog.Format(regions)
+				if extractOnly {
+					p.Infof("== %s\n", job.Name)
+					p.Printf("%s\n", excerpt)
+					continue
+				}
+				p.Infof("Explaining %s with model %s...\n", job.Name, runner.Model())
+				prompt := buildCIPrompt(template, job, excerpt, root, cilog.FileRefs(regions))
+				out, err := runner.capture(prompt)
+				if err != nil {
+					p.Warnf("%s: %v\n", job.Name, err)
+					continue
+				}
+				p.Printf("%s\n", strings.TrimRight(p.RenderMarkdown(out), "\n"))
+				if job.URL != "" {
+					p.Printf("%s\n\n", job.URL)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&logFiles, "log-file", nil, "Explain a saved job log instead of downloading (repeatable)")
+	cmd.Flags().BoolVar(&extractOnly, "extract-only", false, "Print the extracted failure regions without calling the model")
+	cmd.Flags().IntVar(&opts.Before, "before", opts.Before, "Log lines kept before each failure marker")
+	cmd.Flags().IntVar(&opts.After, "after", opts.After, "Log lines kept after each failure marker")
+	cmd.Flags().IntVar(&opts.MaxLines, "max-lines", opts.MaxLines, "Maximum log lines sent to the model per job")
+	return cmd
+}
+
+// fetchFailedJobs downloads the logs of failing Actions jobs of the current
+// PR. Checks from other CI systems are reported through warnf and skipped.
+func fetchFailedJobs(warnf func(string, ...any)) ([]failedJob, error) {
+	repo, err := currentRepo()
+	if err != nil {
+		return nil, err
+	}
+	c := exec.Command("gh", "pr", "checks", "--json", "name,workflow,bucket,link")
+	out, err := c.Output()
+	var checks []struct {
+		ghCheck
+		Link string `json:"link"`
+	}
+	if jerr := json.Unmarshal(out, &checks); jerr != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh pr checks failed: %s", string(ee.Stderr))
+		}
+		return nil, fmt.Errorf("parse gh pr checks json: %w", jerr)
+	}
+	var jobs []failedJob
+	for _, ch := range checks {
+		if ch.Bucket != "fail" {
+			continue
+		}
+		name := ch.Name
+		if ch.Workflow != "" && ch.Workflow != ch.Name {
+			name = ch.Workflow + " / " + ch.Name
+		}
+		m := actionsJobRe.FindStringSubmatch(ch.Link)
+		if m == nil {
+			warnf("Skipping %s: not a GitHub Actions job (%s)\n", name, ch.Link)
+			continue
+		}
+		path := fmt.Sprintf("repos/%s/actions/jobs/%s/logs", repo, m[1])
+		log, err := exec.Command("gh", "api", path).Output()
+		if err != nil {
+			var ee *exec.ExitError
+			if errors.As(err, &ee) {
+				return nil, fmt.Errorf("gh api %s failed: %s", path, string(ee.Stderr))
+			}
+			return nil, err
+		}
+		jobs = append(jobs, failedJob{Name: name, URL: ch.Link, Log: string(log)})
+	}
+	return jobs, nil
+}
+
+// buildCIPrompt adds the log excerpt and the local files it mentions.
+func buildCIPrompt(template string, job failedJob, excerpt, root string, refs []cilog.FileRef) string {
+	var b strings.Builder
+	b.WriteString(template)
+	fmt.Fprintf(&b, "\n\nFailing job: %s\n\nLog excerpt:\n%s\n", job.Name, excerpt)
+	if root == "" {
+		return b.String()
+	}
+	shown := 0
+	for _, ref := range refs {
+		if shown == 5 {
+			break
+		}
+		// The log comes from the PR's code: only read files inside root.
+		rel := ref.Path
+		full, ok := localFile(root, rel)
+		if filepath.IsAbs(rel) {
+			// CI paths are absolute on the runner; try the repo-relative tail.
+			full, rel, ok = findLocalFile(root, rel)
+		}
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s\n", fileContext(full, rel, ref.Line, 15))
+		shown++
+	}
+	return b.String()
+}
+
+// findLocalFile maps a runner path like /home/runner/work/repo/repo/pkg/x.go
+// to the longest existing repo-relative suffix.
+func findLocalFile(root, abs string) (full, rel string, ok bool) {
+	parts := strings.Split(filepath.ToSlash(abs), "/")
+	for i := 1; i < len(parts); i++ {
+		rel = strings.Join(parts[i:], "/")
+		if full, ok = localFile(root, rel); ok {
+			return full, rel, true
+		}
+	}
+	return "", "", false
+}
+
+// localFile returns rel joined to root if it is an existing file that stays
+// inside root, also after resolving symlinks.
+func localFile(root, rel string) (string, bool) {
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) {
+		return "", false
+	}
+	full := filepath.Join(root, rel)
+	resolved, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", false
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", false
+	}
+	if inside, err := filepath.Rel(realRoot, resolved); err != nil || !filepath.IsLocal(inside) {
+		return "", false
+	}
+	return full, true
+}
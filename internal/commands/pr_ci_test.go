@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dennisloska/noji/internal/cilog"
+)
+
+func TestBuildCIPromptStaysInRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repo")
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(dir, "secret.yml"), "token: SECRET\n")
+	write(filepath.Join(root, "pkg", "x.go"), "package pkg\n")
+	if err := os.Symlink(filepath.Join(dir, "secret.yml"), filepath.Join(root, "link.yml")); err != nil {
+		t.Fatal(err)
+	}
+	refs := []cilog.FileRef{
+		{Path: "../secret.yml", Line: 1},
+		{Path: "link.yml", Line: 1},
+		{Path: filepath.ToSlash(filepath.Join(root, "..", "secret.yml")), Line: 1},
+		{Path: "pkg/x.go", Line: 1},
+	}
+	got := buildCIPrompt("T", failedJob{Name: "test"}, "log", root, refs)
+	if strings.Contains(got, "SECRET") {
+		t.Errorf("prompt includes a file outside the repository:\n%s", got)
+	}
+	if !strings.Contains(got, "package pkg") {
+		t.Errorf("prompt is missing pkg/x.go:\n%s", got)
+	}
+}
@@ -43,15 +43,37 @@ type ghReviewComment struct {
 	CreatedAt string `json:"created_at"`
 	User      struct {
 		Login string `json:"login"`
+		ID    int64  `json:"id"`
 	} `json:"user"`
 	Path           string `json:"path"`
+	Line           *int   `json:"line"`
+	StartLine      *int   `json:"start_line"`
+	Side           string `json:"side"`
 	DiffHunk       string `json:"diff_hunk"`
 	InReplyToID    *int64 `json:"in_reply_to_id"`
 	PullRequestURL string `json:"pull_request_url"`
 }
 
+// severityOrder ranks the severities used for comments and review findings.
+var severityOrder = map[string]int{"blocker": 5, "high": 4, "medium": 3, "low": 2, "info": 1}
+
+// ghReview is a submitted PR review (top-level body plus state).
+type ghReview struct {
+	ID          int64  `json:"id"`
+	Body        string `json:"body"`
+	State       string `json:"state"` // APPROVED|CHANGES_REQUESTED|COMMENTED|DISMISSED|PENDING
+	HTMLURL     string `json:"html_url"`
+	SubmittedAt string `json:"submitted_at"`
+	User        struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// commentBotRe matches logins of bots whose PR comments are not human feedback.
+var commentBotRe = regexp.MustCompile(`(?i)(\[bot\]$|-bot$|^github-actions(\[bot\])?$|^dependabot(\[bot\])?$|^renovate(\[bot\]|-bot)?$|^snyk(-bot)?$|^mergify(\[bot\])?$|copilot)`)
+
 type classifiedComment struct {
-	Kind      string // issue|review
+	Kind      string // issue|review|summary
 	ID        int64
 	Author    string
 	CreatedAt string
@@ -59,6 +81,8 @@ type classifiedComment struct {
 	URL       string
 	Path      string
 	ParentID  int64  // 0 if none
+	ThreadID  string // review thread node id (set with --unresolved)
+	State     string // review state, summaries only
 	Severity  string // from opencode classification
 }
 
@@ -70,210 +94,282 @@ type prWithComments struct {
 	Author   string
 	Comments []classifiedComment
 	Priority string // derived from comments severities
+	// ApprovalStatus summarises each reviewer's latest review:
+	// approved|changes_requested|commented|none
+	ApprovalStatus string
 }
 
 func newPRCommentsCmd() *cobra.Command {
 	var repo string
 	var state string
-	var jsonOut bool
 	var excludeBots bool
 	var includeDrafts bool
 	var limit int
 	var since string
 	var doClassify bool
 	var renderMD bool
-	var urlsOnly bool
+	var unresolved bool
+	var outFlags *outputFlags
 
 	cmd := &cobra.Command{
 		Use:   "comments",
 		Short: "List your PRs with human comments (optional severity classification)",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Who am I
-			me, err := whoAmI()
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
 			if err != nil {
 				return err
 			}
-			botRe := regexp.MustCompile(`(?i)(\[bot\]$|-bot$|^github-actions(\[bot\])?$|^dependabot(\[bot\])?$|^renovate(\[bot\]|-bot)?$|^snyk(-bot)?$|^mergify(\[bot\])?$|copilot)`)
-
-			// Find PRs authored by me (prefilter: comments>0; optional since)
-			// Important: limit applies to number of PRs processed overall
-			prs, err := listMyPRs(me, repo, state, includeDrafts, limit, since)
+			results, err := collectPRComments(commentsQuery{
+				Repo:          repo,
+				State:         state,
+				ExcludeBots:   excludeBots,
+				IncludeDrafts: includeDrafts,
+				Limit:         limit,
+				Since:         since,
+				Classify:      doClassify,
+				Unresolved:    unresolved,
+			})
 			if err != nil {
 				return err
 			}
-			if len(prs) == 0 {
-				output.Warnf(output.ModeAuto, "No PRs found.\n")
+			if len(results) == 0 && outOpts.Human() {
+				p.Warnf("No PRs found.\n")
 				return nil
 			}
 
-			var results []prWithComments
-			for _, pr := range prs {
-				repoFull, err := repoFromPRURL(pr.HTMLURL)
-				if err != nil {
-					continue
-				}
-				// Fast probes: check for human comments with per_page=1
-				hasHuman, err := hasHumanComments(repoFull, pr.Number, botRe)
-				if err != nil {
-					return err
-				}
-				if !hasHuman && excludeBots {
-					// Skip heavy fetch, no human activity
-					continue
-				}
-				// Fetch full comments only when necessary
-				issues, err := fetchIssueComments(repoFull, pr.Number)
-				if err != nil {
-					return err
-				}
-				reviews, err := fetchReviewComments(repoFull, pr.Number)
-				if err != nil {
-					return err
-				}
-				var cc []classifiedComment
-				for _, ic := range issues {
-					if excludeBots && botRe.MatchString(ic.User.Login) {
-						continue
-					}
-					cc = append(cc, classifiedComment{
-						Kind:      "issue",
-						ID:        ic.ID,
-						Author:    ic.User.Login,
-						CreatedAt: ic.CreatedAt,
-						Body:      ic.Body,
-						URL:       ic.HTMLURL,
-					})
-				}
-				// Build threading for review comments
-				idToIndex := map[int64]int{}
-				for _, rc := range reviews {
-					if excludeBots && botRe.MatchString(rc.User.Login) {
-						continue
-					}
-					parent := int64(0)
-					if rc.InReplyToID != nil {
-						parent = *rc.InReplyToID
-					}
-					cc = append(cc, classifiedComment{
-						Kind:      "review",
-						ID:        rc.ID,
-						Author:    rc.User.Login,
-						CreatedAt: rc.CreatedAt,
-						Body:      rc.Body,
-						URL:       rc.HTMLURL,
-						Path:      rc.Path,
-						ParentID:  parent,
-					})
-					idToIndex[rc.ID] = len(cc) - 1
-				}
-				// Optionally classify severity per comment using opencode
-				priority := "none"
-				if doClassify {
-					model, _ := config.GetModel()
-					for i := range cc {
-						sev, _ := classifyComment(model, cc[i].Body)
-						cc[i].Severity = sev
-					}
-					// Compute PR priority: highest severity among comments
-					priority = derivePriority(cc)
-				}
-				// Sort comments by time
-				sort.Slice(cc, func(i, j int) bool { return cc[i].CreatedAt < cc[j].CreatedAt })
-				results = append(results, prWithComments{
-					Repo:     repoFull,
-					Number:   pr.Number,
-					Title:    pr.Title,
-					URL:      pr.HTMLURL,
-					Author:   pr.User.Login,
-					Comments: cc,
-					Priority: priority,
-				})
-			}
-
-			if jsonOut {
-				enc := json.NewEncoder(cmd.OutOrStdout())
-				enc.SetIndent("", "  ")
-				return enc.Encode(results)
+			if !outOpts.Human() {
+				return p.Render(outOpts, results)
 			}
 
-			if urlsOnly {
-				for _, r := range results {
-					if strings.TrimSpace(r.URL) != "" {
-						output.Printf(output.ModeNever, "%s\n", r.URL)
-					}
-				}
-				return nil
-			}
-
-			// Human output
-			for _, r := range results {
-				output.Infof(output.ModeAuto, "PR: #%d %s\n", r.Number, r.Title)
-				output.Printf(output.ModeAuto, "Repo: %s\n", r.Repo)
-				// Raw PR URL only (no clickable label line)
-				output.Printf(output.ModeAuto, "URL:  %s\n", r.URL)
-				if doClassify {
-					output.Printf(output.ModeAuto, "Priority: %s\n", r.Priority)
-				}
-				if len(r.Comments) == 0 {
-					output.Warnf(output.ModeAuto, "  (no human comments)\n\n")
-					continue
-				}
-				// Group review replies by thread using ParentID, but print simply with indentation
-				indexByID := map[int64]int{}
-				for i := range r.Comments {
-					indexByID[r.Comments[i].ID] = i
-				}
-				for _, c := range r.Comments {
-					indent := "  "
-					if c.Kind == "review" && c.ParentID != 0 {
-						indent = "    ↳ "
-					}
-					sev := c.Severity
-					if !doClassify || sev == "" {
-						sev = "-"
-					}
-					author := c.Author
-					if output.AuthorColorEnabled() {
-						author = output.ColorizeAuthor(author)
-					}
-					// header line with severity and author
-					header := fmt.Sprintf("%s- [%s] @%s", indent, sev, author)
-					if c.Path != "" {
-						header += fmt.Sprintf(" (%s)", c.Path)
-					}
-					output.Printf(output.ModeAuto, "%s\n", header)
-					// body on its own line; render as markdown to ANSI
-					if strings.TrimSpace(c.Body) != "" {
-						var rendered string
-						if renderMD {
-							rendered = output.RenderMarkdown(c.Body)
-						} else {
-							rendered = oneLiner(c.Body)
-						}
-						for _, line := range strings.Split(strings.TrimRight(rendered, "\n"), "\n") {
-							output.Printf(output.ModeAuto, "%s  %s\n", indent, line)
-						}
-					}
-				}
-				output.Printf(output.ModeAuto, "\n")
-			}
+			renderPRComments(p, results, doClassify, renderMD)
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&repo, "repo", "", "Limit to a single repo (OWNER/REPO). If empty, searches across accessible repos")
 	cmd.Flags().StringVar(&state, "state", "open", "PR state: open|closed|all")
-	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output JSON")
 	cmd.Flags().BoolVar(&excludeBots, "no-bots", true, "Exclude bot comments")
 	cmd.Flags().BoolVar(&includeDrafts, "drafts", true, "Include draft PRs")
 	cmd.Flags().IntVar(&limit, "limit", 0, "Limit number of PRs (0=all)")
 	cmd.Flags().StringVar(&since, "since", "", "Only PRs updated on/after YYYY-MM-DD")
 	cmd.Flags().BoolVar(&doClassify, "classify", false, "Classify comment severity and derive PR priority (uses opencode)")
+	cmd.Flags().BoolVar(&unresolved, "unresolved", false, "Hide review threads that have been resolved")
 	cmd.Flags().BoolVar(&renderMD, "md", true, "Render comment bodies as Markdown to ANSI (requires a compatible terminal)")
-	cmd.Flags().BoolVar(&urlsOnly, "urls", false, "Print only PR URLs (one per line)")
+	outFlags = addOutputFlags(cmd)
+	outFlags.addLegacy(cmd)
 	return cmd
 }
 
+// commentsQuery selects the PRs and comments collectPRComments returns.
+type commentsQuery struct {
+	Repo          string
+	State         string
+	ExcludeBots   bool
+	IncludeDrafts bool
+	Limit         int
+	Since         string
+	Classify      bool
+	Unresolved    bool
+}
+
+// collectPRComments finds my PRs and gathers their human comments.
+func collectPRComments(q commentsQuery) ([]prWithComments, error) {
+	// Who am I
+	me, err := whoAmI()
+	if err != nil {
+		return nil, err
+	}
+	botRe := commentBotRe
+
+	// Find PRs authored by me (prefilter: comments>0; optional since)
+	// Important: limit applies to number of PRs processed overall
+	prs, err := listMyPRs(me, q.Repo, q.State, q.IncludeDrafts, q.Limit, q.Since)
+	if err != nil {
+		return nil, err
+	}
+	var classifier *modelRunner
+	if q.Classify {
+		if classifier, err = newModelRunner(config.TaskClassify, nil); err != nil {
+			return nil, err
+		}
+	}
+	results := []prWithComments{}
+	for _, pr := range prs {
+		repoFull, err := repoFromPRURL(pr.HTMLURL)
+		if err != nil {
+			continue
+		}
+		// Fast probe: check the latest comments for a human
+		hasHuman, err := hasHumanComments(repoFull, pr.Number, botRe)
+		if err != nil {
+			return nil, err
+		}
+		if !hasHuman && q.ExcludeBots {
+			// Skip heavy fetch, no human activity
+			continue
+		}
+		// Fetch full comments only when necessary
+		issues, err := fetchIssueComments(repoFull, pr.Number)
+		if err != nil {
+			return nil, err
+		}
+		reviews, err := fetchReviewComments(repoFull, pr.Number)
+		if err != nil {
+			return nil, err
+		}
+		summaries, err := fetchReviews(repoFull, pr.Number)
+		if err != nil {
+			return nil, err
+		}
+		var cc []classifiedComment
+		for _, ic := range issues {
+			if q.ExcludeBots && botRe.MatchString(ic.User.Login) {
+				continue
+			}
+			cc = append(cc, classifiedComment{
+				Kind:      "issue",
+				ID:        ic.ID,
+				Author:    ic.User.Login,
+				CreatedAt: ic.CreatedAt,
+				Body:      ic.Body,
+				URL:       ic.HTMLURL,
+			})
+		}
+		// Build threading for review comments
+		idToIndex := map[int64]int{}
+		for _, rc := range reviews {
+			if q.ExcludeBots && botRe.MatchString(rc.User.Login) {
+				continue
+			}
+			parent := int64(0)
+			if rc.InReplyToID != nil {
+				parent = *rc.InReplyToID
+			}
+			cc = append(cc, classifiedComment{
+				Kind:      "review",
+				ID:        rc.ID,
+				Author:    rc.User.Login,
+				CreatedAt: rc.CreatedAt,
+				Body:      rc.Body,
+				URL:       rc.HTMLURL,
+				Path:      rc.Path,
+				ParentID:  parent,
+			})
+			idToIndex[rc.ID] = len(cc) - 1
+		}
+		// Review summaries: bodies plus approve/request-changes verdicts.
+		// Every non-bot review counts towards the approval status.
+		var counted []ghReview
+		for _, rv := range summaries {
+			if q.ExcludeBots && botRe.MatchString(rv.User.Login) {
+				continue
+			}
+			counted = append(counted, rv)
+			if strings.TrimSpace(rv.Body) == "" && rv.State != "APPROVED" && rv.State != "CHANGES_REQUESTED" {
+				continue
+			}
+			if rv.State == "PENDING" {
+				continue
+			}
+			cc = append(cc, classifiedComment{
+				Kind:      "summary",
+				ID:        rv.ID,
+				Author:    rv.User.Login,
+				CreatedAt: rv.SubmittedAt,
+				Body:      rv.Body,
+				URL:       rv.HTMLURL,
+				State:     rv.State,
+			})
+		}
+		if q.Unresolved {
+			threads, err := fetchReviewThreads(repoFull, pr.Number)
+			if err != nil {
+				return nil, err
+			}
+			cc = dropResolved(cc, threads)
+		}
+		// Optionally classify severity per comment using opencode
+		priority := "none"
+		if q.Classify {
+			for i := range cc {
+				if strings.TrimSpace(cc[i].Body) == "" {
+					continue
+				}
+				sev, _ := classifyComment(classifier, cc[i].Body)
+				cc[i].Severity = sev
+			}
+			// Compute PR priority: highest severity among comments
+			priority = derivePriority(cc)
+		}
+		// Sort comments by time
+		sort.Slice(cc, func(i, j int) bool { return cc[i].CreatedAt < cc[j].CreatedAt })
+		results = append(results, prWithComments{
+			Repo:           repoFull,
+			Number:         pr.Number,
+			Title:          pr.Title,
+			URL:            pr.HTMLURL,
+			Author:         pr.User.Login,
+			Comments:       cc,
+			Priority:       priority,
+			ApprovalStatus: approvalStatus(counted),
+		})
+	}
+	return results, nil
+}
+
+// renderPRComments prints the human-readable comment listing.
+func renderPRComments(p *output.Printer, results []prWithComments, classified, renderMD bool) {
+	for _, r := range results {
+		p.Infof("PR: #%d %s\n", r.Number, r.Title)
+		p.Printf("Repo: %s\n", r.Repo)
+		// Raw PR URL only (no clickable label line)
+		p.Printf("URL:  %s\n", r.URL)
+		if classified {
+			p.Printf("Priority: %s\n", r.Priority)
+		}
+		p.Printf("Approval: %s\n", approvalLabel(p, r.ApprovalStatus))
+		if len(r.Comments) == 0 {
+			p.Warnf("  (no human comments)\n\n")
+			continue
+		}
+		// Review replies are printed under their parent with extra indentation
+		for _, c := range r.Comments {
+			indent := "  "
+			if c.Kind == "review" && c.ParentID != 0 {
+				indent = "    ↳ "
+			}
+			sev := c.Severity
+			if !classified || sev == "" {
+				sev = "-"
+			}
+			// header line with severity and author
+			header := fmt.Sprintf("%s- [%s] @%s", indent, sev, p.ColorizeAuthor(c.Author))
+			if c.State != "" {
+				header += " " + stateLabel(p, c.State)
+			}
+			if c.Path != "" {
+				header += fmt.Sprintf(" (%s)", c.Path)
+			}
+			header += fmt.Sprintf(" id:%d", c.ID)
+			p.Printf("%s\n", header)
+			// body on its own line; render as markdown to ANSI
+			if strings.TrimSpace(c.Body) != "" {
+				var rendered string
+				if renderMD {
+					rendered = p.RenderMarkdown(c.Body)
+				} else {
+					rendered = oneLiner(c.Body)
+				}
+				for _, line := range strings.Split(strings.TrimRight(rendered, "\n"), "\n") {
+					p.Printf("%s  %s\n", indent, line)
+				}
+			}
+		}
+		p.Printf("\n")
+	}
+}
+
 func oneLiner(s string) string {
 	s = strings.ReplaceAll(s, "\n", " ")
 	s = strings.ReplaceAll(s, "\r", " ")
@@ -414,32 +510,79 @@ func repoFromPRURL(url string) (string, error) {
 	return "", fmt.Errorf("cannot parse repo from url: %s", url)
 }
 
-func hasHumanComments(repo string, prNumber int, botRe *regexp.Regexp) (bool, error) {
-	// Probe latest issue comment
-	path1 := fmt.Sprintf("repos/%s/issues/%d/comments?per_page=1", repo, prNumber)
-	c1 := exec.Command("gh", "api", path1)
-	b1, err1 := c1.Output()
-	if err1 == nil {
-		var one []ghIssueComment
-		if json.Unmarshal(b1, &one) == nil && len(one) > 0 {
-			if !botRe.MatchString(one[0].User.Login) {
-				return true, nil
-			}
-		}
+// latestActivityQuery reads the newest issue comment, review and review
+// comment of a PR; the REST endpoints list oldest first.
+const latestActivityQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      comments(last: 1) { nodes { author { __typename login } } }
+      reviews(last: 1) { nodes { author { __typename login } } }
+      reviewThreads(last: 1) { nodes { comments(last: 1) { nodes { author { __typename login } } } } }
+    }
+  }
+}`
+
+type graphQLAuthor struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+}
+
+// restLogin returns the login as the REST API shows it; GraphQL leaves out
+// the [bot] suffix of apps.
+func (a graphQLAuthor) restLogin() string {
+	if a.Typename == "Bot" {
+		return a.Login + "[bot]"
 	}
-	// Probe latest review comment
-	path2 := fmt.Sprintf("repos/%s/pulls/%d/comments?per_page=1", repo, prNumber)
-	c2 := exec.Command("gh", "api", path2)
-	b2, err2 := c2.Output()
-	if err2 == nil {
-		var one []ghReviewComment
-		if json.Unmarshal(b2, &one) == nil && len(one) > 0 {
-			if !botRe.MatchString(one[0].User.Login) {
+	return a.Login
+}
+
+func hasHumanComments(repo string, prNumber int, botRe *regexp.Regexp) (bool, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	out, err := exec.Command("gh", "api", "graphql", "-f", "query="+latestActivityQuery,
+		"-f", "owner="+owner, "-f", "name="+name, "-F", fmt.Sprintf("number=%d", prNumber)).Output()
+	if err != nil {
+		// treat a failed probe as no human activity, as before
+		return false, nil
+	}
+	type authors struct {
+		Nodes []struct {
+			Author *graphQLAuthor `json:"author"`
+		} `json:"nodes"`
+	}
+	var resp struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					Comments      authors `json:"comments"`
+					Reviews       authors `json:"reviews"`
+					ReviewThreads struct {
+						Nodes []struct {
+							Comments authors `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(out, &resp) != nil {
+		return false, nil
+	}
+	pr := resp.Data.Repository.PullRequest
+	// Latest issue comment, review (approvals without comments count too)
+	// and review comment
+	latest := []authors{pr.Comments, pr.Reviews}
+	for _, t := range pr.ReviewThreads.Nodes {
+		latest = append(latest, t.Comments)
+	}
+	for _, a := range latest {
+		for _, n := range a.Nodes {
+			// deleted accounts have no author
+			if n.Author != nil && !botRe.MatchString(n.Author.restLogin()) {
 				return true, nil
 			}
 		}
 	}
-	// If both failed or only bots observed
+	// If the probe failed or only bots observed
 	return false, nil
 }
 
@@ -492,16 +635,97 @@ func fetchReviewComments(repo string, prNumber int) ([]ghReviewComment, error) {
 	return items, nil
 }
 
-func classifyComment(model, body string) (string, error) {
+func fetchReviews(repo string, prNumber int) ([]ghReview, error) {
+	path := fmt.Sprintf("repos/%s/pulls/%d/reviews", repo, prNumber)
+	c := exec.Command("gh", "api", path, "--paginate")
+	out, err := c.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh api %s failed: %s", path, string(ee.Stderr))
+		}
+		return nil, err
+	}
+	var items []ghReview
+	if err := json.Unmarshal(out, &items); err == nil {
+		return items, nil
+	}
+	for _, chunk := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var part []ghReview
+		if err := json.Unmarshal([]byte(chunk), &part); err == nil {
+			items = append(items, part...)
+		}
+	}
+	return items, nil
+}
+
+// approvalStatus derives the PR's overall review state from each reviewer's
+// latest approving, blocking or dismissed review.
+func approvalStatus(reviews []ghReview) string {
+	latest := map[string]string{}
+	commented := false
+	for _, rv := range reviews {
+		switch rv.State {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[rv.User.Login] = rv.State
+		case "COMMENTED":
+			commented = true
+		}
+	}
+	status := "none"
+	if commented {
+		status = "commented"
+	}
+	for _, st := range latest {
+		switch st {
+		case "CHANGES_REQUESTED":
+			return "changes_requested"
+		case "APPROVED":
+			status = "approved"
+		}
+	}
+	return status
+}
+
+func approvalLabel(p *output.Printer, status string) string {
+	switch status {
+	case "approved":
+		return p.Good(status)
+	case "changes_requested":
+		return p.Bad("changes requested")
+	default:
+		return status
+	}
+}
+
+func stateLabel(p *output.Printer, state string) string {
+	switch state {
+	case "APPROVED":
+		return p.Good(state)
+	case "CHANGES_REQUESTED":
+		return p.Bad(state)
+	default:
+		return state
+	}
+}
+
+func classifyComment(runner *modelRunner, body string) (string, error) {
 	if strings.TrimSpace(body) == "" {
 		return "info", nil
 	}
 	// Simple prompt to opencode for classification
 	prompt := fmt.Sprintf("Classify the following GitHub PR comment by severity as one of: blocker, high, medium, low, info. Respond with just the label. Comment: %q", body)
+	prompt, err := runner.prepare(prompt)
+	if err != nil {
+		return "info", err
+	}
 	// Use opencode CLI to run and capture output
-	args := []string{"run", "-m", model, prompt}
-	cmd := exec.Command("opencode", args...)
-	b, err := cmd.Output()
+	var b []byte
+	err = runner.run(func(model string) error {
+		var err error
+		b, err = exec.Command("opencode", "run", "-m", model, prompt).Output()
+		return err
+	})
 	if err != nil {
 		return "info", nil
 	}
@@ -516,12 +740,22 @@ func classifyComment(model, body string) (string, error) {
 
 func derivePriority(comments []classifiedComment) string {
 	priority := "none"
-	order := map[string]int{"blocker": 5, "high": 4, "medium": 3, "low": 2, "info": 1}
+	order := severityOrder
 	max := 0
+	// A reviewer's latest CHANGES_REQUESTED review keeps the PR at least "high"
+	latestState := map[string]string{}
 	for _, c := range comments {
 		if v := order[c.Severity]; v > max {
 			max = v
 		}
+		if c.Kind == "summary" && c.State != "COMMENTED" {
+			latestState[c.Author] = c.State
+		}
+	}
+	for _, st := range latestState {
+		if st == "CHANGES_REQUESTED" && max < order["high"] {
+			max = order["high"]
+		}
 	}
 	for k, v := range order {
 		if v == max {
@@ -533,3 +767,82 @@ func derivePriority(comments []classifiedComment) string {
 	}
 	return priority
 }
+
+// dropResolved removes review comments in resolved threads and records the
+// thread id on the rest. Issue comments cannot be resolved and are kept.
+func dropResolved(comments []classifiedComment, threads []reviewThread) []classifiedComment {
+	byComment := map[int64]reviewThread{}
+	for _, t := range threads {
+		for _, id := range t.CommentIDs {
+			byComment[id] = t
+		}
+	}
+	kept := comments[:0]
+	for _, c := range comments {
+		if c.Kind == "review" {
+			t, ok := byComment[c.ID]
+			if ok && t.IsResolved {
+				continue
+			}
+			c.ThreadID = t.ID
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// commentThreads groups comments into threads: each issue comment stands
+// alone, review replies join the thread of the comment they answer.
+func commentThreads(comments []classifiedComment) [][]classifiedComment {
+	var threads [][]classifiedComment
+	index := map[int64]int{}
+	for _, c := range comments {
+		if c.Kind == "review" && c.ParentID != 0 {
+			if i, ok := index[c.ParentID]; ok {
+				threads[i] = append(threads[i], c)
+				index[c.ID] = i
+				continue
+			}
+		}
+		index[c.ID] = len(threads)
+		threads = append(threads, []classifiedComment{c})
+	}
+	return threads
+}
+
+// quoteReply seeds a reply buffer with the quoted comment.
+func quoteReply(c classifiedComment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "> @%s wrote:\n", c.Author)
+	for _, line := range strings.Split(strings.TrimRight(c.Body, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// postCommentReply answers c on PR number in repo. Review comments get a
+// threaded reply, issue comments a new PR comment.
+func postCommentReply(repo string, number int, c classifiedComment, body string) (string, error) {
+	path := fmt.Sprintf("repos/%s/issues/%d/comments", repo, number)
+	if c.Kind == "review" {
+		root := c.ID
+		if c.ParentID != 0 {
+			root = c.ParentID
+		}
+		path = fmt.Sprintf("repos/%s/pulls/%d/comments/%d/replies", repo, number, root)
+	}
+	out, err := exec.Command("gh", "api", "-X", "POST", path, "-f", "body="+body).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return "", fmt.Errorf("gh api %s failed: %s", path, string(ee.Stderr))
+		}
+		return "", err
+	}
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	_ = json.Unmarshal(out, &created)
+	return created.HTMLURL, nil
+}
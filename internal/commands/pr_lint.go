@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// titleLint is the result of linting one PR title.
+type titleLint struct {
+	Number     int      `json:"number,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	Title      string   `json:"title"`
+	Ticket     string   `json:"ticket,omitempty"`
+	OK         bool     `json:"ok"`
+	Problems   []string `json:"problems,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	Fixed      bool     `json:"fixed,omitempty"`
+}
+
+func newPRLintCmd() *cobra.Command {
+	var outFlags *outputFlags
+	var title, ticket, repo string
+	var fix bool
+
+	cmd := &cobra.Command{
+		Use:   "lint [number|url|branch]",
+		Short: "Check a PR title against the title rules (exits 1 when invalid)",
+		Long: "Validates the PR title against the `title` section of the config: a\n" +
+			"conventional-commit type list and scope rule, or a custom regular expression.\n" +
+			"The ticket key expected in the scope is taken from the PR's head branch.\n" +
+			"Without an argument the current branch's PR is linted; --title lints a string.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			settings, err := config.GetTitleSettings()
+			if err != nil {
+				return err
+			}
+			rules := settings.Rules
+
+			res := titleLint{Title: title}
+			var branch string
+			if !cmd.Flags().Changed("title") {
+				if err := ensureGh(); err != nil {
+					return err
+				}
+				pr, err := fetchLintPR(args, repo)
+				if err != nil {
+					return err
+				}
+				res.Number, res.URL, res.Title, branch = pr.Number, pr.URL, pr.Title, pr.HeadRefName
+			} else if len(args) == 1 {
+				return errors.New("use either a PR reference or --title")
+			} else {
+				branch, _ = getCurrentBranch()
+			}
+			res.Ticket = ticket
+			if res.Ticket == "" {
+				res.Ticket = rules.TicketFrom(branch)
+			}
+			res.Problems = rules.Check(res.Title, res.Ticket)
+			res.OK = len(res.Problems) == 0
+			if !res.OK {
+				res.Suggestion = rules.Fix(res.Title, res.Ticket)
+			}
+			if fix && res.Suggestion != "" && res.Number > 0 {
+				if err := setPRTitle(res.Number, repo, res.Suggestion); err != nil {
+					return err
+				}
+				res.Fixed, res.OK, res.Title = true, true, res.Suggestion
+			}
+
+			if !outOpts.Human() {
+				if err := p.Render(outOpts, res); err != nil {
+					return err
+				}
+			} else {
+				renderTitleLint(p, res)
+			}
+			if !res.OK {
+				return &ExitError{Code: 1}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&title, "title", "", "Lint this title instead of a PR's")
+	cmd.Flags().StringVar(&ticket, "ticket", "", "Expected ticket key (default: from the branch name)")
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository (OWNER/REPO); defaults to the current repo")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Apply the corrected title to the PR when one can be derived")
+	outFlags = addOutputFlags(cmd)
+	outFlags.addJSON(cmd)
+	return cmd
+}
+
+type ghLintPR struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	HeadRefName string `json:"headRefName"`
+}
+
+func fetchLintPR(args []string, repo string) (*ghLintPR, error) {
+	ghArgs := append([]string{"pr", "view"}, args...)
+	if repo != "" {
+		ghArgs = append(ghArgs, "--repo", repo)
+	}
+	ghArgs = append(ghArgs, "--json", "number,title,url,headRefName")
+	out, err := exec.Command("gh", ghArgs...).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh pr view failed: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+	var pr ghLintPR
+	if err := json.Unmarshal(out, &pr); err != nil {
+		return nil, fmt.Errorf("parse gh pr view json: %w", err)
+	}
+	return &pr, nil
+}
+
+func setPRTitle(number int, repo, title string) error {
+	args := []string{"pr", "edit", strconv.Itoa(number), "--title", title}
+	if repo != "" {
+		args = append(args, "--repo", repo)
+	}
+	if out, err := exec.Command("gh", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("gh pr edit failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func renderTitleLint(p *output.Printer, res titleLint) {
+	switch {
+	case res.Fixed:
+		p.Successf("✓ Title corrected: %s\n", res.Title)
+	case res.OK:
+		p.Successf("✓ %s\n", res.Title)
+	default:
+		p.Printf("%s %s\n", p.Bad("✗"), res.Title)
+		for _, prob := range res.Problems {
+			p.Printf("  - %s\n", prob)
+		}
+		if res.Suggestion != "" {
+			p.Printf("Suggested: %s\n", p.Good(res.Suggestion))
+		}
+	}
+}
+
+// checkTitle lints title for pr create/update/edit title. When it is invalid
+// the problems are shown and, on a terminal, the corrected title is offered;
+// elsewhere title.enforce applies it without asking. It returns the title to
+// use; with title.enforce an invalid title that is kept is an error.
+func checkTitle(cmd *cobra.Command, title, branch string) (string, error) {
+	p := printer(cmd)
+	settings, err := config.GetTitleSettings()
+	if err != nil {
+		return "", err
+	}
+	rules := settings.Rules
+	ticket := rules.TicketFrom(branch)
+	problems := rules.Check(title, ticket)
+	if len(problems) == 0 {
+		return title, nil
+	}
+	p.Warnf("PR title %q does not follow the title rules:\n", title)
+	for _, prob := range problems {
+		p.Warnf("  - %s\n", prob)
+	}
+	if fixed := rules.Fix(title, ticket); fixed != "" {
+		switch {
+		case !stdinIsTerminal(cmd) && settings.Enforce:
+			p.Infof("Using %s (title.enforce is on)\n", fixed)
+			return fixed, nil
+		case !stdinIsTerminal(cmd):
+			p.Infof("Suggested: %s (apply with `noji pr lint --fix`)\n", fixed)
+		default:
+			ok, err := newAsker(p, cmd.InOrStdin()).yesNo(fmt.Sprintf("Use %q instead?", fixed))
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return fixed, nil
+			}
+		}
+	}
+	if settings.Enforce {
+		return "", fmt.Errorf("PR title rejected: %s", strings.Join(problems, "; "))
+	}
+	return title, nil
+}
+
+// lintCurrentPRTitle runs checkTitle on the current branch's PR after the
+// model created or updated it, and applies an accepted correction. The PR
+// already exists by then, so title.enforce can only correct the title or
+// report that the invalid one is live.
+func lintCurrentPRTitle(cmd *cobra.Command) error {
+	branch, err := getCurrentBranch()
+	if err != nil || branch == "" {
+		return nil
+	}
+	pr, err := getPRForCurrentBranch(branch)
+	if err != nil || pr == nil {
+		return err
+	}
+	title, err := checkTitle(cmd, pr.Title, branch)
+	if err != nil {
+		return fmt.Errorf("PR #%d was saved with this title, fix it with `noji pr edit title`: %w", pr.Number, err)
+	}
+	if title == pr.Title {
+		return nil
+	}
+	if err := setPRTitle(pr.Number, "", title); err != nil {
+		return err
+	}
+	printer(cmd).Successf("PR #%d title updated.\n", pr.Number)
+	return nil
+}
+
+func stdinIsTerminal(cmd *cobra.Command) bool {
+	f, ok := cmd.InOrStdin().(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,358 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// reviewThread is a PR review thread as reported by the GraphQL API.
+type reviewThread struct {
+	ID         string
+	IsResolved bool
+	IsOutdated bool
+	Path       string
+	CommentIDs []int64
+}
+
+func newPRReplyCmd() *cobra.Command {
+	var repo string
+	var body string
+
+	cmd := &cobra.Command{
+		Use:   "reply <comment-id>",
+		Short: "Reply to a PR comment or review thread using your editor",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "id:"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid comment id: %s", args[0])
+			}
+			if repo == "" {
+				if repo, err = currentRepo(); err != nil {
+					return err
+				}
+			}
+			c, number, err := fetchCommentByID(repo, id)
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(body) == "" {
+				seed := quoteReply(c)
+				tmpFile, err := createTempFile(seed)
+				if err != nil {
+					return fmt.Errorf("create temp file: %w", err)
+				}
+				defer os.Remove(tmpFile)
+				ed, err := resolveEditor()
+				if err != nil {
+					return err
+				}
+				if err := openInEditor(ed, tmpFile); err != nil {
+					return err
+				}
+				b, err := os.ReadFile(tmpFile)
+				if err != nil {
+					return fmt.Errorf("read edited file: %w", err)
+				}
+				body = strings.TrimSpace(string(b))
+				if body == "" || body == strings.TrimSpace(seed) {
+					p.Infof("No changes detected.\n")
+					return nil
+				}
+			}
+			url, err := postCommentReply(repo, number, c, body)
+			if err != nil {
+				return err
+			}
+			p.Successf("Reply posted to %s#%d.\n", repo, number)
+			if url != "" {
+				p.Printf("%s\n", url)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository (OWNER/REPO); defaults to the current repo")
+	cmd.Flags().StringVarP(&body, "body", "b", "", "Reply text (skips the editor)")
+	return cmd
+}
+
+func newPRResolveCmd() *cobra.Command {
+	var repo string
+	var undo bool
+
+	cmd := &cobra.Command{
+		Use:   "resolve <thread-id|comment-id>",
+		Short: "Resolve a review thread (by GraphQL thread id or any comment id in it)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			threadID := args[0]
+			if id, err := strconv.ParseInt(strings.TrimPrefix(threadID, "id:"), 10, 64); err == nil {
+				if repo == "" {
+					if repo, err = currentRepo(); err != nil {
+						return err
+					}
+				}
+				c, number, err := fetchCommentByID(repo, id)
+				if err != nil {
+					return err
+				}
+				if c.Kind != "review" {
+					return errors.New("only review comments belong to resolvable threads")
+				}
+				threads, err := fetchReviewThreads(repo, number)
+				if err != nil {
+					return err
+				}
+				threadID = ""
+				for _, t := range threads {
+					if contains64(t.CommentIDs, id) {
+						threadID = t.ID
+						break
+					}
+				}
+				if threadID == "" {
+					return fmt.Errorf("no review thread found for comment %d", id)
+				}
+			}
+			if err := setThreadResolved(threadID, !undo); err != nil {
+				return err
+			}
+			if undo {
+				p.Successf("Thread %s unresolved.\n", threadID)
+			} else {
+				p.Successf("Thread %s resolved.\n", threadID)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository (OWNER/REPO); defaults to the current repo")
+	cmd.Flags().BoolVar(&undo, "undo", false, "Unresolve the thread instead")
+	return cmd
+}
+
+func contains64(slice []int64, v int64) bool {
+	for _, x := range slice {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// currentRepo returns OWNER/REPO for the repository in the working directory.
+func currentRepo() (string, error) {
+	out, err := exec.Command("gh", "repo", "view", "--json", "nameWithOwner", "-q", ".nameWithOwner").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return "", fmt.Errorf("gh repo view failed: %s", string(ee.Stderr))
+		}
+		return "", err
+	}
+	repo := strings.TrimSpace(string(out))
+	if repo == "" {
+		return "", errors.New("could not determine current repository")
+	}
+	return repo, nil
+}
+
+// ghAPINotFound reports whether err is gh api failing with HTTP 404.
+func ghAPINotFound(err error) bool {
+	var ee *exec.ExitError
+	return errors.As(err, &ee) && strings.Contains(string(ee.Stderr), "(HTTP 404)")
+}
+
+// ghAPIError wraps a failed gh api call with its stderr.
+func ghAPIError(path string, err error) error {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return fmt.Errorf("gh api %s failed: %s", path, strings.TrimSpace(string(ee.Stderr)))
+	}
+	return err
+}
+
+// fetchCommentByID looks the id up as a review comment first, then as an
+// issue comment, and returns it with the number of its PR.
+func fetchCommentByID(repo string, id int64) (classifiedComment, int, error) {
+	reviewPath := fmt.Sprintf("repos/%s/pulls/comments/%d", repo, id)
+	out, err := exec.Command("gh", "api", reviewPath).Output()
+	if err != nil && !ghAPINotFound(err) {
+		return classifiedComment{}, 0, ghAPIError(reviewPath, err)
+	}
+	if err == nil {
+		var rc ghReviewComment
+		if err := json.Unmarshal(out, &rc); err != nil {
+			return classifiedComment{}, 0, err
+		}
+		number, err := numberFromAPIURL(rc.PullRequestURL)
+		if err != nil {
+			return classifiedComment{}, 0, err
+		}
+		parent := int64(0)
+		if rc.InReplyToID != nil {
+			parent = *rc.InReplyToID
+		}
+		return classifiedComment{
+			Kind:      "review",
+			ID:        rc.ID,
+			Author:    rc.User.Login,
+			CreatedAt: rc.CreatedAt,
+			Body:      rc.Body,
+			URL:       rc.HTMLURL,
+			Path:      rc.Path,
+			ParentID:  parent,
+		}, number, nil
+	}
+	issuePath := fmt.Sprintf("repos/%s/issues/comments/%d", repo, id)
+	out, err = exec.Command("gh", "api", issuePath).Output()
+	if ghAPINotFound(err) {
+		return classifiedComment{}, 0, fmt.Errorf("comment %d not found in %s", id, repo)
+	}
+	if err != nil {
+		return classifiedComment{}, 0, ghAPIError(issuePath, err)
+	}
+	var ic struct {
+		ghIssueComment
+		IssueURL string `json:"issue_url"`
+	}
+	if err := json.Unmarshal(out, &ic); err != nil {
+		return classifiedComment{}, 0, err
+	}
+	number, err := numberFromAPIURL(ic.IssueURL)
+	if err != nil {
+		return classifiedComment{}, 0, err
+	}
+	return classifiedComment{
+		Kind:      "issue",
+		ID:        ic.ID,
+		Author:    ic.User.Login,
+		CreatedAt: ic.CreatedAt,
+		Body:      ic.Body,
+		URL:       ic.HTMLURL,
+	}, number, nil
+}
+
+// numberFromAPIURL extracts the trailing number of an API URL like .../pulls/12.
+func numberFromAPIURL(u string) (int, error) {
+	i := strings.LastIndex(u, "/")
+	n, err := strconv.Atoi(u[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse PR number from url: %s", u)
+	}
+	return n, nil
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          isResolved
+          isOutdated
+          path
+          comments(first: 100) { nodes { databaseId } }
+        }
+      }
+    }
+  }
+}`
+
+// fetchReviewThreads lists the review threads of a PR with their resolution state.
+func fetchReviewThreads(repo string, number int) ([]reviewThread, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repo: %s", repo)
+	}
+	var threads []reviewThread
+	after := ""
+	for {
+		args := []string{"api", "graphql", "-f", "query=" + reviewThreadsQuery,
+			"-f", "owner=" + owner, "-f", "name=" + name, "-F", fmt.Sprintf("number=%d", number)}
+		if after != "" {
+			args = append(args, "-f", "after="+after)
+		}
+		out, err := exec.Command("gh", args...).Output()
+		if err != nil {
+			var ee *exec.ExitError
+			if errors.As(err, &ee) {
+				return nil, fmt.Errorf("gh api graphql reviewThreads failed: %s", string(ee.Stderr))
+			}
+			return nil, err
+		}
+		var resp struct {
+			Data struct {
+				Repository struct {
+					PullRequest struct {
+						ReviewThreads struct {
+							PageInfo struct {
+								HasNextPage bool   `json:"hasNextPage"`
+								EndCursor   string `json:"endCursor"`
+							} `json:"pageInfo"`
+							Nodes []struct {
+								ID         string `json:"id"`
+								IsResolved bool   `json:"isResolved"`
+								IsOutdated bool   `json:"isOutdated"`
+								Path       string `json:"path"`
+								Comments   struct {
+									Nodes []struct {
+										DatabaseID int64 `json:"databaseId"`
+									} `json:"nodes"`
+								} `json:"comments"`
+							} `json:"nodes"`
+						} `json:"reviewThreads"`
+					} `json:"pullRequest"`
+				} `json:"repository"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(out, &resp); err != nil {
+			return nil, fmt.Errorf("parse reviewThreads: %w", err)
+		}
+		rt := resp.Data.Repository.PullRequest.ReviewThreads
+		for _, n := range rt.Nodes {
+			t := reviewThread{ID: n.ID, IsResolved: n.IsResolved, IsOutdated: n.IsOutdated, Path: n.Path}
+			for _, c := range n.Comments.Nodes {
+				t.CommentIDs = append(t.CommentIDs, c.DatabaseID)
+			}
+			threads = append(threads, t)
+		}
+		if !rt.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		after = rt.PageInfo.EndCursor
+	}
+}
+
+// setThreadResolved runs the resolveReviewThread (or unresolveReviewThread) mutation.
+func setThreadResolved(threadID string, resolved bool) error {
+	mutation := "resolveReviewThread"
+	if !resolved {
+		mutation = "unresolveReviewThread"
+	}
+	query := fmt.Sprintf(`mutation($id: ID!) { %s(input: {threadId: $id}) { thread { id isResolved } } }`, mutation)
+	c := exec.Command("gh", "api", "graphql", "-f", "query="+query, "-f", "id="+threadID)
+	if _, err := c.Output(); err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return fmt.Errorf("gh api graphql %s failed: %s", mutation, string(ee.Stderr))
+		}
+		return err
+	}
+	return nil
+}
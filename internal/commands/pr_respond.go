@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// responseDraft is the model's proposal for answering a review comment.
+type responseDraft struct {
+	Reply string
+	Patch string
+}
+
+// respondTarget is a review thread awaiting a response from the PR author.
+type respondTarget struct {
+	Root   ghReviewComment // first comment, carries path and diff hunk
+	Latest ghReviewComment // last comment by a reviewer
+}
+
+func newPRRespondCmd() *cobra.Command {
+	var all bool
+	var limit int
+	var contextLines int
+
+	cmd := &cobra.Command{
+		Use:   "respond",
+		Short: "Draft replies or code changes for review comments on your PR using opencode",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			pr, err := currentPR()
+			if err != nil {
+				return err
+			}
+			repo, err := currentRepo()
+			if err != nil {
+				return err
+			}
+			root, err := gitRoot()
+			if err != nil {
+				return err
+			}
+			me, err := whoAmI()
+			if err != nil {
+				return err
+			}
+			comments, err := fetchReviewComments(repo, pr.Number)
+			if err != nil {
+				return err
+			}
+			threads, err := fetchReviewThreads(repo, pr.Number)
+			if err != nil {
+				return err
+			}
+			targets := respondTargets(comments, threads, me, all)
+			if limit > 0 && len(targets) > limit {
+				targets = targets[:limit]
+			}
+			if len(targets) == 0 {
+				p.Infof("No review comments awaiting a response on PR #%d.\n", pr.Number)
+				return nil
+			}
+			template, err := readPrompt("pr_respond.txt")
+			if err != nil {
+				return err
+			}
+			runner, err := newModelRunner(config.TaskPRRespond, p.Warnf)
+			if err != nil {
+				return err
+			}
+
+			ask := newAsker(p, cmd.InOrStdin())
+			posted := 0
+			for i, t := range targets {
+				p.Infof("\n[%d/%d] @%s on %s\n", i+1, len(targets), t.Latest.User.Login, commentLocation(t.Root))
+				p.Printf("%s\n", strings.TrimRight(p.RenderMarkdown(t.Latest.Body), "\n"))
+
+				p.Infof("Drafting response with model %s...\n", runner.Model())
+				prompt := buildRespondPrompt(template, t, root, contextLines)
+				out, err := runner.capture(prompt)
+				if err != nil {
+					p.Warnf("Draft failed: %v\n", err)
+					continue
+				}
+				draft := parseResponseDraft(out)
+				done, quit, err := reviewDraft(p, ask, repo, pr.Number, root, t, &draft)
+				if err != nil {
+					return err
+				}
+				if done {
+					posted++
+				}
+				if quit {
+					break
+				}
+			}
+			p.Successf("Done. %d repl%s posted.\n", posted, pluralY(posted))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Include threads where you already replied last")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Limit number of comments to process (0=all)")
+	cmd.Flags().IntVar(&contextLines, "context", 60, "Lines of file context around the comment sent to the model")
+	return cmd
+}
+
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+// reviewDraft lets the user step through one draft. It reports whether a
+// reply was posted and whether the user asked to stop.
+func reviewDraft(p *output.Printer, ask *asker, repo string, number int, root string, t respondTarget, d *responseDraft) (posted, quit bool, err error) {
+	patchApplied := false
+	for {
+		p.Infof("\nDraft reply:\n")
+		if strings.TrimSpace(d.Reply) == "" {
+			p.Warnf("  (empty)\n")
+		} else {
+			p.Printf("%s\n", strings.TrimRight(p.RenderMarkdown(d.Reply), "\n"))
+		}
+		options := "[a]ccept reply, [e]dit reply, [s]kip, [q]uit"
+		keys := "aesq"
+		if strings.TrimSpace(d.Patch) != "" {
+			p.Infof("Proposed change:\n")
+			p.Printf("%s\n", strings.TrimRight(p.RenderMarkdown("```diff\n"+d.Patch+"\n```"), "\n"))
+			if !patchApplied {
+				options = "[a]ccept reply, [e]dit reply, [p]atch apply, [d]iff edit, [s]kip, [q]uit"
+				keys = "aepdsq"
+			}
+		}
+		ans, err := ask.choice(options+":", keys, "")
+		if err != nil {
+			return false, false, err
+		}
+		switch ans {
+		case "a":
+			if strings.TrimSpace(d.Reply) == "" {
+				p.Warnf("Reply is empty; edit it first or skip.\n")
+				continue
+			}
+			c := classifiedComment{Kind: "review", ID: t.Root.ID}
+			url, err := postCommentReply(repo, number, c, d.Reply)
+			if err != nil {
+				return false, false, err
+			}
+			p.Successf("Reply posted. %s\n", url)
+			return true, false, nil
+		case "e":
+			edited, err := editText(d.Reply)
+			if err != nil {
+				return false, false, err
+			}
+			d.Reply = edited
+		case "d":
+			edited, err := editText(d.Patch)
+			if err != nil {
+				return false, false, err
+			}
+			d.Patch = edited
+		case "p":
+			if err := applyPatch(root, d.Patch); err != nil {
+				p.Warnf("%v\n", err)
+				continue
+			}
+			patchApplied = true
+			p.Successf("Patch applied to the working tree (not committed).\n")
+		case "s":
+			return false, false, nil
+		case "q":
+			return false, true, nil
+		}
+	}
+}
+
+// respondTargets picks the human review threads on the PR that still wait for
+// the author: unresolved, started by someone else and not last answered by me.
+func respondTargets(comments []ghReviewComment, threads []reviewThread, me string, all bool) []respondTarget {
+	resolved := map[int64]bool{}
+	for _, t := range threads {
+		for _, id := range t.CommentIDs {
+			resolved[id] = t.IsResolved
+		}
+	}
+	var roots []int64
+	byRoot := map[int64][]ghReviewComment{}
+	for _, c := range comments {
+		root := c.ID
+		if c.InReplyToID != nil {
+			root = *c.InReplyToID
+		}
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], c)
+	}
+	var out []respondTarget
+	for _, id := range roots {
+		thread := byRoot[id]
+		first := thread[0]
+		if first.ID != id || resolved[id] {
+			continue
+		}
+		if first.User.Login == me || commentBotRe.MatchString(first.User.Login) {
+			continue
+		}
+		last := thread[len(thread)-1]
+		if last.User.Login == me && !all {
+			continue
+		}
+		latest := first
+		for _, c := range thread {
+			if c.User.Login != me && !commentBotRe.MatchString(c.User.Login) {
+				latest = c
+			}
+		}
+		out = append(out, respondTarget{Root: first, Latest: latest})
+	}
+	return out
+}
+
+func commentLocation(c ghReviewComment) string {
+	if c.Line != nil {
+		if c.StartLine != nil && *c.StartLine != *c.Line {
+			return fmt.Sprintf("%s:%d-%d", c.Path, *c.StartLine, *c.Line)
+		}
+		return fmt.Sprintf("%s:%d", c.Path, *c.Line)
+	}
+	return c.Path + " (outdated)"
+}
+
+func buildRespondPrompt(template string, t respondTarget, root string, contextLines int) string {
+	var b strings.Builder
+	b.WriteString(template)
+	fmt.Fprintf(&b, "\n\nReviewer: @%s\nLocation: %s\n", t.Latest.User.Login, commentLocation(t.Root))
+	if t.Latest.ID != t.Root.ID {
+		fmt.Fprintf(&b, "\nOriginal comment by @%s:\n%s\n", t.Root.User.Login, t.Root.Body)
+	}
+	fmt.Fprintf(&b, "\nComment to respond to:\n%s\n", t.Latest.Body)
+	fmt.Fprintf(&b, "\nDiff hunk:\n%s\n", t.Root.DiffHunk)
+	line := 0
+	if t.Root.Line != nil {
+		line = *t.Root.Line
+	}
+	fmt.Fprintf(&b, "\n%s\n", fileContext(filepath.Join(root, t.Root.Path), t.Root.Path, line, contextLines))
+	return b.String()
+}
+
+// fileContext returns the working-tree content of path around line (the whole
+// file when line is unknown or the file is short). A line past the end of the
+// local file (a stale checkout, a file that got shorter) is reported as such.
+func fileContext(full, path string, line, radius int) string {
+	b, err := os.ReadFile(full)
+	if err != nil {
+		return fmt.Sprintf("Current content of %s: (file not present in working tree)", path)
+	}
+	lines := strings.Split(string(b), "\n")
+	if line > len(lines) {
+		return fmt.Sprintf("Current content of %s: (line %d not present locally, the file has %d lines)", path, line, len(lines))
+	}
+	from, to := 1, len(lines)
+	if line > 0 && radius > 0 && len(lines) > 2*radius {
+		from = max(1, line-radius)
+		to = min(len(lines), line+radius)
+	}
+	return fmt.Sprintf("Current content of %s (lines %d-%d of %d):\n%s", path, from, to, len(lines), strings.Join(lines[from-1:to], "\n"))
+}
+
+// parseResponseDraft splits model output into the reply and patch sections.
+// Output without markers is treated as a plain reply.
+func parseResponseDraft(out string) responseDraft {
+	if !strings.Contains(out, "===REPLY===") && !strings.Contains(out, "===PATCH===") {
+		return responseDraft{Reply: strings.TrimSpace(out)}
+	}
+	var reply, patch strings.Builder
+	section := ""
+	sc := bufio.NewScanner(strings.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch strings.TrimSpace(line) {
+		case "===REPLY===":
+			section = "reply"
+			continue
+		case "===PATCH===":
+			section = "patch"
+			continue
+		}
+		switch section {
+		case "reply":
+			reply.WriteString(line + "\n")
+		case "patch":
+			patch.WriteString(line + "\n")
+		}
+	}
+	return responseDraft{
+		Reply: strings.TrimSpace(reply.String()),
+		Patch: stripCodeFence(strings.TrimSpace(patch.String())),
+	}
+}
+
+// stripCodeFence removes a surrounding ``` fence that models like to add.
+func stripCodeFence(s string) string {
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+// editText opens text in the configured editor and returns the result.
+func editText(text string) (string, error) {
+	tmpFile, err := createTempFile(text)
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile)
+	ed, err := resolveEditor()
+	if err != nil {
+		return "", err
+	}
+	if err := openInEditor(ed, tmpFile); err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(tmpFile)
+	if err != nil {
+		return "", fmt.Errorf("read edited file: %w", err)
+	}
+	return strings.TrimRight(string(b), "\n"), nil
+}
+
+// applyPatch applies a unified diff to the working tree at root.
+func applyPatch(root, patch string) error {
+	if strings.TrimSpace(patch) == "" {
+		return errors.New("no patch to apply")
+	}
+	tmp, err := createTempFile(strings.TrimRight(patch, "\n") + "\n")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	check := exec.Command("git", "apply", "--check", "--recount", tmp)
+	check.Dir = root
+	if out, err := check.CombinedOutput(); err != nil {
+		return fmt.Errorf("patch does not apply: %s", strings.TrimSpace(string(out)))
+	}
+	apply := exec.Command("git", "apply", "--recount", tmp)
+	apply.Dir = root
+	if out, err := apply.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileContextLineOutOfRange(t *testing.T) {
+	full := filepath.Join(t.TempDir(), "x.go")
+	var lines []string
+	for range 40 {
+		lines = append(lines, "// line")
+	}
+	if err := os.WriteFile(full, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A review comment can be on a line that has since been deleted locally.
+	for _, line := range []int{0, 1, 40, 41, 500} {
+		got := fileContext(full, "x.go", line, 15)
+		if line > 40 && !strings.Contains(got, "not present locally") {
+			t.Errorf("line %d: %q, want a not-present note", line, got)
+		}
+		if line <= 40 && !strings.Contains(got, "of 40)") {
+			t.Errorf("line %d: %q, want a window of the file", line, got)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/diffpack"
+	"github.com/spf13/cobra"
+)
+
+// reviewFinding is one remark of the model on a PR under review.
+type reviewFinding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// InDiff reports whether GitHub accepts an inline comment at File:Line.
+	InDiff bool `json:"in_diff"`
+}
+
+// reviewPR is the PR metadata needed to review it.
+type reviewPR struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	URL        string `json:"url"`
+	HeadRefOid string `json:"headRefOid"`
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+func newPRReviewCmd() *cobra.Command {
+	var repo string
+	var budget int
+	var maxFileLines int
+	var promptFile string
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "review <number|url>",
+		Short: "Review a PR with opencode and optionally submit findings as a pending review",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			repo, number, err := parsePRRef(args[0], repo)
+			if err != nil {
+				return err
+			}
+			pr, err := fetchReviewPR(repo, number)
+			if err != nil {
+				return err
+			}
+			diff, err := fetchPRDiff(repo, number)
+			if err != nil {
+				return err
+			}
+			files := diffpack.Parse(diff)
+			if len(files) == 0 {
+				p.Infof("PR #%d has no changes to review.\n", number)
+				return nil
+			}
+			template, err := readPrompt(promptFile)
+			if err != nil {
+				return err
+			}
+			runner, err := newModelRunner(config.TaskPRReview, p.Warnf)
+			if err != nil {
+				return err
+			}
+			// files such as .env never reach the model, not even as content
+			kept := files[:0]
+			for _, f := range files {
+				if runner.excluded(f.Path) {
+					p.Warnf("Skipping %s (redact.exclude_paths).\n", f.Path)
+					continue
+				}
+				kept = append(kept, f)
+			}
+			if files = kept; len(files) == 0 {
+				p.Infof("PR #%d only changes excluded files.\n", number)
+				return nil
+			}
+
+			opts, err := config.GetDiffOptions()
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("budget") {
+				opts.Budget = budget
+			}
+			chunks, skipped := diffpack.Split(files, opts)
+			if len(skipped) > 0 && outOpts.Human() {
+				stats := make([]string, len(skipped))
+				for i, e := range skipped {
+					stats[i] = e.Stat()
+				}
+				p.Infof("Not reviewed: %s\n", strings.Join(stats, ", "))
+			}
+			if len(chunks) == 0 {
+				p.Infof("PR #%d only changes generated files and lockfiles.\n", number)
+				return nil
+			}
+			contents := map[string]string{}
+			var findings []reviewFinding
+			seen := map[string]bool{}
+			for i, chunk := range chunks {
+				if outOpts.Human() {
+					p.Infof("Reviewing %s#%d with model %s (part %d/%d)...\n", repo, number, runner.Model(), i+1, len(chunks))
+				}
+				prompt := buildReviewPrompt(template, pr, chunk, func(path string) string {
+					if maxFileLines <= 0 {
+						return ""
+					}
+					if _, ok := contents[path]; !ok {
+						contents[path] = fetchFileAtRef(repo, path, pr.HeadRefOid, maxFileLines)
+					}
+					return contents[path]
+				})
+				out, err := runner.capture(prompt)
+				if err != nil {
+					p.Warnf("Part %d failed: %v\n", i+1, err)
+					continue
+				}
+				part, err := parseFindings(out)
+				if err != nil {
+					p.Warnf("Part %d: %v\n", i+1, err)
+					continue
+				}
+				for _, f := range part {
+					key := fmt.Sprintf("%s:%d:%s", f.File, f.Line, f.Message)
+					if !seen[key] {
+						seen[key] = true
+						findings = append(findings, f)
+					}
+				}
+			}
+			markInDiff(findings, files)
+			sortFindings(findings)
+
+			if !outOpts.Human() {
+				return p.Render(outOpts, findings)
+			}
+			if len(findings) == 0 {
+				p.Successf("No findings for %s#%d.\n", repo, number)
+				return nil
+			}
+			p.Printf("%s\n", strings.TrimRight(p.RenderMarkdown(findingsMarkdown(pr, findings)), "\n"))
+
+			ask := newAsker(p, cmd.InOrStdin())
+			sel, err := ask.line("Add findings to a pending review (e.g. 1,3-5 or all; empty to skip):")
+			if err != nil {
+				return err
+			}
+			chosen, err := pickFindings(findings, sel)
+			if err != nil {
+				return err
+			}
+			if len(chosen) == 0 {
+				return nil
+			}
+			ok, err := ask.yesNo(fmt.Sprintf("Create a pending review on %s#%d with %d finding(s)?", repo, number, len(chosen)))
+			if err != nil || !ok {
+				return err
+			}
+			reviewURL, err := submitPendingReview(repo, number, pr.HeadRefOid, chosen)
+			if err != nil {
+				return err
+			}
+			p.Successf("Pending review created; finish it on GitHub.\n")
+			if reviewURL != "" {
+				p.Printf("%s\n", reviewURL)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository (OWNER/REPO); defaults to the current repo")
+	cmd.Flags().IntVar(&budget, "budget", 0, "Approximate diff tokens sent to the model per request (default: diff.budget)")
+	cmd.Flags().IntVar(&maxFileLines, "max-file-lines", 400, "Send full content of changed files up to this many lines (0 disables)")
+	cmd.Flags().StringVar(&promptFile, "prompt", "pr_review.txt", "Prompt file in the prompts directory")
+	outFlags = addOutputFlags(cmd)
+	return cmd
+}
+
+// parsePRRef accepts a PR number or URL. Numbers use repo, or the current
+// repository when repo is empty.
+func parsePRRef(ref, repo string) (string, int, error) {
+	ref = strings.TrimPrefix(ref, "#")
+	if n, err := strconv.Atoi(ref); err == nil {
+		if repo == "" {
+			r, err := currentRepo()
+			if err != nil {
+				return "", 0, err
+			}
+			repo = r
+		}
+		return repo, n, nil
+	}
+	r, err := repoFromPRURL(ref)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid PR reference: %s", ref)
+	}
+	_, tail, ok := strings.Cut(ref, "/pull/")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid PR reference: %s", ref)
+	}
+	tail, _, _ = strings.Cut(tail, "/")
+	n, err := strconv.Atoi(tail)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid PR reference: %s", ref)
+	}
+	return r, n, nil
+}
+
+func fetchReviewPR(repo string, number int) (*reviewPR, error) {
+	c := exec.Command("gh", "pr", "view", strconv.Itoa(number), "--repo", repo, "--json", "number,title,body,url,headRefOid")
+	out, err := c.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh pr view failed: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+	var pr reviewPR
+	if err := json.Unmarshal(out, &pr); err != nil {
+		return nil, fmt.Errorf("parse gh pr view json: %w", err)
+	}
+	return &pr, nil
+}
+
+func fetchPRDiff(repo string, number int) (string, error) {
+	c := exec.Command("gh", "pr", "diff", strconv.Itoa(number), "--repo", repo, "--color", "never")
+	out, err := c.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return "", fmt.Errorf("gh pr diff failed: %s", string(ee.Stderr))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// fetchFileAtRef returns the content of path at ref, or "" when the file is
+// missing, binary or longer than maxLines.
+func fetchFileAtRef(repo, path, ref string, maxLines int) string {
+	api := fmt.Sprintf("repos/%s/contents/%s?ref=%s", repo, escapePath(path), url.QueryEscape(ref))
+	out, err := exec.Command("gh", "api", api, "-H", "Accept: application/vnd.github.raw").Output()
+	if err != nil || bytes.IndexByte(out, 0) >= 0 {
+		return ""
+	}
+	if strings.Count(string(out), "\n") > maxLines {
+		return ""
+	}
+	return string(out)
+}
+
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
+
+// chunkPaths lists the files touched by a diff chunk.
+func chunkPaths(chunk string) []string {
+	var paths []string
+	for _, f := range diffpack.Parse(chunk) {
+		if f.Path != "" {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+func buildReviewPrompt(template string, pr *reviewPR, chunk string, content func(path string) string) string {
+	var b strings.Builder
+	b.WriteString(template)
+	fmt.Fprintf(&b, "\n\nPR title: %s\n\nPR description:\n%s\n", pr.Title, strings.TrimSpace(pr.Body))
+	for _, path := range chunkPaths(chunk) {
+		if c := content(path); c != "" {
+			fmt.Fprintf(&b, "\nContent of %s after the change:\n%s\n", path, c)
+		}
+	}
+	fmt.Fprintf(&b, "\nDiff:\n%s\n", chunk)
+	return b.String()
+}
+
+// parseFindings reads the JSON array of findings from model output,
+// tolerating code fences and text around it.
+func parseFindings(out string) ([]reviewFinding, error) {
+	s := stripCodeFence(strings.TrimSpace(out))
+	start, end := strings.Index(s, "["), strings.LastIndex(s, "]")
+	if start < 0 || end < start {
+		return nil, errors.New("model returned no JSON findings")
+	}
+	var findings []reviewFinding
+	if err := json.Unmarshal([]byte(s[start:end+1]), &findings); err != nil {
+		return nil, fmt.Errorf("parse findings: %w", err)
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		if _, ok := severityOrder[f.Severity]; !ok {
+			f.Severity = "info"
+		}
+		if strings.TrimSpace(f.Message) == "" {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, nil
+}
+
+// markInDiff flags findings whose line is an added or context line of the
+// diff; only those can become inline review comments.
+func markInDiff(findings []reviewFinding, files []diffpack.File) {
+	lines := map[string]map[int]bool{}
+	for _, f := range files {
+		set := map[int]bool{}
+		for _, h := range f.Hunks {
+			n := 0
+			for i, line := range strings.Split(strings.TrimRight(h, "\n"), "\n") {
+				if i == 0 {
+					m := hunkHeaderRe.FindStringSubmatch(line)
+					if m == nil {
+						break
+					}
+					n, _ = strconv.Atoi(m[1])
+					continue
+				}
+				switch {
+				case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+				default:
+					set[n] = true
+					n++
+				}
+			}
+		}
+		lines[f.Path] = set
+	}
+	for i := range findings {
+		findings[i].InDiff = lines[findings[i].File][findings[i].Line]
+	}
+}
+
+func sortFindings(findings []reviewFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if severityOrder[a.Severity] != severityOrder[b.Severity] {
+			return severityOrder[a.Severity] > severityOrder[b.Severity]
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+func findingsMarkdown(pr *reviewPR, findings []reviewFinding) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Review of #%d %s\n\n", pr.Number, safeOneLine(pr.Title))
+	for i, f := range findings {
+		loc := fmt.Sprintf("%s:%d", f.File, f.Line)
+		if !f.InDiff {
+			loc += " (outside the diff)"
+		}
+		fmt.Fprintf(&md, "## %d. %s · `%s`\n\n%s\n\n", i+1, f.Severity, loc, strings.TrimSpace(f.Message))
+	}
+	return md.String()
+}
+
+// pickFindings resolves a selection like "1,3-5" or "all" to findings.
+func pickFindings(findings []reviewFinding, sel string) ([]reviewFinding, error) {
+	sel = strings.TrimSpace(sel)
+	switch strings.ToLower(sel) {
+	case "":
+		return nil, nil
+	case "all", "a":
+		return findings, nil
+	}
+	var out []reviewFinding
+	seen := map[int]bool{}
+	for _, part := range strings.FieldsFunc(sel, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection: %s", part)
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid selection: %s", part)
+			}
+			if b < a {
+				return nil, fmt.Errorf("invalid selection: %s (did you mean %d-%d?)", part, b, a)
+			}
+		}
+		for i := a; i <= b; i++ {
+			if i < 1 || i > len(findings) {
+				return nil, fmt.Errorf("no finding %d", i)
+			}
+			if !seen[i] {
+				seen[i] = true
+				out = append(out, findings[i-1])
+			}
+		}
+	}
+	return out, nil
+}
+
+// submitPendingReview creates a review without an event, which GitHub keeps
+// pending until the user submits it. Findings outside the diff go into the
+// review body.
+func submitPendingReview(repo string, number int, commit string, findings []reviewFinding) (string, error) {
+	type reviewComment struct {
+		Path string `json:"path"`
+		Line int    `json:"line"`
+		Side string `json:"side"`
+		Body string `json:"body"`
+	}
+	payload := struct {
+		CommitID string          `json:"commit_id,omitempty"`
+		Body     string          `json:"body,omitempty"`
+		Comments []reviewComment `json:"comments"`
+	}{CommitID: commit, Comments: []reviewComment{}}
+	var body strings.Builder
+	for _, f := range findings {
+		text := fmt.Sprintf("**%s**: %s", f.Severity, strings.TrimSpace(f.Message))
+		if f.InDiff {
+			payload.Comments = append(payload.Comments, reviewComment{Path: f.File, Line: f.Line, Side: "RIGHT", Body: text})
+			continue
+		}
+		fmt.Fprintf(&body, "- `%s:%d` %s\n", f.File, f.Line, text)
+	}
+	payload.Body = strings.TrimSpace(body.String())
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("repos/%s/pulls/%d/reviews", repo, number)
+	c := exec.Command("gh", "api", "-X", "POST", path, "--input", "-")
+	c.Stdin = bytes.NewReader(data)
+	out, err := c.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return "", fmt.Errorf("gh api %s failed: %s", path, string(ee.Stderr))
+		}
+		return "", err
+	}
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	_ = json.Unmarshal(out, &created)
+	return created.HTMLURL, nil
+}
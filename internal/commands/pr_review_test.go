@@ -0,0 +1,29 @@
+package commands
+
+import "testing"
+
+func TestPickFindings(t *testing.T) {
+	findings := make([]reviewFinding, 5)
+	for _, tc := range []struct {
+		sel  string
+		want int
+	}{
+		{"", 0},
+		{"all", 5},
+		{"1,3", 2},
+		{"2-4 4", 3},
+	} {
+		got, err := pickFindings(findings, tc.sel)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.sel, err)
+		}
+		if len(got) != tc.want {
+			t.Errorf("%q picked %d, want %d", tc.sel, len(got), tc.want)
+		}
+	}
+	for _, sel := range []string{"5-3", "0", "6", "x", "1-"} {
+		if _, err := pickFindings(findings, sel); err == nil {
+			t.Errorf("%q: expected an error", sel)
+		}
+	}
+}
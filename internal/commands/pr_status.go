@@ -0,0 +1,458 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/stack"
+	"github.com/spf13/cobra"
+)
+
+// Checklist states of `noji pr status`. Only pass and warn count as ready.
+const (
+	statusPass    = "pass"
+	statusWarn    = "warn"
+	statusPending = "pending"
+	statusFail    = "fail"
+)
+
+// statusItem is one line of the readiness checklist.
+type statusItem struct {
+	Name   string   `json:"name"`
+	State  string   `json:"state"`
+	Detail string   `json:"detail"`
+	Items  []string `json:"items,omitempty"` // e.g. failing job names
+}
+
+// prStatus is the merge readiness report of a PR.
+type prStatus struct {
+	Number int          `json:"number"`
+	Title  string       `json:"title"`
+	URL    string       `json:"url"`
+	Ready  bool         `json:"ready"`
+	Checks []statusItem `json:"checks"`
+}
+
+// ghStatusPR is the subset of `gh pr view --json` used by pr status.
+type ghStatusPR struct {
+	Number           int    `json:"number"`
+	Title            string `json:"title"`
+	URL              string `json:"url"`
+	Body             string `json:"body"`
+	IsDraft          bool   `json:"isDraft"`
+	Mergeable        string `json:"mergeable"`        // MERGEABLE|CONFLICTING|UNKNOWN
+	MergeStateStatus string `json:"mergeStateStatus"` // BEHIND|BLOCKED|CLEAN|DIRTY|...
+	ReviewDecision   string `json:"reviewDecision"`   // APPROVED|CHANGES_REQUESTED|REVIEW_REQUIRED|""
+	BaseRefName      string `json:"baseRefName"`
+	HeadRefOid       string `json:"headRefOid"`
+	LatestReviews    []struct {
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		State string `json:"state"`
+	} `json:"latestReviews"`
+}
+
+// ghCheck is one entry of `gh pr checks --json`.
+type ghCheck struct {
+	Name     string `json:"name"`
+	Workflow string `json:"workflow"`
+	Bucket   string `json:"bucket"` // pass|fail|pending|skipping|cancel
+}
+
+var (
+	htmlCommentRe      = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markdownCodeRe     = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	todoMarkerRe       = regexp.MustCompile(`\b(TODO|TBD|FIXME)\b`)
+	anglePlaceholderRe = regexp.MustCompile(`<([A-Za-z][^<>\n]*\s[^<>\n]*)>`)
+	// an HTML tag has attributes (name=value) or is self-closing; <a short
+	// summary> is a placeholder
+	htmlTagRe = regexp.MustCompile(`^(?i)(a|img|br|p|div|span|details|summary|sub|sup|table|tr|td|th|code|pre|kbd)(\s*/|\s+open|\s.*=.*)$`)
+)
+
+func newPRStatusCmd() *cobra.Command {
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the current branch's PR is ready to merge (exits 1 when not)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			st, err := collectPRStatus()
+			if err != nil {
+				return err
+			}
+			if !outOpts.Human() {
+				if err := p.Render(outOpts, st); err != nil {
+					return err
+				}
+			} else {
+				renderPRStatus(p, st)
+			}
+			if !st.Ready {
+				return &ExitError{Code: 1}
+			}
+			return nil
+		},
+	}
+	outFlags = addOutputFlags(cmd)
+	outFlags.addJSON(cmd)
+	return cmd
+}
+
+func collectPRStatus() (*prStatus, error) {
+	out, err := exec.Command("gh", "pr", "view", "--json",
+		"number,title,url,body,isDraft,mergeable,mergeStateStatus,reviewDecision,baseRefName,headRefOid,latestReviews").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh pr view failed: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+	var pr ghStatusPR
+	if err := json.Unmarshal(out, &pr); err != nil {
+		return nil, fmt.Errorf("parse gh pr view json: %w", err)
+	}
+	repo, err := currentRepo()
+	if err != nil {
+		return nil, err
+	}
+	checks, err := fetchChecks(pr.Number)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := fetchReviewThreads(repo, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+	behind, err := behindBy(repo, pr.BaseRefName, pr.HeadRefOid)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &prStatus{Number: pr.Number, Title: pr.Title, URL: pr.URL}
+	st.Checks = []statusItem{
+		draftStatus(pr),
+		ciStatus(checks),
+		reviewStatus(pr, requiredApprovals(repo, pr.Number)),
+		threadStatus(threads),
+		conflictStatus(pr),
+		behindStatus(pr, behind),
+		bodyStatus(pr.Body),
+	}
+	st.Ready = true
+	for _, c := range st.Checks {
+		if c.State == statusFail || c.State == statusPending {
+			st.Ready = false
+		}
+	}
+	return st, nil
+}
+
+// fetchChecks lists the PR's checks. gh exits non-zero when checks fail or
+// are pending, so stdout is parsed regardless of the exit status.
+func fetchChecks(number int) ([]ghCheck, error) {
+	c := exec.Command("gh", "pr", "checks", strconv.Itoa(number), "--json", "name,workflow,bucket")
+	out, err := c.Output()
+	var checks []ghCheck
+	if jerr := json.Unmarshal(out, &checks); jerr == nil {
+		return checks, nil
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		if strings.Contains(string(ee.Stderr), "no checks reported") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("gh pr checks failed: %s", string(ee.Stderr))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("parse gh pr checks json: %s", strings.TrimSpace(string(out)))
+}
+
+// requiredApprovals reads the approving review count required by branch
+// protection; -1 when unknown (no rule or no permission to read it).
+func requiredApprovals(repo string, number int) int {
+	owner, name, _ := strings.Cut(repo, "/")
+	query := `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) { baseRef { branchProtectionRule { requiredApprovingReviewCount } } }
+  }
+}`
+	out, err := exec.Command("gh", "api", "graphql", "-f", "query="+query,
+		"-f", "owner="+owner, "-f", "name="+name, "-F", fmt.Sprintf("number=%d", number)).Output()
+	if err != nil {
+		return -1
+	}
+	var resp struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					BaseRef *struct {
+						BranchProtectionRule *struct {
+							RequiredApprovingReviewCount int `json:"requiredApprovingReviewCount"`
+						} `json:"branchProtectionRule"`
+					} `json:"baseRef"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(out, &resp) != nil {
+		return -1
+	}
+	ref := resp.Data.Repository.PullRequest.BaseRef
+	if ref == nil || ref.BranchProtectionRule == nil {
+		return -1
+	}
+	return ref.BranchProtectionRule.RequiredApprovingReviewCount
+}
+
+// behindBy returns how many commits of base are missing from head.
+func behindBy(repo, base, head string) (int, error) {
+	path := fmt.Sprintf("repos/%s/compare/%s...%s", repo, escapePath(base), head)
+	out, err := exec.Command("gh", "api", path, "-q", ".behind_by").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return 0, fmt.Errorf("gh api %s failed: %s", path, string(ee.Stderr))
+		}
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("parse behind_by: %w", err)
+	}
+	return n, nil
+}
+
+func draftStatus(pr ghStatusPR) statusItem {
+	if pr.IsDraft {
+		return statusItem{Name: "Draft", State: statusFail, Detail: "PR is still a draft"}
+	}
+	return statusItem{Name: "Draft", State: statusPass, Detail: "ready for review"}
+}
+
+func ciStatus(checks []ghCheck) statusItem {
+	it := statusItem{Name: "CI"}
+	if len(checks) == 0 {
+		it.State, it.Detail = statusPass, "no checks reported"
+		return it
+	}
+	var failed, pending []string
+	passed := 0
+	for _, c := range checks {
+		name := c.Name
+		if c.Workflow != "" && c.Workflow != c.Name {
+			name = c.Workflow + " / " + c.Name
+		}
+		switch c.Bucket {
+		case "fail", "cancel":
+			failed = append(failed, name)
+		case "pending":
+			pending = append(pending, name)
+		case "pass":
+			passed++
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		it.State, it.Detail, it.Items = statusFail, fmt.Sprintf("%d of %d checks failing", len(failed), len(checks)), failed
+	case len(pending) > 0:
+		it.State, it.Detail, it.Items = statusPending, fmt.Sprintf("%d of %d checks pending", len(pending), len(checks)), pending
+	default:
+		it.State, it.Detail = statusPass, fmt.Sprintf("%d checks passed", passed)
+	}
+	return it
+}
+
+func reviewStatus(pr ghStatusPR, required int) statusItem {
+	it := statusItem{Name: "Reviews"}
+	approvals := 0
+	for _, r := range pr.LatestReviews {
+		switch r.State {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			it.Items = append(it.Items, "@"+r.Author.Login+" requested changes")
+		}
+	}
+	count := fmt.Sprintf("%d approval(s)", approvals)
+	if required >= 0 {
+		count = fmt.Sprintf("%d/%d approvals", approvals, required)
+	}
+	switch {
+	case pr.ReviewDecision == "CHANGES_REQUESTED" || len(it.Items) > 0:
+		it.State, it.Detail = statusFail, count+", changes requested"
+	case pr.ReviewDecision == "REVIEW_REQUIRED" || (required > 0 && approvals < required):
+		it.State, it.Detail = statusFail, count+", more approvals required"
+	case approvals == 0:
+		it.State, it.Detail = statusWarn, "no approvals yet"
+	default:
+		it.State, it.Detail = statusPass, count
+	}
+	return it
+}
+
+func threadStatus(threads []reviewThread) statusItem {
+	it := statusItem{Name: "Threads"}
+	for _, t := range threads {
+		if !t.IsResolved {
+			it.Items = append(it.Items, t.Path)
+		}
+	}
+	if n := len(it.Items); n > 0 {
+		it.State, it.Detail = statusFail, fmt.Sprintf("%d unresolved review thread(s)", n)
+		return it
+	}
+	it.State, it.Detail = statusPass, "all review threads resolved"
+	return it
+}
+
+func conflictStatus(pr ghStatusPR) statusItem {
+	switch pr.Mergeable {
+	case "CONFLICTING":
+		return statusItem{Name: "Conflicts", State: statusFail, Detail: "merge conflicts with " + pr.BaseRefName}
+	case "MERGEABLE":
+		return statusItem{Name: "Conflicts", State: statusPass, Detail: "no merge conflicts"}
+	default:
+		return statusItem{Name: "Conflicts", State: statusPending, Detail: "GitHub is still checking mergeability"}
+	}
+}
+
+func behindStatus(pr ghStatusPR, behind int) statusItem {
+	it := statusItem{Name: "Base", Detail: "up to date with " + pr.BaseRefName}
+	switch {
+	case behind > 0 && pr.MergeStateStatus == "BEHIND":
+		it.State, it.Detail = statusFail, fmt.Sprintf("%d commit(s) behind %s; branch must be up to date", behind, pr.BaseRefName)
+	case behind > 0:
+		it.State, it.Detail = statusWarn, fmt.Sprintf("%d commit(s) behind %s", behind, pr.BaseRefName)
+	default:
+		it.State = statusPass
+	}
+	return it
+}
+
+func bodyStatus(body string) statusItem {
+	it := statusItem{Name: "Description"}
+	if strings.TrimSpace(body) == "" {
+		it.State, it.Detail = statusFail, "PR description is empty"
+		return it
+	}
+	it.Items = templatePlaceholders(body, prTemplateLines())
+	if len(it.Items) > 0 {
+		it.State, it.Detail = statusFail, "description still contains template placeholders"
+		return it
+	}
+	it.State, it.Detail = statusPass, "no template placeholders"
+	return it
+}
+
+// templatePlaceholders finds leftovers of a PR template in body: HTML
+// comments, TODO markers, <angle placeholders> outside code and unchanged
+// template text.
+func templatePlaceholders(body string, template []string) []string {
+	var found []string
+	body = stack.StripNav(body)
+	if htmlCommentRe.MatchString(body) {
+		found = append(found, "HTML comment from the template")
+	}
+	clean := htmlCommentRe.ReplaceAllString(body, "")
+	// code may contain TODOs and generics like Map<K, V>
+	prose := markdownCodeRe.ReplaceAllString(clean, "")
+	for _, m := range todoMarkerRe.FindAllString(prose, -1) {
+		found = append(found, m+" marker")
+	}
+	for _, m := range anglePlaceholderRe.FindAllStringSubmatch(prose, -1) {
+		if !htmlTagRe.MatchString(m[1]) {
+			found = append(found, m[0])
+		}
+	}
+	lines := map[string]bool{}
+	for _, l := range strings.Split(clean, "\n") {
+		lines[strings.TrimSpace(l)] = true
+	}
+	for _, l := range template {
+		if lines[l] {
+			found = append(found, fmt.Sprintf("template text %q", l))
+		}
+	}
+	return found
+}
+
+// prTemplateLines returns the instructional lines of the repository's PR
+// template: everything except headings, checkboxes, rules and comments.
+func prTemplateLines() []string {
+	root, err := gitRoot()
+	if err != nil {
+		return nil
+	}
+	for _, name := range []string{
+		".github/pull_request_template.md",
+		".github/PULL_REQUEST_TEMPLATE.md",
+		"docs/pull_request_template.md",
+		"PULL_REQUEST_TEMPLATE.md",
+		"pull_request_template.md",
+	} {
+		b, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		var out []string
+		for _, l := range strings.Split(htmlCommentRe.ReplaceAllString(string(b), ""), "\n") {
+			l = strings.TrimSpace(l)
+			switch {
+			case l == "", strings.HasPrefix(l, "#"), strings.HasPrefix(l, "- ["), strings.HasPrefix(l, "* ["),
+				strings.Trim(l, "-*_") == "", l == "-", l == "*":
+				continue
+			}
+			out = append(out, l)
+		}
+		return out
+	}
+	return nil
+}
+
+func renderPRStatus(p *output.Printer, st *prStatus) {
+	p.Infof("PR #%d %s\n", st.Number, safeOneLine(st.Title))
+	p.Printf("%s\n\n", st.URL)
+	for _, c := range st.Checks {
+		var mark string
+		switch c.State {
+		case statusPass:
+			mark = p.Good("✓")
+		case statusFail:
+			mark = p.Bad("✗")
+		case statusPending:
+			mark = p.Caution("…")
+		default:
+			mark = p.Caution("!")
+		}
+		p.Printf("%s %-12s %s\n", mark, c.Name, c.Detail)
+		for _, item := range c.Items {
+			p.Printf("    - %s\n", item)
+		}
+	}
+	p.Printf("\n")
+	if st.Ready {
+		p.Successf("Ready to merge.\n")
+	} else {
+		p.Warnf("Not ready yet.\n")
+	}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTemplatePlaceholders(t *testing.T) {
+	template := []string{"Describe your change.", "Link the ticket."}
+	for _, tc := range []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"clean", "Fixes the login redirect.\n\nCloses #12.", nil},
+		{"html", "<details open>\n<summary>Logs</summary>\n\n```\nok\n```\n</details>\n<img src=\"a.png\" alt=\"screenshot\">\n<br />\n<a href=\"https://x\">link</a>", nil},
+		{"comment", "Done.\n<!-- Describe your change -->", []string{"HTML comment from the template"}},
+		{"todo", "Fixes it. TODO: tests\n<!-- TODO inside a comment -->", []string{"HTML comment from the template", "TODO marker"}},
+		{"todo word", "Adds a todoList helper.", nil},
+		{"angle", "Fixes <short description of the bug>.\nSee <a short summary>.", []string{"<short description of the bug>", "<a short summary>"}},
+		{"code", "Returns `Map<String, Int>` values.\n\n```go\n// TODO: remove <after the release>\n```", nil},
+		{"template", "Describe your change.\n\nReal text.\n  Link the ticket.  ", []string{`template text "Describe your change."`, `template text "Link the ticket."`}},
+		{"template inline", "I describe your change. Link the ticket. Done.", nil},
+		{"nav", "Text.\n\n<!-- noji-stack -->\n### Stack\n<!-- /noji-stack -->\n", nil},
+	} {
+		if got := templatePlaceholders(tc.body, template); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCIStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		checks []ghCheck
+		state  string
+		items  []string
+	}{
+		{"none", nil, statusPass, nil},
+		{"passed", []ghCheck{{Name: "test", Bucket: "pass"}, {Name: "lint", Bucket: "skipping"}}, statusPass, nil},
+		{"pending", []ghCheck{{Name: "test", Bucket: "pass"}, {Name: "build", Workflow: "CI", Bucket: "pending"}}, statusPending, []string{"CI / build"}},
+		{"cancelled", []ghCheck{{Name: "test", Workflow: "test", Bucket: "cancel"}}, statusFail, []string{"test"}},
+		{"failed before pending", []ghCheck{{Name: "a", Bucket: "pending"}, {Name: "b", Bucket: "fail"}, {Name: "c", Bucket: "cancel"}}, statusFail, []string{"b", "c"}},
+	} {
+		got := ciStatus(tc.checks)
+		if got.State != tc.state || !slices.Equal(got.Items, tc.items) {
+			t.Errorf("%s: %s %q, want %s %q", tc.name, got.State, got.Items, tc.state, tc.items)
+		}
+	}
+}
+
+func TestReviewStatus(t *testing.T) {
+	pr := func(decision string, states ...string) ghStatusPR {
+		p := ghStatusPR{ReviewDecision: decision}
+		p.LatestReviews = slices.Grow(p.LatestReviews, len(states))[:len(states)]
+		for i, s := range states {
+			p.LatestReviews[i].Author.Login, p.LatestReviews[i].State = string(rune('a'+i)), s
+		}
+		return p
+	}
+	for _, tc := range []struct {
+		name     string
+		pr       ghStatusPR
+		required int
+		state    string
+		detail   string
+	}{
+		{"approved", pr("APPROVED", "APPROVED"), -1, statusPass, "1 approval(s)"},
+		{"no reviews", pr(""), -1, statusWarn, "no approvals yet"},
+		{"commented", pr("", "COMMENTED"), 0, statusWarn, "no approvals yet"},
+		{"required", pr("REVIEW_REQUIRED", "APPROVED"), 2, statusFail, "1/2 approvals, more approvals required"},
+		{"enough", pr("APPROVED", "APPROVED", "APPROVED"), 2, statusPass, "2/2 approvals"},
+		{"short of rule", pr("", "APPROVED"), 2, statusFail, "1/2 approvals, more approvals required"},
+		{"changes", pr("CHANGES_REQUESTED", "APPROVED", "CHANGES_REQUESTED"), -1, statusFail, "1 approval(s), changes requested"},
+		{"changes undecided", pr("", "CHANGES_REQUESTED"), -1, statusFail, "0 approval(s), changes requested"},
+	} {
+		got := reviewStatus(tc.pr, tc.required)
+		if got.State != tc.state || got.Detail != tc.detail {
+			t.Errorf("%s: %s %q, want %s %q", tc.name, got.State, got.Detail, tc.state, tc.detail)
+		}
+	}
+	if got := reviewStatus(pr("", "CHANGES_REQUESTED"), -1).Items; !slices.Equal(got, []string{"@a requested changes"}) {
+		t.Errorf("items = %q", got)
+	}
+}
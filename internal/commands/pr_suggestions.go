@@ -0,0 +1,452 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/spf13/cobra"
+)
+
+// Suggestion states relative to the working tree.
+const (
+	suggestionPending  = "pending"  // original lines still at the commented range
+	suggestionMoved    = "moved"    // original lines found elsewhere in the file
+	suggestionDrift    = "drift"    // the lines changed since the comment
+	suggestionApplied  = "applied"  // the replacement is already in place
+	suggestionOutdated = "outdated" // GitHub no longer maps the comment to a line
+)
+
+var suggestionRe = regexp.MustCompile("(?s)```suggestion[^\n]*\n(.*?)```")
+
+// suggestion is a ```suggestion block from a review comment. A comment with
+// several blocks offers alternatives for the same lines.
+type suggestion struct {
+	ID int64 `json:"id"`
+	// Block is the 1-based position of the block in the comment.
+	Block       int      `json:"block"`
+	Reviewer    string   `json:"reviewer"`
+	ReviewerID  int64    `json:"-"`
+	Path        string   `json:"path"`
+	StartLine   int      `json:"start_line"`
+	EndLine     int      `json:"end_line"`
+	Original    []string `json:"original"`
+	Replacement []string `json:"replacement"`
+	Status      string   `json:"status"`
+	URL         string   `json:"url"`
+	// offset is added to the line range when the original lines moved
+	offset int
+}
+
+func newPRSuggestionsCmd() *cobra.Command {
+	var all bool
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "suggestions",
+		Short: "List unapplied ```suggestion blocks from reviews on the current branch's PR",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			sugs, err := loadSuggestions()
+			if err != nil {
+				return err
+			}
+			if !all {
+				sugs = unapplied(sugs)
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, sugs)
+			}
+			if len(sugs) == 0 {
+				p.Infof("No unapplied suggestions.\n")
+				return nil
+			}
+			renderSuggestions(p, sugs)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Include applied and outdated suggestions")
+	outFlags = addOutputFlags(cmd)
+	cmd.AddCommand(newPRSuggestionsApplyCmd())
+	return cmd
+}
+
+func newPRSuggestionsApplyCmd() *cobra.Command {
+	var commit bool
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "apply [ids...]",
+		Short: "Apply suggestions to the working tree (all pending ones if no ids are given)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			sugs, err := loadSuggestions()
+			if err != nil {
+				return err
+			}
+			selected, err := selectSuggestions(unapplied(sugs), args)
+			if err != nil {
+				return err
+			}
+			var apply []suggestion
+			for _, s := range selected {
+				switch s.Status {
+				case suggestionDrift:
+					if !force {
+						p.Warnf("Skipping %s (%s:%d): lines changed since the comment (use --force to overwrite)\n", s.ref(), s.Path, s.StartLine)
+						continue
+					}
+				case suggestionOutdated:
+					p.Warnf("Skipping %s (%s): comment is outdated\n", s.ref(), s.Path)
+					continue
+				}
+				apply = append(apply, s)
+			}
+			if len(apply) == 0 {
+				p.Infof("Nothing to apply.\n")
+				return nil
+			}
+			root, err := gitRoot()
+			if err != nil {
+				return err
+			}
+			files, err := applySuggestions(root, apply)
+			if err != nil {
+				return err
+			}
+			for _, s := range apply {
+				p.Successf("Applied %s to %s:%d-%d\n", s.ref(), s.Path, s.StartLine+s.offset, s.EndLine+s.offset)
+			}
+			if !commit {
+				return nil
+			}
+			if err := commitSuggestions(root, files, apply); err != nil {
+				return err
+			}
+			p.Successf("Committed %d suggestion(s).\n", len(apply))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&commit, "commit", false, "Create a single commit crediting the reviewers as co-authors")
+	cmd.Flags().BoolVar(&force, "force", false, "Apply suggestions whose lines changed since the comment")
+	return cmd
+}
+
+// ref identifies the suggestion on the command line: the comment ID, with
+// the block number for the alternatives after the first, e.g. 123.2.
+func (s suggestion) ref() string {
+	if s.Block > 1 {
+		return fmt.Sprintf("%d.%d", s.ID, s.Block)
+	}
+	return strconv.FormatInt(s.ID, 10)
+}
+
+func unapplied(sugs []suggestion) []suggestion {
+	out := []suggestion{}
+	for _, s := range sugs {
+		if s.Status != suggestionApplied && s.Status != suggestionOutdated {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func selectSuggestions(sugs []suggestion, ids []string) ([]suggestion, error) {
+	if len(ids) == 0 {
+		// alternatives are only applied when asked for by id
+		var first []suggestion
+		for _, s := range sugs {
+			if s.Block == 1 {
+				first = append(first, s)
+			}
+		}
+		return first, nil
+	}
+	byRef := map[string]suggestion{}
+	for _, s := range sugs {
+		byRef[s.ref()] = s
+	}
+	var out []suggestion
+	for _, raw := range ids {
+		ref := strings.TrimPrefix(raw, "id:")
+		id, block, _ := strings.Cut(ref, ".")
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid suggestion id: %s", raw)
+		}
+		if n, err := strconv.Atoi(block); block != "" && (err != nil || n < 1) {
+			return nil, fmt.Errorf("invalid suggestion id: %s", raw)
+		} else if n == 1 {
+			ref = id
+		}
+		s, ok := byRef[ref]
+		if !ok {
+			return nil, fmt.Errorf("no unapplied suggestion with id %s", ref)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// loadSuggestions fetches review comments of the current branch's PR and
+// extracts their suggestions with a status against the working tree.
+func loadSuggestions() ([]suggestion, error) {
+	if err := ensureGh(); err != nil {
+		return nil, err
+	}
+	pr, err := currentPR()
+	if err != nil {
+		return nil, err
+	}
+	repo, err := currentRepo()
+	if err != nil {
+		return nil, err
+	}
+	root, err := gitRoot()
+	if err != nil {
+		return nil, err
+	}
+	comments, err := fetchReviewComments(repo, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+	out := []suggestion{}
+	for _, c := range comments {
+		if strings.EqualFold(c.Side, "LEFT") {
+			continue
+		}
+		for i, m := range suggestionRe.FindAllStringSubmatch(c.Body, -1) {
+			s := suggestion{
+				ID:          c.ID,
+				Block:       i + 1,
+				Reviewer:    c.User.Login,
+				ReviewerID:  c.User.ID,
+				Path:        c.Path,
+				Replacement: splitLines(m[1]),
+				URL:         c.HTMLURL,
+			}
+			if c.Line == nil {
+				s.Status = suggestionOutdated
+				out = append(out, s)
+				continue
+			}
+			s.EndLine = *c.Line
+			s.StartLine = s.EndLine
+			if c.StartLine != nil {
+				s.StartLine = *c.StartLine
+			}
+			s.Original = hunkTail(c.DiffHunk, s.EndLine-s.StartLine+1)
+			s.Status, s.offset = suggestionStatus(filepath.Join(root, c.Path), s)
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		if out[i].StartLine != out[j].StartLine {
+			return out[i].StartLine < out[j].StartLine
+		}
+		return out[i].Block < out[j].Block
+	})
+	return out, nil
+}
+
+// splitLines splits text into lines without the trailing empty line.
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
+
+// hunkTail returns the last n lines of the new side of a diff hunk, which are
+// the lines a review comment ending at the hunk was left on.
+func hunkTail(hunk string, n int) []string {
+	var right []string
+	for _, l := range strings.Split(strings.TrimRight(hunk, "\n"), "\n") {
+		if strings.HasPrefix(l, "@@") || strings.HasPrefix(l, "-") || strings.HasPrefix(l, `\`) {
+			continue
+		}
+		if l != "" {
+			l = l[1:]
+		}
+		right = append(right, l)
+	}
+	if len(right) > n {
+		right = right[len(right)-n:]
+	}
+	return right
+}
+
+func suggestionStatus(file string, s suggestion) (string, int) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return suggestionDrift, 0
+	}
+	lines := splitLines(string(b))
+	if s.StartLine >= 1 && s.EndLine <= len(lines) {
+		cur := lines[s.StartLine-1 : s.EndLine]
+		if equalLines(cur, s.Original) {
+			return suggestionPending, 0
+		}
+		if equalLines(cur, s.Replacement) {
+			return suggestionApplied, 0
+		}
+	}
+	// Look for the original block elsewhere (e.g. lines were added above).
+	if len(s.Original) > 0 {
+		found := -1
+		for i := 0; i+len(s.Original) <= len(lines); i++ {
+			if equalLines(lines[i:i+len(s.Original)], s.Original) {
+				if found >= 0 {
+					return suggestionDrift, 0 // ambiguous
+				}
+				found = i
+			}
+		}
+		if found >= 0 {
+			return suggestionMoved, found + 1 - s.StartLine
+		}
+	}
+	return suggestionDrift, 0
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimRight(a[i], "\r") != strings.TrimRight(b[i], "\r") {
+			return false
+		}
+	}
+	return true
+}
+
+// applySuggestions rewrites the affected files bottom-up so earlier line
+// numbers stay valid. It returns the touched paths.
+func applySuggestions(root string, sugs []suggestion) ([]string, error) {
+	byPath := map[string][]suggestion{}
+	var paths []string
+	for _, s := range sugs {
+		if _, ok := byPath[s.Path]; !ok {
+			paths = append(paths, s.Path)
+		}
+		byPath[s.Path] = append(byPath[s.Path], s)
+	}
+	for _, path := range paths {
+		list := byPath[path]
+		sort.Slice(list, func(i, j int) bool { return list[i].StartLine+list[i].offset > list[j].StartLine+list[j].offset })
+		full := filepath.Join(root, path)
+		b, err := os.ReadFile(full)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		content := string(b)
+		trailingNL := strings.HasSuffix(content, "\n")
+		lines := splitLines(content)
+		prevStart := len(lines) + 1
+		for _, s := range list {
+			start, end := s.StartLine+s.offset, s.EndLine+s.offset
+			if start < 1 || end > len(lines) || end >= prevStart {
+				return nil, fmt.Errorf("suggestion %s overlaps another suggestion or is out of range", s.ref())
+			}
+			next := append([]string{}, lines[:start-1]...)
+			next = append(next, s.Replacement...)
+			lines = append(next, lines[end:]...)
+			prevStart = start
+		}
+		out := strings.Join(lines, "\n")
+		if trailingNL {
+			out += "\n"
+		}
+		st, err := os.Stat(full)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(full, []byte(out), st.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+	return paths, nil
+}
+
+// commitSuggestions commits the touched files with Co-authored-by trailers.
+func commitSuggestions(root string, files []string, sugs []suggestion) error {
+	var msg strings.Builder
+	msg.WriteString("Apply review suggestions\n\n")
+	seen := map[string]bool{}
+	var trailers []string
+	for _, s := range sugs {
+		fmt.Fprintf(&msg, "- %s:%d-%d (@%s)\n", s.Path, s.StartLine+s.offset, s.EndLine+s.offset, s.Reviewer)
+		if seen[s.Reviewer] {
+			continue
+		}
+		seen[s.Reviewer] = true
+		email := fmt.Sprintf("%s@users.noreply.github.com", s.Reviewer)
+		if s.ReviewerID != 0 {
+			email = fmt.Sprintf("%d+%s@users.noreply.github.com", s.ReviewerID, s.Reviewer)
+		}
+		trailers = append(trailers, fmt.Sprintf("Co-authored-by: %s <%s>", s.Reviewer, email))
+	}
+	msg.WriteString("\n" + strings.Join(trailers, "\n") + "\n")
+
+	tmp, err := createTempFile(msg.String())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	add := exec.Command("git", append([]string{"add", "--"}, files...)...)
+	add.Dir = root
+	if out, err := add.CombinedOutput(); err != nil {
+		return fmt.Errorf("git add failed: %s", strings.TrimSpace(string(out)))
+	}
+	commit := exec.Command("git", append([]string{"commit", "-F", tmp, "--"}, files...)...)
+	commit.Dir = root
+	if out, err := commit.CombinedOutput(); err != nil {
+		return fmt.Errorf("git commit failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// renderSuggestions prints each suggestion with a small diff.
+func renderSuggestions(p *output.Printer, sugs []suggestion) {
+	for _, s := range sugs {
+		loc := fmt.Sprintf("%s:%d", s.Path, s.StartLine+s.offset)
+		if s.EndLine != s.StartLine {
+			loc = fmt.Sprintf("%s:%d-%d", s.Path, s.StartLine+s.offset, s.EndLine+s.offset)
+		}
+		if s.Status == suggestionOutdated {
+			loc = s.Path
+		}
+		p.Infof("id:%s %s\n", s.ref(), loc)
+		status := s.Status
+		if s.Status == suggestionMoved {
+			status = fmt.Sprintf("%s (%+d lines)", s.Status, s.offset)
+		}
+		p.Printf("  @%s · %s\n", p.ColorizeAuthor(s.Reviewer), status)
+		var diff strings.Builder
+		for _, l := range s.Original {
+			diff.WriteString("-" + l + "\n")
+		}
+		for _, l := range s.Replacement {
+			diff.WriteString("+" + l + "\n")
+		}
+		rendered := p.RenderMarkdown("```diff\n" + diff.String() + "```")
+		for _, line := range strings.Split(strings.TrimRight(rendered, "\n"), "\n") {
+			p.Printf("  %s\n", line)
+		}
+		p.Printf("\n")
+	}
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelectSuggestions(t *testing.T) {
+	sugs := []suggestion{{ID: 1, Block: 1}, {ID: 1, Block: 2}, {ID: 2, Block: 1}}
+	refs := func(list []suggestion) []string {
+		var out []string
+		for _, s := range list {
+			out = append(out, s.ref())
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		ids  []string
+		want []string
+	}{
+		{nil, []string{"1", "2"}},
+		{[]string{"1.2"}, []string{"1.2"}},
+		{[]string{"id:1.1", "2"}, []string{"1", "2"}},
+	} {
+		got, err := selectSuggestions(sugs, tc.ids)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.ids, err)
+		}
+		if g := refs(got); !slices.Equal(g, tc.want) {
+			t.Errorf("%v = %v, want %v", tc.ids, g, tc.want)
+		}
+	}
+	for _, ids := range [][]string{{"1.3"}, {"x"}, {"1.0"}} {
+		if _, err := selectSuggestions(sugs, ids); err == nil {
+			t.Errorf("%v: expected an error", ids)
+		}
+	}
+}
+
+func TestSuggestionBlocks(t *testing.T) {
+	body := "Either\n```suggestion\na\n```\nor\n```suggestion\nb\nc\n```\n"
+	m := suggestionRe.FindAllStringSubmatch(body, -1)
+	if len(m) != 2 || m[0][1] != "a\n" || m[1][1] != "b\nc\n" {
+		t.Errorf("blocks = %q", m)
+	}
+}
@@ -0,0 +1,209 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage config profiles for different work contexts",
+		Long: "Profiles are named sets of settings under `profiles` in the user config, e.g.\n" +
+			"profiles.work.model or profiles.oss.github.host. The active profile is chosen by\n" +
+			"--profile, NOJI_PROFILE, `profile` in the repository's .noji.yaml, a profile whose\n" +
+			"`match` patterns fit the origin remote, or `noji profile use`, in that order.",
+	}
+	cmd.AddCommand(newProfileListCmd())
+	cmd.AddCommand(newProfileUseCmd())
+	cmd.AddCommand(newProfileShowCmd())
+	return cmd
+}
+
+// profileRow is one profile in `profile list`.
+type profileRow struct {
+	Name   string   `json:"name"`
+	Active bool     `json:"active"`
+	Reason string   `json:"reason,omitempty"`
+	Match  []string `json:"match,omitempty"`
+	Keys   []string `json:"keys"`
+}
+
+func newProfileListCmd() *cobra.Command {
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles and show which one is active",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			profiles, active, err := config.Profiles()
+			if err != nil {
+				return err
+			}
+			rows := make([]profileRow, 0, len(profiles))
+			for _, pr := range profiles {
+				row := profileRow{Name: pr.Name, Match: pr.Match, Keys: slices.Sorted(maps.Keys(pr.Settings))}
+				if pr.Name == active.Name {
+					row.Active, row.Reason = true, active.Reason
+				}
+				rows = append(rows, row)
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, rows)
+			}
+			if len(rows) == 0 {
+				p.Infof("No profiles defined. Add them under `profiles` with `noji config edit`.\n")
+				return nil
+			}
+			for _, r := range rows {
+				mark := "  "
+				if r.Active {
+					mark = p.Good("* ")
+				}
+				line := mark + r.Name
+				if r.Active {
+					line += " " + p.Dim("("+profileReason(r.Reason)+")")
+				}
+				p.Printf("%s\n", line)
+				if len(r.Match) > 0 {
+					p.Printf("    match: %s\n", strings.Join(r.Match, ", "))
+				}
+				p.Printf("    sets:  %s\n", strings.Join(r.Keys, ", "))
+			}
+			return nil
+		},
+	}
+	outFlags = addOutputFlags(cmd)
+	return cmd
+}
+
+func profileReason(reason string) string {
+	switch reason {
+	case config.ProfileByFlag:
+		return "--profile"
+	case config.ProfileByEnv:
+		return config.EnvName("profile")
+	case config.ProfileByRepo:
+		return config.RepoConfigFile
+	case config.ProfileByRemote:
+		return "matches the origin remote"
+	default:
+		return "noji profile use"
+	}
+}
+
+func newProfileUseCmd() *cobra.Command {
+	var clear bool
+
+	cmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Use a profile when no flag, env, repo setting or remote match selects one",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if clear {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if clear {
+				if err := config.UseProfile(""); err != nil {
+					return err
+				}
+				p.Successf("Default profile cleared.\n")
+				return nil
+			}
+			if err := config.UseProfile(args[0]); err != nil {
+				return err
+			}
+			p.Successf("Using profile %s.\n", args[0])
+			if _, active, err := config.Profiles(); err == nil && active.Name != args[0] {
+				p.Warnf("Profile %s is active here (%s).\n", active.Name, profileReason(active.Reason))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&clear, "clear", false, "Stop using a default profile")
+	return cmd
+}
+
+func newProfileShowCmd() *cobra.Command {
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:               "show [name]",
+		Short:             "Show the settings of a profile (default: the active one)",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			profiles, active, err := config.Profiles()
+			if err != nil {
+				return err
+			}
+			name := active.Name
+			if len(args) == 1 {
+				name = args[0]
+			}
+			if name == "" {
+				p.Infof("No profile is active.\n")
+				return nil
+			}
+			i := slices.IndexFunc(profiles, func(pr config.Profile) bool { return pr.Name == name })
+			if i < 0 {
+				return fmt.Errorf("unknown profile %q", name)
+			}
+			pr := profiles[i]
+			if !outOpts.Human() {
+				return p.Render(outOpts, pr)
+			}
+			header := pr.Name
+			if pr.Name == active.Name {
+				header += " " + p.Dim("(active: "+profileReason(active.Reason)+")")
+			}
+			p.Infof("%s\n", header)
+			if len(pr.Match) > 0 {
+				p.Printf("match: %s\n", strings.Join(pr.Match, ", "))
+			}
+			keys := slices.Sorted(maps.Keys(pr.Settings))
+			width := 0
+			for _, k := range keys {
+				width = max(width, len(k))
+			}
+			for _, k := range keys {
+				p.Printf("%-*s  %s\n", width, k, formatSettingValue(pr.Settings[k]))
+			}
+			return nil
+		},
+	}
+	outFlags = addOutputFlags(cmd)
+	return cmd
+}
+
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	profiles, _, _ := config.Profiles()
+	names := make([]string, 0, len(profiles))
+	for _, pr := range profiles {
+		names = append(names, pr.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -2,14 +2,21 @@ package commands
 
 import "github.com/spf13/cobra"
 
-// Expose ready-to-use commands
+// Expose ready-to-use commands. Those running gh, opencode or Jira are
+// marked with usesTokens.
 func BuildRoot() *cobra.Command {
 	root := NewRoot()
 	root.AddCommand(newModelsCmd())
 	root.AddCommand(newUseCmd())
-	root.AddCommand(newPRCmd())
-	root.AddCommand(newTicketCmd())
+	root.AddCommand(usesTokens(newPRCmd()))
+	root.AddCommand(usesTokens(newTicketCmd()))
 	root.AddCommand(newConfigCmd())
+	root.AddCommand(newProfileCmd())
+	root.AddCommand(newAuthCmd())
 	root.AddCommand(newCurrentCmd())
+	root.AddCommand(usesTokens(newDashCmd()))
+	root.AddCommand(usesTokens(newStackCmd()))
+	root.AddCommand(usesTokens(newCommitCmd()))
+	root.AddCommand(usesTokens(newStandupCmd()))
 	return root
 }
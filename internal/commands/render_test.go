@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name.golden.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("output differs from %s:\n--- got ---\n%s--- want ---\n%s", golden, got, want)
+	}
+}
+
+// goldenPrinter writes stdout and stderr to one buffer, in order.
+func goldenPrinter(color bool) (*output.Printer, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return &output.Printer{Out: &buf, Err: &buf, Color: color, ErrColor: color}, &buf
+}
+
+var commentResults = []prWithComments{
+	{
+		Repo: "acme/app", Number: 12, Title: "Add retry to the client", URL: "https://github.com/acme/app/pull/12",
+		Priority: "high", ApprovalStatus: "changes_requested",
+		Comments: []classifiedComment{
+			{Kind: "summary", ID: 1, Author: "alice", State: "CHANGES_REQUESTED", Body: "Needs a test.", Severity: "major"},
+			{Kind: "review", ID: 2, Author: "bob", Path: "client/retry.go", Body: "Cap the backoff?\nIt grows forever.", Severity: "minor"},
+			{Kind: "review", ID: 3, ParentID: 2, Author: "carol", Path: "client/retry.go", Body: "+1", Severity: "info"},
+			{Kind: "issue", ID: 4, Author: "dave", Body: "   "},
+		},
+	},
+	{Repo: "acme/app", Number: 7, Title: "Docs", URL: "https://github.com/acme/app/pull/7", ApprovalStatus: "approved"},
+}
+
+func TestRenderPRCommentsGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		classified bool
+		color      bool
+	}{
+		{"pr_comments", false, false},
+		{"pr_comments_classified", true, false},
+		{"pr_comments_color", true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, buf := goldenPrinter(tc.color)
+			renderPRComments(p, commentResults, tc.classified, false)
+			checkGolden(t, tc.name, buf.Bytes())
+		})
+	}
+}
+
+func TestRenderReviewQueueGolden(t *testing.T) {
+	item := func(number int, title, author string) reviewItem {
+		it := reviewItem{}
+		it.Number, it.Title, it.HTMLURL = number, title, fmt.Sprintf("https://github.com/acme/app/pull/%d", number)
+		if author != "" {
+			it.User = &struct {
+				Login string `json:"login"`
+			}{author}
+		}
+		return it
+	}
+	a := item(12, "Add retry\nto the client", "alice")
+	a.Additions, a.Deletions, a.ChangedFiles, a.CI, a.AgeHours = 120, 4, 3, "success", 30
+	a.RequestedFrom = []string{"@me", "acme/backend"}
+	b := item(7, "Docs", "")
+	b.CI, b.Draft, b.Reviewed, b.AgeHours, b.SLABreached = "failure", true, true, 0.5, true
+	c := item(3, "Bump deps", "renovate[bot]")
+	c.CI, c.AgeHours, c.SLABreached = "pending", 80, true
+	settings := config.ReviewSettings{SLA: 24 * time.Hour}
+
+	for _, tc := range []struct {
+		name  string
+		color bool
+	}{
+		{"reviews", false},
+		{"reviews_color", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, buf := goldenPrinter(tc.color)
+			renderReviewQueue(p, []reviewItem{a, b, c}, settings)
+			checkGolden(t, tc.name, buf.Bytes())
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +26,9 @@ type ghIssueItem struct {
 	User *struct {
 		Login string `json:"login"`
 	} `json:"user"`
+	// RequestedFrom is filled by noji: "@me" and/or the ORG/TEAM slugs whose
+	// review was requested.
+	RequestedFrom []string `json:"requested_from,omitempty"`
 }
 
 type ghSearchIssuesResponse struct {
@@ -41,175 +46,330 @@ func safeOneLine(s string) string {
 	return strings.TrimSpace(s)
 }
 
+// rankWindow is how many times --limit requests are fetched for ranking.
+const rankWindow = 3
+
 func newReviewsPRCmd() *cobra.Command {
 	var org string
 	var limit int
-	var outputJSON bool
 	var inferOrgs bool
 	var noBots bool
 	var botsOnly bool
-	var urlsOnly bool
+	var teams bool
+	var sortMode string
+	var sla time.Duration
+	var outFlags *outputFlags
 
 	cmd := &cobra.Command{
 		Use:     "reviews",
 		Short:   "List open PRs where reviews are requested from you",
 		Aliases: []string{"r"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// compile bot login regex once per invocation
-			botRe := regexp.MustCompile(`(?i)(\[bot\]|-bot$|bot$|^github-actions(\[bot\])?$|^dependabot(\[bot\])?$|^renovate(\[bot\]|-bot)?$|^snyk(-bot)?$|^mergify(\[bot\])?$|copilot)`)
-			// Build search query
-			queryParts := []string{"is:open", "is:pr", "archived:false"}
-			// Always limit to PRs requesting my review
-			queryParts = append(queryParts, "review-requested:@me")
-			if org != "" {
-				queryParts = append(queryParts, fmt.Sprintf("org:%s", org))
-			} else if inferOrgs {
-				// Try to infer organizations for the authenticated user
-				orgs, err := inferUserOrgs()
-				if err == nil && len(orgs) > 0 {
-					for _, o := range orgs {
-						queryParts = append(queryParts, fmt.Sprintf("org:%s", o))
-					}
-				}
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
 			}
-			query := strings.Join(queryParts, "+")
-
-			// Base command
-			// Use gh api exactly as: gh api -X GET 'search/issues?q=is:open+is:pr+review-requested:@me+archived:false' --paginate
-			apiURL := fmt.Sprintf("search/issues?q=%s", query)
-			// Optimize API usage: if a small limit is requested, avoid full pagination
-			ghArgs := []string{"api", "-X", "GET", apiURL}
-			perPage := 0
-			if limit > 0 && limit <= 100 {
-				perPage = limit
-			} else if limit == 0 || limit > 100 {
-				// Use high per_page to reduce round-trips when paginating
-				perPage = 100
-				ghArgs = append(ghArgs, "--paginate")
+			settings, err := config.GetReviewSettings()
+			if err != nil {
+				return err
 			}
-			if perPage > 0 {
-				ghArgs = append(ghArgs, fmt.Sprintf("-Fper_page=%d", perPage))
+			if cmd.Flags().Changed("sort") {
+				settings.Sort = sortMode
 			}
-
-			c := exec.Command("gh", ghArgs...)
-			out, err := c.Output()
+			if cmd.Flags().Changed("sla") {
+				settings.SLA = sla
+			}
+			if !slices.Contains(config.ReviewSorts, settings.Sort) {
+				return fmt.Errorf("invalid sort %q (valid: %s)", settings.Sort, strings.Join(config.ReviewSorts, ", "))
+			}
+			// Ranking picks the top --limit out of a window of
+			// rankWindow×limit requests rather than paging through the whole
+			// queue; the limit is applied afterwards.
+			fetchLimit := limit
+			if settings.Sort != "api" {
+				fetchLimit = limit * rankWindow
+			}
+			orgs, err := config.GetGitHubOrgs()
 			if err != nil {
-				var ee *exec.ExitError
-				if errors.As(err, &ee) {
-					return fmt.Errorf("gh api failed: %s", string(ee.Stderr))
-				}
 				return err
 			}
-
-			payload := strings.TrimSpace(string(out))
-			if payload == "" {
-				output.Warnf(output.ModeAuto, "No PRs found.\n")
-				return nil
+			found, err := fetchReviewRequests(reviewQuery{
+				Org:       org,
+				Orgs:      orgs,
+				Limit:     fetchLimit,
+				InferOrgs: inferOrgs,
+				NoBots:    noBots,
+				BotsOnly:  botsOnly,
+				Teams:     teams,
+			})
+			if err != nil {
+				return err
 			}
-
-			// gh --paginate returns concatenated JSON documents separated by newlines.
-			// However, when search results fit in one page, gh returns a single JSON object
-			// possibly followed by a trailing newline and then another JSON object with only
-			// the 'incomplete_results' and 'total_count' fields. We'll parse robustly by
-			// attempting to decode the entire payload first; if that fails, fall back to
-			// splitting by newlines and decoding each chunk that looks like a JSON object.
-			var merged ghSearchIssuesResponse
-			// Try whole-payload decode first
-			if err := json.Unmarshal([]byte(payload), &merged); err != nil {
-				// Fallback: line-delimited JSON documents
-				merged = ghSearchIssuesResponse{}
-				for _, chunk := range strings.Split(payload, "\n") {
-					chunk = strings.TrimSpace(chunk)
-					if chunk == "" {
-						continue
-					}
-					var r ghSearchIssuesResponse
-					if err := json.Unmarshal([]byte(chunk), &r); err != nil {
-						// ignore non-matching chunks (like {"incomplete_results":...})
-						continue
-					}
-					merged.Items = append(merged.Items, r.Items...)
-				}
+			items, err := enrichReviewItems(found, settings, time.Now())
+			if err != nil {
+				return err
 			}
-
-			// Filter author by bot vs human according to flags. The query already
-			// limits to PRs requesting my review.
-			items := merged.Items
-			filtered := make([]ghIssueItem, 0, len(items))
-			for _, it := range items {
-				author := ""
-				if it.User != nil {
-					author = it.User.Login
-				}
-				isBot := botRe.MatchString(author)
-				if botsOnly && !isBot {
-					continue
-				}
-				if !botsOnly && noBots && isBot {
-					continue
-				}
-				filtered = append(filtered, it)
+			if err := sortReviewItems(items, settings.Sort); err != nil {
+				return err
 			}
-			itemsAny := filtered
-
-			// Apply limit if specified
-			if limit > 0 && limit < len(itemsAny) {
-				itemsAny = itemsAny[:limit]
+			if limit > 0 && limit < len(items) {
+				items = items[:limit]
 			}
+			// Remember the order so `noji pr checkout @N` can refer to it;
+			// the listing itself must not fail because of the cache.
+			_ = writeListedPRs(items)
 
-			if outputJSON {
-				enc := json.NewEncoder(cmd.OutOrStdout())
-				enc.SetIndent("", "  ")
-				return enc.Encode(itemsAny)
+			if !outOpts.Human() {
+				return p.Render(outOpts, items)
 			}
 
-			if urlsOnly {
-				for _, it := range itemsAny {
-					if strings.TrimSpace(it.HTMLURL) != "" {
-						output.Printf(output.ModeNever, "%s\n", it.HTMLURL)
-					}
-				}
+			if len(items) == 0 {
+				p.Warnf("No PRs found.\n")
 				return nil
 			}
-
-			if len(itemsAny) == 0 {
-				output.Warnf(output.ModeAuto, "No PRs found.\n")
-				return nil
-			}
-
-			for _, it := range itemsAny {
-				author := ""
-				if it.User != nil && it.User.Login != "" {
-					author = it.User.Login
-				}
-				if author == "" {
-					author = "unknown"
-				}
-				// plain output
-				authorLabel := author
-				if output.AuthorColorEnabled() {
-					authorLabel = output.ColorizeAuthor(authorLabel)
-				}
-				output.Infof(output.ModeAuto, "PR:   #%d\n", it.Number)
-				output.Printf(output.ModeAuto, "Title: %s\n", safeOneLine(it.Title))
-				output.Printf(output.ModeAuto, "Author: %s\n", authorLabel)
-				output.Printf(output.ModeAuto, "Created: %s\n", it.CreatedAt)
-				output.Printf(output.ModeAuto, "URL:   %s\n\n", it.HTMLURL)
-			}
+			renderReviewQueue(p, items, settings)
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&org, "org", "", "Filter by GitHub organization")
+	cmd.Flags().StringVar(&org, "org", "", "Filter by GitHub organization (default from config: github.orgs)")
 	cmd.Flags().IntVar(&limit, "limit", 0, "Limit number of results (0=all)")
-	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output raw JSON")
 	cmd.Flags().BoolVar(&inferOrgs, "infer-orgs", true, "Infer your org memberships if --org not provided")
 	cmd.Flags().BoolVar(&noBots, "no-bots", true, "Exclude PRs from bot authors")
 	cmd.Flags().BoolVar(&botsOnly, "bots", false, "Show only PRs from bot authors (overrides --no-bots)")
-	cmd.Flags().BoolVar(&urlsOnly, "urls", false, "Print only PR URLs (one per line)")
+	cmd.Flags().BoolVar(&teams, "teams", false, "Include PRs requesting review from your GitHub teams")
+	cmd.Flags().StringVar(&sortMode, "sort", "", "Queue order: "+strings.Join(config.ReviewSorts, "|")+"; with --limit N the top N of the first 3N requests are ranked (default from config: reviews.sort)")
+	cmd.Flags().DurationVar(&sla, "sla", 0, "Highlight requests waiting longer than this (default from config: reviews.sla; 0 disables)")
+	outFlags = addOutputFlags(cmd)
+	outFlags.addLegacy(cmd)
 	return cmd
 }
 
+// reviewQuery selects which review requests fetchReviewRequests returns.
+type reviewQuery struct {
+	Org       string
+	Orgs      []string // configured orgs (github.orgs), used without Org
+	Limit     int
+	InferOrgs bool
+	NoBots    bool
+	BotsOnly  bool
+	Teams     bool // include requests to my teams
+}
+
+// fetchReviewRequests searches open PRs requesting my review.
+func fetchReviewRequests(q reviewQuery) ([]ghIssueItem, error) {
+	// compile bot login regex once per invocation
+	botRe := regexp.MustCompile(`(?i)(\[bot\]|-bot$|bot$|^github-actions(\[bot\])?$|^dependabot(\[bot\])?$|^renovate(\[bot\]|-bot)?$|^snyk(-bot)?$|^mergify(\[bot\])?$|copilot)`)
+	// Build search query
+	queryParts := []string{"is:open", "is:pr", "archived:false"}
+	// Always limit to PRs requesting my review; review-requested:@me would
+	// also match my teams' requests and label them "@me"
+	queryParts = append(queryParts, "user-review-requested:@me")
+	if q.Org != "" {
+		queryParts = append(queryParts, fmt.Sprintf("org:%s", q.Org))
+	} else if len(q.Orgs) > 0 {
+		for _, o := range q.Orgs {
+			queryParts = append(queryParts, fmt.Sprintf("org:%s", o))
+		}
+	} else if q.InferOrgs {
+		// Try to infer organizations for the authenticated user
+		orgs, err := inferUserOrgs()
+		if err == nil && len(orgs) > 0 {
+			for _, o := range orgs {
+				queryParts = append(queryParts, fmt.Sprintf("org:%s", o))
+			}
+		}
+	}
+	items, err := searchIssues(queryParts, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].RequestedFrom = []string{"@me"}
+	}
+	if q.Teams {
+		teamItems, err := searchTeamReviewRequests(q)
+		if err != nil {
+			return nil, err
+		}
+		items = mergeRequested(items, teamItems)
+	}
+
+	// Filter author by bot vs human according to flags. The queries already
+	// limit to PRs requesting my (or my teams') review.
+	filtered := make([]ghIssueItem, 0, len(items))
+	for _, it := range items {
+		author := ""
+		if it.User != nil {
+			author = it.User.Login
+		}
+		isBot := botRe.MatchString(author)
+		if q.BotsOnly && !isBot {
+			continue
+		}
+		if !q.BotsOnly && q.NoBots && isBot {
+			continue
+		}
+		filtered = append(filtered, it)
+	}
+
+	// Apply limit if specified
+	if q.Limit > 0 && q.Limit < len(filtered) {
+		filtered = filtered[:q.Limit]
+	}
+	return filtered, nil
+}
+
+// searchIssues runs an issue search for queryParts, paginating unless a
+// small limit allows a single page.
+func searchIssues(queryParts []string, limit int) ([]ghIssueItem, error) {
+	query := strings.Join(queryParts, "+")
+
+	// Base command
+	// Use gh api exactly as: gh api -X GET 'search/issues?q=is:open+is:pr+user-review-requested:@me+archived:false' --paginate
+	apiURL := fmt.Sprintf("search/issues?q=%s", query)
+	// Optimize API usage: if a small limit is requested, avoid full pagination
+	ghArgs := []string{"api", "-X", "GET", apiURL}
+	perPage := 0
+	if limit > 0 && limit <= 100 {
+		perPage = limit
+	} else if limit == 0 || limit > 100 {
+		// Use high per_page to reduce round-trips when paginating
+		perPage = 100
+		ghArgs = append(ghArgs, "--paginate")
+	}
+	if perPage > 0 {
+		ghArgs = append(ghArgs, fmt.Sprintf("-Fper_page=%d", perPage))
+	}
+
+	c := exec.Command("gh", ghArgs...)
+	out, err := c.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh api failed: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+
+	payload := strings.TrimSpace(string(out))
+
+	// gh --paginate returns concatenated JSON documents separated by newlines.
+	// However, when search results fit in one page, gh returns a single JSON object
+	// possibly followed by a trailing newline and then another JSON object with only
+	// the 'incomplete_results' and 'total_count' fields. We'll parse robustly by
+	// attempting to decode the entire payload first; if that fails, fall back to
+	// splitting by newlines and decoding each chunk that looks like a JSON object.
+	var merged ghSearchIssuesResponse
+	// Try whole-payload decode first
+	if err := json.Unmarshal([]byte(payload), &merged); err != nil {
+		// Fallback: line-delimited JSON documents
+		merged = ghSearchIssuesResponse{}
+		for _, chunk := range strings.Split(payload, "\n") {
+			chunk = strings.TrimSpace(chunk)
+			if chunk == "" {
+				continue
+			}
+			var r ghSearchIssuesResponse
+			if err := json.Unmarshal([]byte(chunk), &r); err != nil {
+				// ignore non-matching chunks (like {"incomplete_results":...})
+				continue
+			}
+			merged.Items = append(merged.Items, r.Items...)
+		}
+	}
+	return merged.Items, nil
+}
+
+// searchTeamReviewRequests searches review requests for each of my teams,
+// limited to q.Org or q.Orgs when set. Items are labelled with the team.
+func searchTeamReviewRequests(q reviewQuery) ([]ghIssueItem, error) {
+	teams, err := userTeams()
+	if err != nil {
+		return nil, err
+	}
+	var items []ghIssueItem
+	for _, t := range teams {
+		org, _, _ := strings.Cut(t, "/")
+		if q.Org != "" && !strings.EqualFold(org, q.Org) {
+			continue
+		}
+		if q.Org == "" && len(q.Orgs) > 0 && !slices.ContainsFunc(q.Orgs, func(o string) bool { return strings.EqualFold(o, org) }) {
+			continue
+		}
+		parts := []string{"is:open", "is:pr", "archived:false", "team-review-requested:" + t}
+		found, err := searchIssues(parts, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			found[i].RequestedFrom = []string{t}
+		}
+		items = mergeRequested(items, found)
+	}
+	return items, nil
+}
+
+// mergeRequested appends extra to items, merging the RequestedFrom labels of
+// PRs present in both.
+func mergeRequested(items, extra []ghIssueItem) []ghIssueItem {
+	index := map[string]int{}
+	for i, it := range items {
+		index[it.HTMLURL] = i
+	}
+	for _, it := range extra {
+		if i, ok := index[it.HTMLURL]; ok {
+			for _, r := range it.RequestedFrom {
+				if !slices.Contains(items[i].RequestedFrom, r) {
+					items[i].RequestedFrom = append(items[i].RequestedFrom, r)
+				}
+			}
+			continue
+		}
+		index[it.HTMLURL] = len(items)
+		items = append(items, it)
+	}
+	return items
+}
+
+// userTeams returns my team memberships as ORG/TEAM slugs.
+func userTeams() ([]string, error) {
+	c := exec.Command("gh", "api", "/user/teams", "--paginate")
+	out, err := c.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh api teams failed: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+	type ghTeam struct {
+		Slug         string `json:"slug"`
+		Organization struct {
+			Login string `json:"login"`
+		} `json:"organization"`
+	}
+	var teams []ghTeam
+	if err := json.Unmarshal(out, &teams); err != nil {
+		// Fallback: newline-separated arrays
+		teams = nil
+		for _, chunk := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			var part []ghTeam
+			if err := json.Unmarshal([]byte(chunk), &part); err == nil {
+				teams = append(teams, part...)
+			}
+		}
+	}
+	res := make([]string, 0, len(teams))
+	for _, t := range teams {
+		if t.Slug != "" && t.Organization.Login != "" {
+			res = append(res, t.Organization.Login+"/"+t.Slug)
+		}
+	}
+	return res, nil
+}
+
 // inferUserOrgs returns the list of org logins for the authenticated user using gh api
 func inferUserOrgs() ([]string, error) {
 	c := exec.Command("gh", "api", "/user/orgs", "--paginate")
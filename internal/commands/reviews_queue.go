@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+)
+
+// reviewItem is a review request enriched with what matters for picking
+// the next review.
+type reviewItem struct {
+	ghIssueItem
+	Additions    int     `json:"additions"`
+	Deletions    int     `json:"deletions"`
+	ChangedFiles int     `json:"changed_files"`
+	CI           string  `json:"ci"` // success|failure|pending|error|none
+	Draft        bool    `json:"draft"`
+	RequestedAt  string  `json:"requested_at"`
+	AgeHours     float64 `json:"age_hours"`
+	Reviewed     bool    `json:"reviewed_by_me"`
+	SLABreached  bool    `json:"sla_breached"`
+	Score        float64 `json:"score"`
+}
+
+// reviewBatchSize is the number of PRs fetched per GraphQL request.
+const reviewBatchSize = 25
+
+const reviewItemFragment = `fragment reviewItem on PullRequest {
+  additions
+  deletions
+  changedFiles
+  isDraft
+  createdAt
+  reviews(author: %s, first: 1) { totalCount }
+  commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+  timelineItems(itemTypes: REVIEW_REQUESTED_EVENT, last: 50) {
+    nodes {
+      ... on ReviewRequestedEvent {
+        createdAt
+        requestedReviewer { ... on User { login } ... on Team { slug } }
+      }
+    }
+  }
+}`
+
+type ghReviewItemDetails struct {
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+	ChangedFiles int    `json:"changedFiles"`
+	IsDraft      bool   `json:"isDraft"`
+	CreatedAt    string `json:"createdAt"`
+	Reviews      struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"reviews"`
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					State string `json:"state"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+	TimelineItems struct {
+		Nodes []struct {
+			CreatedAt         string `json:"createdAt"`
+			RequestedReviewer struct {
+				Login string `json:"login"`
+				Slug  string `json:"slug"`
+			} `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"timelineItems"`
+}
+
+// enrichReviewItems fetches size, CI, draft and review request details for
+// items and scores them with the configured weights.
+func enrichReviewItems(items []ghIssueItem, s config.ReviewSettings, now time.Time) ([]reviewItem, error) {
+	out := make([]reviewItem, len(items))
+	for i, it := range items {
+		out[i] = reviewItem{ghIssueItem: it, CI: "none", RequestedAt: it.CreatedAt}
+	}
+	if len(items) == 0 {
+		return out, nil
+	}
+	me, err := whoAmI()
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(out); start += reviewBatchSize {
+		end := min(len(out), start+reviewBatchSize)
+		if err := fetchReviewDetails(out[start:end], me); err != nil {
+			return nil, err
+		}
+	}
+	for i := range out {
+		scoreReviewItem(&out[i], s, now)
+	}
+	return out, nil
+}
+
+// fetchReviewDetails fills one batch of items with a single aliased query.
+func fetchReviewDetails(items []reviewItem, me string) error {
+	var q strings.Builder
+	q.WriteString("query {\n")
+	for i, it := range items {
+		repo, err := repoFromPRURL(it.HTMLURL)
+		if err != nil {
+			return err
+		}
+		owner, name, _ := strings.Cut(repo, "/")
+		fmt.Fprintf(&q, "  pr%d: repository(owner: %s, name: %s) { pullRequest(number: %d) { ...reviewItem } }\n",
+			i, strconv.Quote(owner), strconv.Quote(name), it.Number)
+	}
+	q.WriteString("}\n")
+	q.WriteString(fmt.Sprintf(reviewItemFragment, strconv.Quote(me)))
+
+	out, err := exec.Command("gh", "api", "graphql", "-f", "query="+q.String()).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return fmt.Errorf("gh api graphql review details failed: %s", string(ee.Stderr))
+		}
+		return err
+	}
+	var resp struct {
+		Data map[string]*struct {
+			PullRequest *ghReviewItemDetails `json:"pullRequest"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return fmt.Errorf("parse review details: %w", err)
+	}
+	for i := range items {
+		repo := resp.Data[fmt.Sprintf("pr%d", i)]
+		if repo == nil || repo.PullRequest == nil {
+			continue
+		}
+		applyReviewDetails(&items[i], repo.PullRequest, me)
+	}
+	return nil
+}
+
+func applyReviewDetails(it *reviewItem, d *ghReviewItemDetails, me string) {
+	it.Additions = d.Additions
+	it.Deletions = d.Deletions
+	it.ChangedFiles = d.ChangedFiles
+	it.Draft = d.IsDraft
+	it.Reviewed = d.Reviews.TotalCount > 0
+	if n := d.Commits.Nodes; len(n) > 0 && n[0].Commit.StatusCheckRollup != nil {
+		it.CI = strings.ToLower(n[0].Commit.StatusCheckRollup.State)
+	}
+	// Prefer the latest request addressed to me or one of the teams the item
+	// was found for; any request is the fallback.
+	teams := map[string]bool{}
+	for _, r := range it.RequestedFrom {
+		if _, slug, ok := strings.Cut(r, "/"); ok {
+			teams[strings.ToLower(slug)] = true
+		}
+	}
+	var mine, latest string
+	for _, ev := range d.TimelineItems.Nodes {
+		if ev.CreatedAt == "" {
+			continue
+		}
+		latest = ev.CreatedAt
+		rr := ev.RequestedReviewer
+		if strings.EqualFold(rr.Login, me) || teams[strings.ToLower(rr.Slug)] {
+			mine = ev.CreatedAt
+		}
+	}
+	switch {
+	case mine != "":
+		it.RequestedAt = mine
+	case latest != "":
+		it.RequestedAt = latest
+	case d.CreatedAt != "":
+		it.RequestedAt = d.CreatedAt
+	}
+}
+
+// scoreReviewItem computes age, SLA state and the weighted score.
+func scoreReviewItem(it *reviewItem, s config.ReviewSettings, now time.Time) {
+	if t, err := time.Parse(time.RFC3339, it.RequestedAt); err == nil {
+		it.AgeHours = math.Round(now.Sub(t).Hours()*10) / 10
+	}
+	age := time.Duration(it.AgeHours * float64(time.Hour))
+	it.SLABreached = s.SLA > 0 && !it.Draft && !it.Reviewed && age > s.SLA
+
+	w := s.Weights
+	score := w.Age*it.AgeHours/24 - w.Size*math.Log10(1+float64(it.Additions+it.Deletions))
+	switch it.CI {
+	case "success":
+		score += w.CI
+	case "failure", "error":
+		score -= w.CI
+	}
+	if it.SLABreached {
+		score += w.SLA
+	}
+	if it.Draft {
+		score -= w.Draft
+	}
+	if it.Reviewed {
+		score -= w.Reviewed
+	}
+	it.Score = math.Round(score*100) / 100
+}
+
+// sortReviewItems orders the queue: weighted (highest score first),
+// smallest (fewest changed lines), oldest (longest waiting) or api (as returned).
+func sortReviewItems(items []reviewItem, mode string) error {
+	switch mode {
+	case "weighted":
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+	case "smallest":
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i].Additions+items[i].Deletions, items[j].Additions+items[j].Deletions
+			if a != b {
+				return a < b
+			}
+			return items[i].AgeHours > items[j].AgeHours
+		})
+	case "oldest":
+		sort.SliceStable(items, func(i, j int) bool { return items[i].AgeHours > items[j].AgeHours })
+	case "api", "":
+	default:
+		return fmt.Errorf("invalid sort %q (valid: %s)", mode, strings.Join(config.ReviewSorts, ", "))
+	}
+	return nil
+}
+
+// renderReviewQueue prints the enriched review queue.
+func renderReviewQueue(p *output.Printer, items []reviewItem, s config.ReviewSettings) {
+	for i, it := range items {
+		author := "unknown"
+		if it.User != nil && it.User.Login != "" {
+			author = it.User.Login
+		}
+		p.Infof("PR:   #%d [@%d]\n", it.Number, i+1)
+		p.Printf("Title: %s\n", safeOneLine(it.Title))
+		p.Printf("Author: %s\n", p.ColorizeAuthor(author))
+		p.Printf("Size: +%d -%d in %d file(s)\n", it.Additions, it.Deletions, it.ChangedFiles)
+		status := []string{"CI " + ciLabel(p, it.CI)}
+		if it.Draft {
+			status = append(status, "draft")
+		}
+		if it.Reviewed {
+			status = append(status, "reviewed by you")
+		}
+		p.Printf("Status: %s\n", strings.Join(status, " · "))
+		requested := fmt.Sprintf("%s ago", humanAge(it.AgeHours))
+		if len(it.RequestedFrom) > 0 {
+			requested += " from " + strings.Join(it.RequestedFrom, ", ")
+		}
+		if it.SLABreached {
+			requested += " " + p.Bad(fmt.Sprintf("[SLA %s breached]", humanAge(s.SLA.Hours())))
+		}
+		p.Printf("Requested: %s\n", requested)
+		p.Printf("URL:   %s\n\n", it.HTMLURL)
+	}
+}
+
+func ciLabel(p *output.Printer, state string) string {
+	switch state {
+	case "success":
+		return p.Good(state)
+	case "failure", "error":
+		return p.Bad(state)
+	default:
+		return state
+	}
+}
+
+// humanAge formats hours as 45m, 5h or 3d.
+func humanAge(hours float64) string {
+	switch {
+	case hours < 1:
+		return fmt.Sprintf("%dm", int(hours*60))
+	case hours < 48:
+		return fmt.Sprintf("%dh", int(hours))
+	default:
+		return fmt.Sprintf("%dd", int(hours/24))
+	}
+}
@@ -7,12 +7,17 @@ import (
 
 	"github.com/dennisloska/noji/internal/commands/output"
 	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/opencode"
+	"github.com/dennisloska/noji/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
 func NewRoot() *cobra.Command {
 	var colorFlag string
 	var editorFlag string
+	var profileFlag string
+	var modelFlag string
+	var showRedactions bool
 	var versionFlag bool
 
 	rootCmd := &cobra.Command{
@@ -22,6 +27,9 @@ func NewRoot() *cobra.Command {
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if profileFlag != "" {
+				config.SetFlag("profile", profileFlag)
+			}
 			cfg, prompts, err := config.EnsureConfig()
 			if err != nil {
 				return err
@@ -31,11 +39,43 @@ func NewRoot() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			output.Init(mode)
+			p := output.NewPrinter(cmd.OutOrStdout(), cmd.ErrOrStderr(), mode)
+			output.SetDefault(p)
+			cmd.SetContext(output.WithPrinter(cmd.Context(), p))
+
+			// warn about unknown keys and invalid values in config files
+			if problems, err := config.Check(); err != nil {
+				p.Warnf("%s\n", secrets.Redact(err.Error()))
+			} else {
+				for _, prob := range problems {
+					p.Warnf("%s\n", secrets.Redact(prob.String()))
+				}
+			}
 
-			// If --editor is provided, persist override for this process via env
+			// --editor overrides every config source for this process
 			if strings.TrimSpace(editorFlag) != "" {
-				os.Setenv("NOJI_EDITOR_OVERRIDE", editorFlag)
+				config.SetFlag("editor", editorFlag)
+			}
+			// --model replaces the model of every task for this invocation
+			if strings.TrimSpace(modelFlag) != "" {
+				config.SetFlag("model", strings.TrimSpace(modelFlag))
+			}
+
+			if showRedactions {
+				config.SetFlag("redact.show", true)
+			}
+
+			// the active profile may point gh at another GitHub host
+			if os.Getenv("GH_HOST") == "" {
+				if host, err := config.GetGitHubHost(); err != nil {
+					return err
+				} else if host != "" {
+					os.Setenv("GH_HOST", host)
+				}
+			}
+
+			if needsTokens(cmd) {
+				exportTokens(cmd)
 			}
 
 			// Handle global version flag early and exit
@@ -66,8 +106,15 @@ func NewRoot() *cobra.Command {
 
 	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", "auto", "color output: auto|always|never")
 	rootCmd.PersistentFlags().StringVar(&editorFlag, "editor", "", "preferred editor binary or command (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "config profile to use (overrides NOJI_PROFILE and remote matching)")
+	rootCmd.PersistentFlags().StringVar(&modelFlag, "model", "", "opencode model to use for this invocation (overrides models.* and model)")
+	rootCmd.PersistentFlags().BoolVar(&showRedactions, "show-redactions", false, "report what was masked in prompts before they are sent to the model")
 	rootCmd.PersistentFlags().BoolVarP(&versionFlag, "version", "v", false, "print version and exit")
 	rootCmd.PersistentFlags().Lookup("color").NoOptDefVal = "auto"
+	rootCmd.RegisterFlagCompletionFunc("model", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		models, _ := opencode.ListModels()
+		return models, cobra.ShellCompDirectiveNoFileComp
+	})
 	rootCmd.RegisterFlagCompletionFunc("color", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp
 	})
@@ -75,6 +122,11 @@ func NewRoot() *cobra.Command {
 	return rootCmd
 }
 
+// printer returns the Printer configured for cmd by the root command.
+func printer(cmd *cobra.Command) *output.Printer {
+	return output.FromContext(cmd.Context())
+}
+
 // small shim to keep package import clean in root
 func outputParseMode(s string) (output.Mode, error) {
 	s = strings.ToLower(strings.TrimSpace(s))
@@ -0,0 +1,482 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/stack"
+	"github.com/spf13/cobra"
+)
+
+func newStackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stack",
+		Short: "Manage stacked branches and their chain of dependent PRs",
+		Long: "Track branches built on top of each other (main → feature-a → feature-b), open\n" +
+			"one PR per branch with the right base, keep a Stack section in every PR body\n" +
+			"and restack the chain once lower PRs are merged. Without a subcommand the\n" +
+			"current stack is shown.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStackShow(printer(cmd))
+		},
+	}
+	cmd.AddCommand(newStackBranchCmd())
+	cmd.AddCommand(newStackTrackCmd())
+	cmd.AddCommand(newStackUntrackCmd())
+	cmd.AddCommand(newStackShowCmd())
+	cmd.AddCommand(newStackSubmitCmd())
+	cmd.AddCommand(newStackSyncCmd())
+	return cmd
+}
+
+func newStackBranchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "branch <name>",
+		Short: "Create a branch on top of the current one and track it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			st, err := loadStack()
+			if err != nil {
+				return err
+			}
+			parent, err := getCurrentBranch()
+			if err != nil || parent == "" {
+				return errors.New("could not determine current branch")
+			}
+			base, err := gitOutput("rev-parse", "HEAD")
+			if err != nil {
+				return err
+			}
+			if err := runGit("", "checkout", "-b", args[0]); err != nil {
+				return err
+			}
+			if err := st.Track(args[0], parent, base); err != nil {
+				return err
+			}
+			if err := st.Save(); err != nil {
+				return err
+			}
+			p.Successf("Created %s on top of %s.\n", args[0], parent)
+			return nil
+		},
+	}
+}
+
+func newStackTrackCmd() *cobra.Command {
+	var parent string
+
+	cmd := &cobra.Command{
+		Use:   "track [branch]",
+		Short: "Track an existing branch (default: current) on top of --parent",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			st, err := loadStack()
+			if err != nil {
+				return err
+			}
+			name, err := branchArg(args)
+			if err != nil {
+				return err
+			}
+			if parent == "" {
+				parent = defaultBranch()
+			}
+			base, err := gitOutput("merge-base", parent, name)
+			if err != nil {
+				return err
+			}
+			if err := st.Track(name, parent, base); err != nil {
+				return err
+			}
+			if err := st.Save(); err != nil {
+				return err
+			}
+			p.Successf("Tracking %s on top of %s.\n", name, parent)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&parent, "parent", "", "Branch this one is built on (default: the repository's default branch)")
+	return cmd
+}
+
+func newStackUntrackCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "untrack [branch]",
+		Short: "Stop tracking a branch; branches on top of it move to its parent",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			st, err := loadStack()
+			if err != nil {
+				return err
+			}
+			name, err := branchArg(args)
+			if err != nil {
+				return err
+			}
+			if !st.Tracked(name) {
+				return fmt.Errorf("%s is not tracked", name)
+			}
+			st.Remove(name)
+			if err := st.Save(); err != nil {
+				return err
+			}
+			p.Successf("Stopped tracking %s.\n", name)
+			return nil
+		},
+	}
+}
+
+func newStackShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the stack of the current branch",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStackShow(printer(cmd))
+		},
+	}
+}
+
+func runStackShow(p *output.Printer) error {
+	st, err := loadStack()
+	if err != nil {
+		return err
+	}
+	current, err := getCurrentBranch()
+	if err != nil {
+		return err
+	}
+	chain := st.Chain(current)
+	if len(chain) == 0 {
+		p.Infof("%s is not part of a stack. Use `noji stack branch <name>` or `noji stack track`.\n", current)
+		return nil
+	}
+	p.Printf("%s\n", st.Trunk(chain[0]))
+	for _, n := range chain {
+		line := strings.Repeat("  ", st.Depth(n)+1) + "└ " + n
+		if pr := st.Branches[n].PR; pr > 0 {
+			line += fmt.Sprintf(" (#%d)", pr)
+		}
+		if n == current {
+			p.Successf("%s ←\n", line)
+		} else {
+			p.Printf("%s\n", line)
+		}
+	}
+	return nil
+}
+
+func newStackSubmitCmd() *cobra.Command {
+	var draft bool
+
+	cmd := &cobra.Command{
+		Use:   "submit",
+		Short: "Push every branch of the stack and create or retarget their PRs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			st, chain, err := currentStack()
+			if err != nil {
+				return err
+			}
+			for _, n := range chain {
+				b := st.Branches[n]
+				if err := runGit("", "push", "--force-with-lease", "-u", "origin", n); err != nil {
+					return err
+				}
+				pr, err := branchPR(n)
+				if err != nil {
+					return err
+				}
+				switch {
+				case pr == nil || pr.State != "OPEN":
+					number, err := createStackPR(n, b.Parent, draft)
+					if err != nil {
+						return err
+					}
+					b.PR = number
+					p.Successf("Opened #%d for %s (base %s).\n", number, n, b.Parent)
+				case pr.BaseRefName != b.Parent:
+					if err := setPRBase(pr.Number, b.Parent); err != nil {
+						return err
+					}
+					b.PR = pr.Number
+					p.Successf("Retargeted #%d to %s.\n", pr.Number, b.Parent)
+				default:
+					b.PR = pr.Number
+					p.Infof("#%d for %s is up to date.\n", pr.Number, n)
+				}
+				if err := st.Save(); err != nil {
+					return err
+				}
+			}
+			return updateStackNav(p, st, chain)
+		},
+	}
+	cmd.Flags().BoolVar(&draft, "draft", false, "Open new PRs as drafts")
+	return cmd
+}
+
+func newStackSyncCmd() *cobra.Command {
+	var noPush bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Drop merged branches, rebase the rest of the stack and force-push it",
+		Long: "Fetches origin, removes branches whose PR was merged from the stack, rebases\n" +
+			"the remaining branches onto their (new) parents, force-pushes them, retargets\n" +
+			"their PRs and refreshes the Stack section. On a rebase conflict, resolve it,\n" +
+			"run `git rebase --continue` and run `noji stack sync` again.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			if err := ensureGh(); err != nil {
+				return err
+			}
+			st, chain, err := currentStack()
+			if err != nil {
+				return err
+			}
+			start, _ := getCurrentBranch()
+			if err := runGit("", "fetch", "origin", "--prune"); err != nil {
+				return err
+			}
+
+			// Drop merged branches; their children move down a level.
+			var remaining []string
+			for _, n := range chain {
+				pr, err := branchPR(n)
+				if err != nil {
+					return err
+				}
+				if pr != nil && pr.State == "MERGED" {
+					st.Remove(n)
+					p.Successf("%s (#%d) was merged; removed from the stack.\n", n, pr.Number)
+					if start == n {
+						start = ""
+					}
+					continue
+				}
+				remaining = append(remaining, n)
+			}
+			if err := st.Save(); err != nil {
+				return err
+			}
+
+			// Restack bottom-up. Base makes this safe to re-run after a conflict.
+			for _, n := range remaining {
+				b := st.Branches[n]
+				onto := b.Parent
+				if !st.Tracked(onto) {
+					onto = "origin/" + onto
+				}
+				target, err := gitOutput("rev-parse", onto)
+				if err != nil {
+					return err
+				}
+				if target == b.Base {
+					continue
+				}
+				p.Infof("Rebasing %s onto %s...\n", n, onto)
+				upstream := b.Base
+				if upstream == "" {
+					upstream = onto
+				}
+				rebase := exec.Command("git", "rebase", "--onto", target, upstream, n)
+				rebase.Stdout, rebase.Stderr = p.Out, p.Err
+				if err := rebase.Run(); err != nil {
+					return fmt.Errorf("rebase of %s failed; resolve the conflicts, run `git rebase --continue`, then `noji stack sync` again", n)
+				}
+				b.Base = target
+				if err := st.Save(); err != nil {
+					return err
+				}
+				if !noPush {
+					if err := runGit("", "push", "--force-with-lease", "origin", n); err != nil {
+						return err
+					}
+				}
+			}
+			if start == "" && len(remaining) > 0 {
+				start = remaining[0]
+			}
+			if start != "" {
+				if err := runGit("", "checkout", start); err != nil {
+					return err
+				}
+			}
+			if noPush {
+				return nil
+			}
+			return updateStackNav(p, st, remaining)
+		},
+	}
+	cmd.Flags().BoolVar(&noPush, "no-push", false, "Only rebase locally; do not push or touch PRs")
+	return cmd
+}
+
+// stackPR is the PR of a stacked branch.
+type stackPR struct {
+	Number      int    `json:"number"`
+	State       string `json:"state"`
+	BaseRefName string `json:"baseRefName"`
+	Body        string `json:"body"`
+}
+
+// branchPR returns the most recent PR with head branch name, or nil.
+func branchPR(name string) (*stackPR, error) {
+	out, err := exec.Command("gh", "pr", "list", "--head", name, "--state", "all", "--limit", "1",
+		"--json", "number,state,baseRefName,body").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh pr list failed: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+	var prs []stackPR
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return nil, fmt.Errorf("parse gh pr list json: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+func createStackPR(head, base string, draft bool) (int, error) {
+	args := []string{"pr", "create", "--head", head, "--base", base, "--fill"}
+	if draft {
+		args = append(args, "--draft")
+	}
+	c := exec.Command("gh", args...)
+	c.Stderr = os.Stderr
+	out, err := c.Output()
+	if err != nil {
+		return 0, fmt.Errorf("gh pr create for %s failed: %w", head, err)
+	}
+	number, err := strconv.Atoi(filepath.Base(strings.TrimSpace(string(out))))
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse PR number from: %s", strings.TrimSpace(string(out)))
+	}
+	return number, nil
+}
+
+func setPRBase(number int, base string) error {
+	c := exec.Command("gh", "pr", "edit", strconv.Itoa(number), "--base", base)
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("gh pr edit failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// updateStackNav records the PR of every branch in chain, retargets open PRs
+// whose base no longer matches the branch's parent and rewrites their Stack
+// section.
+func updateStackNav(p *output.Printer, st *stack.Stack, chain []string) error {
+	prs := map[string]*stackPR{}
+	for _, n := range chain {
+		pr, err := branchPR(n)
+		if err != nil {
+			return err
+		}
+		if pr != nil && pr.State == "OPEN" {
+			prs[n] = pr
+			st.Branches[n].PR = pr.Number
+		}
+	}
+	if err := st.Save(); err != nil {
+		return err
+	}
+	for _, n := range chain {
+		pr := prs[n]
+		if pr == nil {
+			continue
+		}
+		if parent := st.Branches[n].Parent; pr.BaseRefName != parent {
+			if err := setPRBase(pr.Number, parent); err != nil {
+				return err
+			}
+			p.Successf("Retargeted #%d to %s.\n", pr.Number, parent)
+		}
+		body := stack.ReplaceNav(pr.Body, st.NavSection(chain, n))
+		if body == pr.Body {
+			continue
+		}
+		c := exec.Command("gh", "pr", "edit", strconv.Itoa(pr.Number), "--body-file", "-")
+		c.Stdin = strings.NewReader(body)
+		if out, err := c.CombinedOutput(); err != nil {
+			return fmt.Errorf("gh pr edit failed: %s", strings.TrimSpace(string(out)))
+		}
+	}
+	p.Successf("Stack section updated in %d PR(s).\n", len(prs))
+	return nil
+}
+
+func loadStack() (*stack.Stack, error) {
+	dir, err := gitOutput("rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return nil, fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return stack.Load(dir)
+}
+
+// currentStack loads the metadata and the stack of the current branch.
+func currentStack() (*stack.Stack, []string, error) {
+	st, err := loadStack()
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := getCurrentBranch()
+	if err != nil {
+		return nil, nil, err
+	}
+	chain := st.Chain(current)
+	if len(chain) == 0 {
+		return nil, nil, fmt.Errorf("%s is not part of a stack", current)
+	}
+	return st, chain, nil
+}
+
+func branchArg(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	name, err := getCurrentBranch()
+	if err != nil || name == "" {
+		return "", errors.New("could not determine current branch")
+	}
+	return name, nil
+}
+
+// defaultBranch returns origin's default branch, falling back to main.
+func defaultBranch() string {
+	ref, err := gitOutput("symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		return "main"
+	}
+	return strings.TrimPrefix(ref, "origin/")
+}
+
+// gitOutput runs git and returns its trimmed stdout.
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
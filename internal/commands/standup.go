@@ -0,0 +1,533 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/jira"
+	"github.com/spf13/cobra"
+)
+
+// standupFacts is what `noji standup` gathers before the model writes it up.
+type standupFacts struct {
+	Since    time.Time        `json:"since"`
+	Commits  []standupCommit  `json:"commits"`
+	PRs      []standupPR      `json:"prs"`
+	Reviews  []standupReview  `json:"reviews"`
+	Comments []standupComment `json:"comments"`
+	// Tickets are the tickets mentioned by commits, PRs and branches, with
+	// their status transitions when Jira can be reached (only the key
+	// otherwise).
+	Tickets []jira.Issue `json:"tickets"`
+}
+
+type standupCommit struct {
+	Repo    string    `json:"repo"`
+	Hash    string    `json:"hash"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Refs    string    `json:"refs,omitempty"`
+}
+
+type standupPR struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	// Action is opened|merged|closed|updated, the latest that happened
+	// since the stand-up.
+	Action string `json:"action"`
+	Draft  bool   `json:"draft"`
+}
+
+type standupReview struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
+type standupComment struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Body   string `json:"body"`
+	URL    string `json:"url"`
+}
+
+// standupSearchItem is a PR returned by the issue search.
+type standupSearchItem struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	HTMLURL     string `json:"html_url"`
+	State       string `json:"state"`
+	Draft       bool   `json:"draft"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	ClosedAt    string `json:"closed_at"`
+	PullRequest struct {
+		MergedAt string `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+func newStandupCmd() *cobra.Command {
+	var since string
+	var raw bool
+	var copyOut bool
+	var outFlags *outputFlags
+
+	cmd := &cobra.Command{
+		Use:   "standup",
+		Short: "Write your stand-up from commits, PRs, reviews, comments and tickets",
+		Long: "Collects your commits in standup.repos (default: the current repository), the PRs\n" +
+			"you opened, updated or merged, the reviews you submitted and the comments you\n" +
+			"received since --since, and lets the model write a Yesterday / Today / Blockers\n" +
+			"summary. --raw shows the gathered facts without the model.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
+			outOpts, err := outFlags.options()
+			if err != nil {
+				return err
+			}
+			from, err := parseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+			tracker, trackerErr := standupJira(cmd)
+			facts, err := gatherStandupFacts(p, from, tracker)
+			if err != nil {
+				return err
+			}
+			if trackerErr != nil && len(facts.Tickets) > 0 {
+				p.Warnf("Ticket transitions left out: %v\n", trackerErr)
+			}
+			if !outOpts.Human() {
+				return p.Render(outOpts, facts)
+			}
+			text := standupMarkdown(facts)
+			if !raw {
+				template, err := readPrompt("standup.txt")
+				if err != nil {
+					return err
+				}
+				runner, err := newModelRunner(config.TaskStandup, p.Warnf)
+				if err != nil {
+					return err
+				}
+				p.Infof("Writing stand-up with model %s...\n", runner.Model())
+				out, err := runner.capture(template + "\n\n" + text)
+				if err != nil {
+					return err
+				}
+				text = strings.TrimSpace(stripCodeFence(strings.TrimSpace(out))) + "\n"
+			}
+			p.Printf("%s\n", strings.TrimRight(p.RenderMarkdown(text), "\n"))
+			if copyOut {
+				if err := copyToClipboard(text); err != nil {
+					return err
+				}
+				p.Successf("Copied to the clipboard.\n")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&since, "since", "yesterday", "Start of the period: yesterday (the last working day), YYYY-MM-DD, or a duration such as 36h or 3d")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Show the gathered facts without the model")
+	cmd.Flags().BoolVar(&copyOut, "copy", false, "Copy the result to the clipboard")
+	outFlags = addOutputFlags(cmd)
+	return cmd
+}
+
+// parseSince returns the start of the stand-up period. "yesterday" is the
+// start of the last working day, so on Monday it is Friday.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s {
+	case "", "yesterday":
+		day := midnight.AddDate(0, 0, -1)
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, -1)
+		}
+		return day, nil
+	case "today":
+		return midnight, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return midnight.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (want yesterday, today, YYYY-MM-DD, 3d or 36h)", s)
+}
+
+// standupJira returns a client for the Jira site of tracker.url with the
+// token of `noji auth login jira`, or an error saying what is missing.
+func standupJira(cmd *cobra.Command) (*jira.Client, error) {
+	browse, err := config.GetTrackerURL()
+	if err != nil {
+		return nil, err
+	}
+	if browse == "" {
+		return nil, errors.New("set tracker.url to your Jira site")
+	}
+	// the token goes to this site, so a cloned repository must not pick it;
+	// the repo config is limited to other sections, this keeps it that way
+	if s, err := config.Get("tracker.url"); err != nil {
+		return nil, err
+	} else if s.Source == config.SourceRepo {
+		return nil, fmt.Errorf("tracker.url from %s is not trusted with your Jira token; set it in the user config", s.File)
+	}
+	store, err := secretStore(cmd)
+	if err != nil {
+		return nil, err
+	}
+	token, _, err := store.Get("jira")
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.New("no Jira token; run `noji auth login jira`")
+	}
+	user, err := config.GetTrackerUser()
+	if err != nil {
+		return nil, err
+	}
+	c := &jira.Client{BaseURL: jira.BaseFromBrowseURL(browse), User: user, Token: token}
+	// Jira Cloud tokens need the account email, usually the git one
+	if u, err := url.Parse(c.BaseURL); c.User == "" && err == nil && strings.HasSuffix(u.Hostname(), ".atlassian.net") {
+		if c.User, err = gitOutput("config", "user.email"); err != nil || c.User == "" {
+			return nil, errors.New("set tracker.user to the email of your Jira account")
+		}
+	}
+	return c, nil
+}
+
+// gatherStandupFacts collects everything since from. Sources that fail are
+// reported and left out, so one unreachable repository does not cost the
+// whole stand-up. tracker may be nil.
+func gatherStandupFacts(p *output.Printer, from time.Time, tracker *jira.Client) (*standupFacts, error) {
+	facts := &standupFacts{Since: from}
+	repos, err := config.GetStandupRepos()
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		if root, err := gitRoot(); err == nil {
+			repos = []string{root}
+		}
+	}
+	for _, dir := range repos {
+		commits, err := myCommits(dir, from)
+		if err != nil {
+			p.Warnf("Commits of %s left out: %v\n", dir, err)
+			continue
+		}
+		facts.Commits = append(facts.Commits, commits...)
+	}
+	sort.SliceStable(facts.Commits, func(i, j int) bool { return facts.Commits[i].Date.Before(facts.Commits[j].Date) })
+
+	if err := ensureGh(); err != nil {
+		p.Warnf("PRs, reviews and comments left out: %v\n", err)
+	} else if me, err := whoAmI(); err != nil {
+		p.Warnf("PRs, reviews and comments left out: %v\n", err)
+	} else {
+		if facts.PRs, err = myStandupPRs(me, from); err != nil {
+			p.Warnf("PRs left out: %v\n", err)
+		}
+		if facts.Reviews, err = myStandupReviews(me, from, p.Warnf); err != nil {
+			p.Warnf("Reviews left out: %v\n", err)
+		}
+		if facts.Comments, err = receivedComments(me, from); err != nil {
+			p.Warnf("Comments left out: %v\n", err)
+		}
+	}
+	for _, key := range mentionedTickets(facts) {
+		ticket := jira.Issue{Key: key}
+		if tracker != nil {
+			issue, err := tracker.Issue(key, from)
+			switch {
+			case err == nil:
+				ticket = *issue
+			case !errors.Is(err, jira.ErrNotFound):
+				p.Warnf("Transitions of %s left out: %v\n", key, err)
+			}
+		}
+		facts.Tickets = append(facts.Tickets, ticket)
+	}
+	return facts, nil
+}
+
+// myCommits returns the commits on any branch of the repository at dir that
+// were authored by its configured user since from.
+func myCommits(dir string, from time.Time) ([]standupCommit, error) {
+	email, err := gitOutput("-C", dir, "config", "user.email")
+	if err != nil || email == "" {
+		return nil, errors.New("git user.email is not set")
+	}
+	out, err := gitOutput("-C", dir, "log", "--all", "--no-merges", "--author="+email,
+		"--since="+from.Format(time.RFC3339), "--format=%H%x09%aI%x09%D%x09%s")
+	if err != nil {
+		return nil, err
+	}
+	var commits []standupCommit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[1])
+		commits = append(commits, standupCommit{Repo: filepath.Base(dir), Hash: fields[0][:min(len(fields[0]), 12)],
+			Date: date, Refs: fields[2], Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// searchStandupPRs runs a PR search and keeps the PRs updated since from.
+func searchStandupPRs(query []string, from time.Time) ([]standupSearchItem, error) {
+	query = append(query, "is:pr", "updated:>="+from.UTC().Format("2006-01-02"))
+	out, err := exec.Command("gh", "api", "-X", "GET", "search/issues", "-f", "q="+strings.Join(query, " "),
+		"-F", "per_page=100", "--paginate", "--jq", ".items[]").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return nil, fmt.Errorf("gh api search issues failed: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, err
+	}
+	var items []standupSearchItem
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var it standupSearchItem
+		if err := json.Unmarshal([]byte(line), &it); err != nil {
+			return nil, fmt.Errorf("parse search result: %w", err)
+		}
+		if after(it.UpdatedAt, from) {
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
+
+// after reports whether the RFC 3339 timestamp ts is at or after t.
+func after(ts string, t time.Time) bool {
+	parsed, err := time.Parse(time.RFC3339, ts)
+	return err == nil && !parsed.Before(t)
+}
+
+func myStandupPRs(me string, from time.Time) ([]standupPR, error) {
+	items, err := searchStandupPRs([]string{"author:" + me}, from)
+	if err != nil {
+		return nil, err
+	}
+	prs := make([]standupPR, 0, len(items))
+	for _, it := range items {
+		repo, _ := repoFromPRURL(it.HTMLURL)
+		pr := standupPR{Repo: repo, Number: it.Number, Title: it.Title, URL: it.HTMLURL, Draft: it.Draft, Action: "updated"}
+		switch {
+		case after(it.PullRequest.MergedAt, from):
+			pr.Action = "merged"
+		case after(it.ClosedAt, from):
+			pr.Action = "closed"
+		case after(it.CreatedAt, from):
+			pr.Action = "opened"
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
+
+// myStandupReviews returns the reviews I submitted on others' PRs since from,
+// the latest per PR. PRs whose reviews cannot be read are reported to warnf
+// and left out.
+func myStandupReviews(me string, from time.Time, warnf func(format string, a ...any)) ([]standupReview, error) {
+	items, err := searchStandupPRs([]string{"reviewed-by:" + me, "-author:" + me}, from)
+	if err != nil {
+		return nil, err
+	}
+	var reviews []standupReview
+	for _, it := range items {
+		repo, err := repoFromPRURL(it.HTMLURL)
+		if err != nil {
+			continue
+		}
+		all, err := fetchReviews(repo, it.Number)
+		if err != nil {
+			warnf("Review of %s#%d left out: %v\n", repo, it.Number, err)
+			continue
+		}
+		state := ""
+		for _, rv := range all {
+			if rv.User.Login == me && rv.State != "PENDING" && after(rv.SubmittedAt, from) {
+				state = rv.State
+			}
+		}
+		if state != "" {
+			reviews = append(reviews, standupReview{Repo: repo, Number: it.Number, Title: it.Title, URL: it.HTMLURL, State: state})
+		}
+	}
+	return reviews, nil
+}
+
+// receivedComments returns the human comments others left on my PRs since
+// from (the `pr comments` data).
+func receivedComments(me string, from time.Time) ([]standupComment, error) {
+	results, err := collectPRComments(commentsQuery{
+		State:         "all",
+		ExcludeBots:   true,
+		IncludeDrafts: true,
+		Since:         from.UTC().Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var comments []standupComment
+	for _, pr := range results {
+		for _, c := range pr.Comments {
+			if c.Author == me || !after(c.CreatedAt, from) {
+				continue
+			}
+			if strings.TrimSpace(c.Body) == "" && c.State == "" {
+				continue
+			}
+			body := c.Body
+			if c.State != "" {
+				body = strings.TrimSpace(strings.ToLower(strings.ReplaceAll(c.State, "_", " ")) + ". " + body)
+			}
+			comments = append(comments, standupComment{Repo: pr.Repo, Number: pr.Number, Title: pr.Title,
+				Author: c.Author, Body: body, URL: c.URL})
+		}
+	}
+	return comments, nil
+}
+
+// mentionedTickets returns the ticket keys in commit subjects, refs and PR
+// titles, in the order they first appear.
+func mentionedTickets(f *standupFacts) []string {
+	settings, err := config.GetTitleSettings()
+	if err != nil {
+		return nil
+	}
+	var texts []string
+	for _, c := range f.Commits {
+		texts = append(texts, c.Subject, c.Refs)
+	}
+	for _, pr := range f.PRs {
+		texts = append(texts, pr.Title)
+	}
+	var keys []string
+	for _, t := range texts {
+		if key := settings.Rules.TicketFrom(t); key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// standupMarkdown formats the facts for the prompt and for --raw.
+func standupMarkdown(f *standupFacts) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Activity since %s\n", f.Since.Format("Mon 2006-01-02 15:04"))
+	b.WriteString("\n## Commits\n")
+	if len(f.Commits) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, c := range f.Commits {
+		fmt.Fprintf(&b, "- %s %s: %s\n", c.Repo, c.Date.Local().Format("Mon 15:04"), c.Subject)
+	}
+	b.WriteString("\n## Pull requests\n")
+	if len(f.PRs) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, pr := range f.PRs {
+		draft := ""
+		if pr.Draft {
+			draft = " (draft)"
+		}
+		fmt.Fprintf(&b, "- %s %s#%d%s: %s\n", pr.Action, pr.Repo, pr.Number, draft, pr.Title)
+	}
+	b.WriteString("\n## Reviews submitted\n")
+	if len(f.Reviews) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, r := range f.Reviews {
+		fmt.Fprintf(&b, "- %s %s#%d: %s\n", strings.ToLower(strings.ReplaceAll(r.State, "_", " ")), r.Repo, r.Number, r.Title)
+	}
+	b.WriteString("\n## Comments received\n")
+	if len(f.Comments) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, c := range f.Comments {
+		fmt.Fprintf(&b, "- @%s on %s#%d (%s): %s\n", c.Author, c.Repo, c.Number, c.Title, oneLiner(c.Body))
+	}
+	b.WriteString("\n## Tickets mentioned\n")
+	if len(f.Tickets) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, t := range f.Tickets {
+		b.WriteString("- " + t.Key)
+		if t.Summary != "" {
+			fmt.Fprintf(&b, " %s (%s)", t.Summary, t.Status)
+		}
+		var moves []string
+		for _, tr := range t.Transitions {
+			moves = append(moves, fmt.Sprintf("%s → %s %s", tr.From, tr.To, tr.At.Local().Format("Mon 15:04")))
+		}
+		if len(moves) > 0 {
+			b.WriteString(": " + strings.Join(moves, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// copyToClipboard puts text on the system clipboard.
+func copyToClipboard(text string) error {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		candidates = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %v: %s", c[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c[0]
+	}
+	return fmt.Errorf("no clipboard tool found on %s (tried %s)", runtime.GOOS, strings.Join(names, ", "))
+}
@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dennisloska/noji/internal/commands/output"
+	"github.com/dennisloska/noji/internal/config"
+	"github.com/dennisloska/noji/internal/diffpack"
+	"github.com/dennisloska/noji/internal/summarize"
+)
+
+// newSummarizer returns the summarizer of input over budget, or nil when
+// diff.summarize is off. Prompts go through the summarize task's runner, so
+// they are redacted like every other prompt.
+func newSummarizer(p *output.Printer, budget int) (*summarize.Summarizer, *modelRunner, error) {
+	settings, err := config.GetSummarizeSettings()
+	if err != nil || !settings.Enabled || budget <= 0 {
+		return nil, nil, err
+	}
+	runner, err := newModelRunner(config.TaskSummarize, p.Warnf)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &summarize.Summarizer{Budget: budget, Parallel: settings.Parallel, Run: runner.quiet, Model: runner.Model}
+	if settings.Cache {
+		if s.Cache, err = summarize.DefaultCache(); err != nil {
+			p.Warnf("Summaries are not cached: %v\n", err)
+		} else if err := s.Cache.Prune(time.Now()); err != nil {
+			p.Warnf("Could not prune the summary cache: %v\n", err)
+		}
+	}
+	return s, runner, nil
+}
+
+// summarizeGroups maps and reduces groups and reports progress on p.
+func summarizeGroups(p *output.Printer, s *summarize.Summarizer, runner *modelRunner, what string, groups []summarize.Group) (string, error) {
+	p.Infof("Summarising %s in %d groups with model %s...\n", what, len(groups), runner.Model())
+	partials, err := s.Map(what, groups)
+	if err != nil {
+		return "", err
+	}
+	cached := 0
+	for _, part := range partials {
+		if part.Cached {
+			cached++
+		}
+	}
+	if cached > 0 {
+		p.Infof("%d of %d summaries reused from the cache.\n", cached, len(partials))
+	}
+	return s.Reduce(what, partials)
+}
+
+// summarizedDiffPrompt summarises a diff that is over the budget directory
+// by directory. It returns "" and an error when summarising is off or fails,
+// so the caller falls back to the packed diff.
+func summarizedDiffPrompt(p *output.Printer, files []diffpack.File, opts diffpack.Options, base string) (string, error) {
+	s, runner, err := newSummarizer(p, opts.Budget)
+	if err != nil || s == nil {
+		return "", err
+	}
+	kept := files[:0:0]
+	for _, f := range files {
+		if !runner.excluded(f.Path) {
+			kept = append(kept, f)
+		}
+	}
+	groups, skipped := summarize.DiffGroups(kept, opts)
+	if len(groups) == 0 {
+		return "", nil
+	}
+	summary, err := summarizeGroups(p, s, runner, "part of a git diff", groups)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\nThe diff of the current branch against %s is too large for one prompt (%d files). Summaries of the changes by directory:\n\n%s",
+		base, len(files), summary)
+	if len(skipped) > 0 {
+		b.WriteString("\nGenerated files and lockfiles that also changed:\n")
+		for _, e := range skipped {
+			b.WriteString(e.Stat() + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// reviewThreadsPrompt returns the review threads of the current branch's PR
+// as a prompt section, one group per file. Threads over diff.budget are
+// summarised file by file. It returns "" when there is no PR or no thread.
+func reviewThreadsPrompt(p *output.Printer) string {
+	pr, err := currentPR()
+	if err != nil {
+		return ""
+	}
+	repo, err := currentRepo()
+	if err != nil {
+		return ""
+	}
+	comments, err := fetchReviewComments(repo, pr.Number)
+	if err != nil {
+		p.Warnf("Review threads left out of the prompt: %v\n", err)
+		return ""
+	}
+	groups := threadGroups(comments)
+	if len(groups) == 0 {
+		return ""
+	}
+	text := summarize.Join(partialsOf(groups))
+	opts, err := config.GetDiffOptions()
+	if err != nil {
+		p.Warnf("%v\n", err)
+		return ""
+	}
+	if opts.Budget > 0 && diffpack.Tokens(text) > opts.Budget {
+		s, runner, err := newSummarizer(p, opts.Budget)
+		switch {
+		case err != nil:
+			p.Warnf("Review threads left out of the prompt: %v\n", err)
+			return ""
+		case s == nil:
+			p.Warnf("Review threads of PR #%d are over the diff budget and diff.summarize is off; leaving them out.\n", pr.Number)
+			return ""
+		}
+		if text, err = summarizeGroups(p, s, runner, "code review discussion of a pull request", groups); err != nil {
+			p.Warnf("Review threads left out of the prompt: %v\n", err)
+			return ""
+		}
+	}
+	return fmt.Sprintf("\n\nReview discussion on PR #%d, by file:\n\n%s", pr.Number, text)
+}
+
+// threadGroups formats review comments as threads, one group per file in
+// the order the files were first commented on.
+func threadGroups(comments []ghReviewComment) []summarize.Group {
+	var roots []int64
+	byRoot := map[int64][]ghReviewComment{}
+	for _, c := range comments {
+		root := c.ID
+		if c.InReplyToID != nil {
+			root = *c.InReplyToID
+		}
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], c)
+	}
+	var paths []string
+	byPath := map[string]*strings.Builder{}
+	for _, id := range roots {
+		thread := byRoot[id]
+		b, ok := byPath[thread[0].Path]
+		if !ok {
+			b = &strings.Builder{}
+			byPath[thread[0].Path] = b
+			paths = append(paths, thread[0].Path)
+		}
+		fmt.Fprintf(b, "%s:\n", commentLocation(thread[0]))
+		for _, c := range thread {
+			fmt.Fprintf(b, "@%s: %s\n", c.User.Login, strings.TrimSpace(c.Body))
+		}
+		b.WriteString("\n")
+	}
+	groups := make([]summarize.Group, len(paths))
+	for i, path := range paths {
+		groups[i] = summarize.Group{Name: path, Text: byPath[path].String()}
+	}
+	return groups
+}
+
+// partialsOf wraps groups as partials so unsummarised threads print like
+// summarised ones.
+func partialsOf(groups []summarize.Group) []summarize.Partial {
+	partials := make([]summarize.Partial, len(groups))
+	for i, g := range groups {
+		partials[i] = summarize.Partial{Name: g.Name, Summary: strings.TrimSpace(g.Text)}
+	}
+	return partials
+}
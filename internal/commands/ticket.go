@@ -6,13 +6,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
 
 	"github.com/dennisloska/noji/internal/commands/output"
 	"github.com/dennisloska/noji/internal/config"
-	"github.com/dennisloska/noji/internal/opencode"
 	"github.com/spf13/cobra"
 )
 
@@ -26,12 +24,20 @@ func newTicketCmd() *cobra.Command {
 	return cmd
 }
 
+// ticketRow is one ticket returned by the ticket_list prompt.
+type ticketRow struct {
+	Key     string `json:"key"`
+	Status  string `json:"status"`
+	Summary string `json:"summary"`
+	URL     string `json:"url"`
+}
+
 func newTicketUpdateCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "update",
 		Short: "Update a ticket using opencode",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runPrompt("ticket_update.txt")
+			return runPrompt(printer(cmd), "ticket_update.txt")
 		},
 	}
 }
@@ -47,24 +53,23 @@ func newTicketEditCmd() *cobra.Command {
 				return errors.New("ticket key is required")
 			}
 			openFlag, _ := cmd.Flags().GetBool("open")
-			return runTicketEdit(key, openFlag)
+			return runTicketEdit(printer(cmd), key, openFlag)
 		},
 	}
 	cmd.Flags().Bool("open", false, "open the ticket in the browser after updating")
 	return cmd
 }
 
-func runTicketEdit(key string, openAfter bool) error {
+func runTicketEdit(p *output.Printer, key string, openAfter bool) error {
 	// 1) Fetch current description via opencode prompt
-	model, err := config.GetModel()
+	runner, err := newModelRunner(config.TaskTicketEdit, p.Warnf)
 	if err != nil {
 		return err
 	}
-	promptsDir, err := config.PromptsDir()
+	promptPath, err := config.PromptPath("ticket_edit.txt")
 	if err != nil {
 		return err
 	}
-	promptPath := filepath.Join(promptsDir, "ticket_edit.txt")
 	promptBytes, err := os.ReadFile(promptPath)
 	if err != nil {
 		return fmt.Errorf("read prompt file %s: %w", promptPath, err)
@@ -73,7 +78,7 @@ func runTicketEdit(key string, openAfter bool) error {
 	prompt := string(promptBytes) + "\nTicket key: " + key + "\n"
 
 	// Capture opencode output to a buffer rather than streaming to stdout
-	desc, err := runOpencodeCapture(model, prompt)
+	desc, err := runner.capture(prompt)
 	if err != nil {
 		return err
 	}
@@ -85,13 +90,10 @@ func runTicketEdit(key string, openAfter bool) error {
 	}
 	defer os.Remove(tmpFile)
 
-	ed, err := config.GetEditor()
+	ed, err := resolveEditor()
 	if err != nil {
 		return err
 	}
-	if ov := os.Getenv("NOJI_EDITOR_OVERRIDE"); strings.TrimSpace(ov) != "" {
-		ed = ov
-	}
 	if err := openInEditor(ed, tmpFile); err != nil {
 		return err
 	}
@@ -102,41 +104,84 @@ func runTicketEdit(key string, openAfter bool) error {
 	}
 	newDesc := string(edited)
 	if newDesc == desc {
-		output.Infof(output.ModeAuto, "No changes detected.\n")
+		p.Infof("No changes detected.\n")
 		return nil
 	}
 
 	// 3) Write back via opencode using MCP to update the ticket Description exactly
 	delimStart := "---BEGIN_DESCRIPTION---"
 	delimEnd := "---END_DESCRIPTION---"
-	updatePrompt := fmt.Sprintf("Use only the Atlassian MCP server tools (no web). Replace the Jira issue %s Description field with EXACTLY the content between %s and %s. Do not add, remove, rephrase, or format anything.\n%s\n%s\n%s", key, delimStart, delimEnd, delimStart, newDesc, delimEnd)
-	if err := opencode.RunWithPrompt(model, updatePrompt); err != nil {
+	updatePrompt := fmt.Sprintf("Use only the Atlassian MCP server tools (no web). Replace the Jira issue %s Description field with EXACTLY the content between %s and %s. Do not add, remove, rephrase, or format anything.\n%s\n%s\n%s", key, delimStart, delimEnd, delimStart, verbatimPlaceholder, delimEnd)
+	if err := runner.streamVerbatim(updatePrompt, newDesc); err != nil {
 		return err
 	}
 
-	output.Successf(output.ModeAuto, "Ticket %s description updated.\n", key)
+	p.Successf("Ticket %s description updated.\n", key)
 	if openAfter {
 		if err := openTicketInBrowser(key); err != nil {
-			output.Infof(output.ModeAuto, "Could not open browser: %v\n", err)
+			p.Infof("Could not open browser: %v\n", err)
 		}
 	}
 	return nil
 }
 
-// runOpencodeCapture runs opencode and returns its stdout as string.
+// listMyTickets asks the model (via the tracker MCP server) for my open tickets.
+func listMyTickets() ([]ticketRow, error) {
+	runner, err := newModelRunner(config.TaskTicketList, nil)
+	if err != nil {
+		return nil, err
+	}
+	promptPath, err := config.PromptPath("ticket_list.txt")
+	if err != nil {
+		return nil, err
+	}
+	promptBytes, err := os.ReadFile(promptPath)
+	if err != nil {
+		return nil, fmt.Errorf("read prompt file %s: %w", promptPath, err)
+	}
+	if strings.TrimSpace(string(promptBytes)) == "" {
+		return nil, fmt.Errorf("prompt file %s is empty", promptPath)
+	}
+	out, err := runner.quiet(string(promptBytes))
+	if err != nil {
+		return nil, err
+	}
+	var rows []ticketRow
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		row := ticketRow{Key: fields[0], Status: fields[1], Summary: fields[2]}
+		if len(fields) > 3 {
+			row.URL = fields[3]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func openTicketInBrowser(key string) error {
 	// Try to construct a Jira URL from environment or git remote
-	// First, allow explicit base via NOJI_JIRA_BASE like https://jira.example.com/browse
-	if base := strings.TrimRight(os.Getenv("NOJI_JIRA_BASE"), "/"); base != "" {
+	// First, allow an explicit base via tracker.url (or the older NOJI_JIRA_BASE)
+	// like https://jira.example.com/browse
+	base, err := config.GetTrackerURL()
+	if err != nil {
+		return err
+	}
+	if base == "" {
+		base = os.Getenv("NOJI_JIRA_BASE")
+	}
+	if base = strings.TrimRight(base, "/"); base != "" {
 		return openURL(base + "/" + key)
 	}
 	// Fallback: ask Atlassian MCP for the browse URL for this key
-	model, err := config.GetModel()
+	runner, err := newModelRunner(config.TaskTicketEdit, nil)
 	if err != nil {
 		return err
 	}
 	prompt := fmt.Sprintf("Using only Atlassian MCP tools (no web), return ONLY the direct browser URL to open the Jira issue %s (no extra text).", key)
-	url, err := runOpencodeCapture(model, prompt)
+	url, err := runner.capture(prompt)
 	if err != nil {
 		return err
 	}
@@ -173,3 +218,16 @@ func runOpencodeCapture(model, prompt string) (string, error) {
 	}
 	return out.String(), nil
 }
+
+// runOpencodeQuiet is runOpencodeCapture for full-screen callers: stderr is
+// captured and only surfaced in the error.
+func runOpencodeQuiet(model, prompt string) (string, error) {
+	cmd := exec.Command("opencode", "run", "-m", model, prompt)
+	var out, errb bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("opencode run failed: %w: %s", err, strings.TrimSpace(errb.String()))
+	}
+	return out.String(), nil
+}
@@ -3,7 +3,6 @@ package commands
 import (
 	"errors"
 
-	"github.com/dennisloska/noji/internal/commands/output"
 	"github.com/dennisloska/noji/internal/config"
 	"github.com/dennisloska/noji/internal/opencode"
 	"github.com/spf13/cobra"
@@ -15,6 +14,7 @@ func newUseCmd() *cobra.Command {
 		Short: "Select a model to use",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			p := printer(cmd)
 			model := args[0]
 			models, err := opencode.ListModels()
 			if err != nil {
@@ -26,7 +26,7 @@ func newUseCmd() *cobra.Command {
 			if err := config.SetModel(model); err != nil {
 				return err
 			}
-			output.Successf(output.ModeAuto, "Selected model: %s\n", model)
+			p.Successf("Selected model: %s\n", model)
 			return nil
 		},
 	}
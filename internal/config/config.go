@@ -19,6 +19,20 @@ const (
 	promptsDir = "prompts"
 )
 
+// Settings that usually differ between work contexts (see profiles).
+const (
+	keyPromptsDir  = "prompts.dir"
+	keyGitHubHost  = "github.host"
+	keyGitHubOrgs  = "github.orgs"
+	keyTrackerURL  = "tracker.url"
+	keyTrackerUser = "tracker.user"
+)
+
+const (
+	keyAuthBackend  = "auth.backend"
+	credentialsFile = "credentials.enc"
+)
+
 // EnsureConfig sets up config dir, default config, and placeholder prompts.
 // Resolution order:
 // 1) If NOJI_CONFIG_HOME is set, use $NOJI_CONFIG_HOME/noji
@@ -42,8 +56,10 @@ func EnsureConfig() (configPath string, promptsPath string, err error) {
 	v.SetConfigName(configName)
 	v.SetConfigType(configType)
 	v.AddConfigPath(appDir)
-	v.SetDefault(keyModel, "github-copilot/gpt-4.1")
-	v.SetDefault(keyEditor, "vim")
+	for _, key := range []string{keyModel, keyEditor} {
+		f, _ := Lookup(key)
+		v.SetDefault(key, f.Default)
+	}
 
 	cfgFile := filepath.Join(appDir, configName+"."+configType)
 	if _, statErr := os.Stat(cfgFile); errors.Is(statErr, os.ErrNotExist) {
@@ -80,7 +96,7 @@ func EnsureConfig() (configPath string, promptsPath string, err error) {
 		}
 	} else {
 		// If reading repo prompts fails, still ensure known files exist as empty
-		fallback := []string{"pr_create.txt", "pr_update.txt", "ticket_update.txt", "ticket_edit.txt"}
+		fallback := []string{"pr_create.txt", "pr_update.txt", "ticket_update.txt", "ticket_edit.txt", "ticket_list.txt", "pr_respond.txt", "pr_review.txt", "ci_explain.txt", "commit.txt", "standup.txt"}
 		for _, name := range fallback {
 			userPath := filepath.Join(prompts, name)
 			if st, err := os.Stat(userPath); errors.Is(err, os.ErrNotExist) || (err == nil && st.Size() == 0) {
@@ -105,86 +121,114 @@ func resolveAppDir() (string, error) {
 	return filepath.Join(configHome, appDirName), nil
 }
 
-// GetModel reads the selected model from config.
-func GetModel() (string, error) {
-	if _, _, err := EnsureConfig(); err != nil {
-		return "", err
+// GetString returns the effective value of a schema setting as a string.
+func GetString(key string) (string, error) {
+	if _, ok := Lookup(key); !ok {
+		return "", unknownKey(key)
 	}
-	v := viper.New()
-	appDir, err := resolveAppDir()
+	v, err := readConfig()
 	if err != nil {
 		return "", err
 	}
-	v.SetConfigName(configName)
-	v.SetConfigType(configType)
-	v.AddConfigPath(appDir)
-	if err := v.ReadInConfig(); err != nil {
+	return v.GetString(key), nil
+}
+
+// GetList returns the effective value of a list setting.
+func GetList(key string) ([]string, error) {
+	if _, ok := Lookup(key); !ok {
+		return nil, unknownKey(key)
+	}
+	v, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	return v.GetStringSlice(key), nil
+}
+
+// GetModel reads the selected model from the effective config.
+func GetModel() (string, error) {
+	c, err := Load()
+	if err != nil {
 		return "", err
 	}
-	return v.GetString(keyModel), nil
+	return c.Model, nil
 }
 
 // GetEditor reads the preferred editor from config (defaults to vim).
 func GetEditor() (string, error) {
-	if _, _, err := EnsureConfig(); err != nil {
-		return "", err
-	}
-	v := viper.New()
-	appDir, err := resolveAppDir()
+	c, err := Load()
 	if err != nil {
 		return "", err
 	}
-	v.SetConfigName(configName)
-	v.SetConfigType(configType)
-	v.AddConfigPath(appDir)
-	if err := v.ReadInConfig(); err != nil {
-		return "", err
-	}
-	ed := v.GetString(keyEditor)
-	if strings.TrimSpace(ed) == "" {
+	if strings.TrimSpace(c.Editor) == "" {
 		return "vim", nil
 	}
-	return ed, nil
+	return c.Editor, nil
 }
 
-// SetModel writes the selected model to config.
-func SetModel(model string) error {
-	if _, _, err := EnsureConfig(); err != nil {
-		return err
+// GetGitHubHost returns the GitHub host gh should talk to, or "".
+func GetGitHubHost() (string, error) {
+	c, err := Load()
+	if err != nil {
+		return "", err
 	}
-	v := viper.New()
-	appDir, err := resolveAppDir()
+	return c.GitHub.Host, nil
+}
+
+// GetGitHubOrgs returns the organizations to search by default.
+func GetGitHubOrgs() ([]string, error) {
+	c, err := Load()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	v.SetConfigName(configName)
-	v.SetConfigType(configType)
-	v.AddConfigPath(appDir)
-	if err := v.ReadInConfig(); err != nil {
-		return err
+	return c.GitHub.Orgs, nil
+}
+
+// GetTrackerURL returns the ticket browse URL, or "".
+func GetTrackerURL() (string, error) {
+	c, err := Load()
+	if err != nil {
+		return "", err
 	}
-	v.Set(keyModel, model)
-	return v.WriteConfig()
+	return c.Tracker.URL, nil
 }
 
-// SetEditor writes the preferred editor to config.
-func SetEditor(editor string) error {
-	if _, _, err := EnsureConfig(); err != nil {
-		return err
+// GetTrackerUser returns the account the Jira API token belongs to, or "".
+func GetTrackerUser() (string, error) {
+	c, err := Load()
+	if err != nil {
+		return "", err
 	}
-	v := viper.New()
-	appDir, err := resolveAppDir()
+	return c.Tracker.User, nil
+}
+
+// GetAuthBackend returns where `noji auth login` stores tokens.
+func GetAuthBackend() (string, error) {
+	c, err := Load()
 	if err != nil {
-		return err
+		return "", err
 	}
-	v.SetConfigName(configName)
-	v.SetConfigType(configType)
-	v.AddConfigPath(appDir)
-	if err := v.ReadInConfig(); err != nil {
-		return err
+	return c.Auth.Backend, nil
+}
+
+// CredentialsPath returns the encrypted token file used when the OS keyring
+// is unavailable.
+func CredentialsPath() (string, error) {
+	dir, err := resolveAppDir()
+	if err != nil {
+		return "", err
 	}
-	v.Set(keyEditor, editor)
-	return v.WriteConfig()
+	return filepath.Join(dir, credentialsFile), nil
+}
+
+// SetModel writes the selected model to the user config.
+func SetModel(model string) error {
+	return Set(keyModel, model, false)
+}
+
+// SetEditor writes the preferred editor to the user config.
+func SetEditor(editor string) error {
+	return Set(keyEditor, editor, false)
 }
 
 // PromptsDir returns the prompts directory path.
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setup points the config at a fresh directory outside any repository.
+func setup(t *testing.T, userConfig string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("NOJI_CONFIG_HOME", home)
+	t.Chdir(t.TempDir())
+	for _, f := range Schema {
+		if _, ok := os.LookupEnv(EnvName(f.Key)); ok {
+			t.Setenv(EnvName(f.Key), "")
+			os.Unsetenv(EnvName(f.Key))
+		}
+	}
+	path, _, err := EnsureConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(userConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// configKeys returns the dotted mapstructure keys of the leaf fields of t.
+func configKeys(prefix string, t reflect.Type) []string {
+	var keys []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key := prefix + f.Tag.Get("mapstructure")
+		if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Duration(0)) {
+			keys = append(keys, configKeys(key+".", f.Type)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestConfigCoversSchema(t *testing.T) {
+	keys := configKeys("", reflect.TypeOf(Config{}))
+	for _, f := range Schema {
+		if !slices.Contains(keys, f.Key) {
+			t.Errorf("schema key %s has no field in Config", f.Key)
+		}
+	}
+	for _, k := range keys {
+		if _, ok := Lookup(k); !ok {
+			t.Errorf("Config field %s is not in the schema", k)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setup(t, "model: a/b\nreviews:\n  sort: oldest\n  weights:\n    age: 2.5\ngithub:\n  orgs: [acme]\n")
+	t.Setenv("NOJI_REVIEWS_SLA", "2h")
+	t.Setenv("NOJI_TITLE_TYPES", "feat, fix")
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Model != "a/b" || c.Reviews.Sort != "oldest" || c.Reviews.Weights.Age != 2.5 || c.Reviews.Weights.Draft != 3 {
+		t.Errorf("file values or defaults not loaded: %+v", c.Reviews)
+	}
+	if c.Reviews.SLA != 2*time.Hour {
+		t.Errorf("SLA = %v, want the 2h from NOJI_REVIEWS_SLA", c.Reviews.SLA)
+	}
+	if !slices.Equal(c.GitHub.Orgs, []string{"acme"}) || !slices.Equal(c.Title.Types, []string{"feat", "fix"}) {
+		t.Errorf("lists: orgs %q, title types %q", c.GitHub.Orgs, c.Title.Types)
+	}
+	if !c.Redact.Enabled || c.Diff.Budget != 16000 {
+		t.Errorf("defaults not applied: redact.enabled %v, diff.budget %d", c.Redact.Enabled, c.Diff.Budget)
+	}
+}
+
+func TestInvalidEnvIsAWarning(t *testing.T) {
+	setup(t, "")
+	t.Setenv("NOJI_REVIEWS_SORT", "bogus")
+	t.Setenv("NOJI_DIFF_BUDGET", "lots")
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("an invalid NOJI_* value must not fail loading: %v", err)
+	}
+	if c.Reviews.Sort != "weighted" || c.Diff.Budget != 16000 {
+		t.Errorf("invalid env values were used: sort %q, budget %d", c.Reviews.Sort, c.Diff.Budget)
+	}
+	problems, err := Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, p := range problems {
+		files = append(files, p.File)
+	}
+	if !slices.Contains(files, "NOJI_REVIEWS_SORT") || !slices.Contains(files, "NOJI_DIFF_BUDGET") {
+		t.Errorf("Check() = %v, want problems for both variables", problems)
+	}
+}
+
+func TestRepoConfigAllowlist(t *testing.T) {
+	setup(t, "editor: vim\ntracker:\n  url: https://acme.atlassian.net/browse\n")
+	repo := "editor: evil\ntracker:\n  url: https://evil.example/browse\nredact:\n  enabled: false\ntitle:\n  max_length: 50\n"
+	if err := os.Mkdir(".git", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(RepoConfigFile, []byte(repo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Editor != "vim" || c.Tracker.URL != "https://acme.atlassian.net/browse" || !c.Redact.Enabled {
+		t.Errorf("repo overrode user-only settings: editor %q, tracker %q, redact %v", c.Editor, c.Tracker.URL, c.Redact.Enabled)
+	}
+	if c.Title.MaxLength != 50 {
+		t.Errorf("title.max_length = %d, want the repo's 50", c.Title.MaxLength)
+	}
+	problems, err := Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, p := range problems {
+		keys = append(keys, p.Key)
+	}
+	slices.Sort(keys)
+	if want := []string{"editor", "redact.enabled", "tracker.url"}; !slices.Equal(keys, want) {
+		t.Errorf("problems for %q, want %q", keys, want)
+	}
+	if err := Set("editor", "nano", true); err == nil {
+		t.Error("Set(editor) in the repo config succeeded")
+	}
+}
+
+func TestSetKeepsCommentsAndOrder(t *testing.T) {
+	path := setup(t, `# noji settings
+model: a/b # the default model
+
+# review queue
+reviews:
+  sla: 24h
+  sort: weighted # ranked
+editor: vim
+`)
+	if err := Set("reviews.sort", "api", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("github.orgs", "acme,globex", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unset("editor", false); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// yaml.v3 does not keep blank lines between entries
+	want := `# noji settings
+model: a/b # the default model
+# review queue
+reviews:
+  sla: 24h
+  sort: api # ranked
+github:
+  orgs:
+    - acme
+    - globex
+`
+	if string(b) != want {
+		t.Errorf("config after Set/Unset:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestUnsetPrunesSections(t *testing.T) {
+	path := setup(t, "# only comments\n")
+	if err := Set("reviews.weights.age", "2", false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := Unset("reviews.weights.age", false); err != nil || !ok {
+		t.Fatalf("Unset = %v, %v", ok, err)
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "# only comments" {
+		t.Errorf("config = %q, want only the comment left", got)
+	}
+}
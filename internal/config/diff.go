@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/dennisloska/noji/internal/diffpack"
+)
+
+const (
+	keyDiffBudget    = "diff.budget"
+	keyDiffGenerated = "diff.generated"
+	keyDiffSummarize = "diff.summarize"
+	keyDiffParallel  = "diff.parallel"
+	keyDiffCache     = "diff.cache"
+)
+
+// GetDiffOptions reads how diffs are packed into prompts.
+func GetDiffOptions() (diffpack.Options, error) {
+	c, err := Load()
+	if err != nil {
+		return diffpack.Options{}, err
+	}
+	return diffpack.Options{Budget: c.Diff.Budget, Generated: c.Diff.Generated}, nil
+}
+
+// SummarizeSettings configure map-reduce summarisation of input over the
+// diff budget.
+type SummarizeSettings struct {
+	Enabled  bool
+	Parallel int
+	Cache    bool
+}
+
+// GetSummarizeSettings reads how input over the diff budget is summarised.
+func GetSummarizeSettings() (SummarizeSettings, error) {
+	c, err := Load()
+	if err != nil {
+		return SummarizeSettings{}, err
+	}
+	return SummarizeSettings{Enabled: c.Diff.Summarize, Parallel: c.Diff.Parallel, Cache: c.Diff.Cache}, nil
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Sources of a setting, lowest precedence first.
+const (
+	SourceDefault = "default"
+	SourceUser    = "user"
+	SourceProfile = "profile"
+	SourceRepo    = "repo"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+const (
+	// RepoConfigFile is the per-repository config at the git root.
+	RepoConfigFile = ".noji.yaml"
+	// RepoPromptsDir holds per-repository prompt files, relative to the git root.
+	RepoPromptsDir = ".noji/prompts"
+	envPrefix      = "NOJI_"
+)
+
+// defaults returns the schema defaults by key.
+func defaults() map[string]any {
+	out := map[string]any{}
+	for _, f := range Schema {
+		if f.Default != nil {
+			out[f.Key] = f.Default
+		}
+	}
+	return out
+}
+
+// flagValues are settings given on the command line for this process.
+var flagValues = map[string]any{}
+
+// SetFlag overrides key for this process; flags take precedence over
+// every other source.
+func SetFlag(key string, value any) {
+	flagValues[key] = value
+}
+
+// layer is one source of settings with its keys flattened ("reviews.sla").
+type layer struct {
+	source  string
+	file    string
+	profile string
+	values  map[string]any
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// RepoRoot returns the root of the git work tree containing the current
+// directory, or "" outside a repository.
+func RepoRoot() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// RepoConfigPath returns the .noji.yaml of the current repository, or ""
+// when there is none.
+func RepoConfigPath() string {
+	root := RepoRoot()
+	if root == "" {
+		return ""
+	}
+	p := filepath.Join(root, RepoConfigFile)
+	if _, err := os.Stat(p); err != nil {
+		return ""
+	}
+	return p
+}
+
+// repoSections are the settings a repository's .noji.yaml may set. The file
+// comes with the code, so a cloned repository or a fork's PR worktree must
+// not choose the editor, where tokens go, the GitHub host or redaction.
+var repoSections = []string{"prompts", "title", "diff", "models", "reviews", "standup"}
+
+// RepoAllowed reports whether key may be set in a repository's .noji.yaml.
+// The profile key only selects one of the user's own profiles.
+func RepoAllowed(key string) bool {
+	if key == keyProfile {
+		return true
+	}
+	section, _, ok := strings.Cut(key, ".")
+	return ok && slices.Contains(repoSections, section)
+}
+
+// readRepoYAML reads a repository config and leaves out the keys it may not
+// set; CheckFile reports them.
+func readRepoYAML(path string) (map[string]any, error) {
+	values, err := readYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	maps.DeleteFunc(values, func(k string, _ any) bool { return !RepoAllowed(k) })
+	return values, nil
+}
+
+// loadLayers reads every source, lowest precedence first.
+func loadLayers() ([]layer, error) {
+	userFile, _, err := EnsureConfig()
+	if err != nil {
+		return nil, err
+	}
+	layers := []layer{{source: SourceDefault, values: defaults()}}
+
+	user, err := readYAML(userFile)
+	if err != nil {
+		return nil, err
+	}
+	profiles := splitProfiles(user)
+	layers = append(layers, layer{source: SourceUser, file: userFile, values: user})
+
+	var repo map[string]any
+	repoFile := RepoConfigPath()
+	if repoFile != "" {
+		if repo, err = readRepoYAML(repoFile); err != nil {
+			return nil, err
+		}
+	}
+
+	active, err := activeProfile(profiles, user, repo)
+	if err != nil {
+		return nil, err
+	}
+	if active.Name != "" {
+		values := maps.Clone(profiles[active.Name].Settings)
+		values[keyProfile] = active.Name
+		layers = append(layers, layer{source: SourceProfile, file: userFile, profile: active.Name, values: values})
+	}
+
+	if repoFile != "" {
+		layers = append(layers, layer{source: SourceRepo, file: repoFile, values: repo})
+	}
+
+	env, _ := envValues()
+	layers = append(layers, layer{source: SourceEnv, values: env})
+	layers = append(layers, layer{source: SourceFlag, values: flagValues})
+	return layers, nil
+}
+
+// envValues reads the settings given as NOJI_<KEY>, the key upper-cased
+// with dots replaced by underscores (NOJI_REVIEWS_SORT). Invalid values are
+// left out and returned as problems, which Check reports.
+func envValues() (map[string]any, []Problem) {
+	env := map[string]any{}
+	var problems []Problem
+	for _, f := range Schema {
+		name := EnvName(f.Key)
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		val, err := f.Parse(raw)
+		if err != nil {
+			problems = append(problems, Problem{File: name, Key: f.Key, Message: err.Error() + "; ignored"})
+			continue
+		}
+		env[f.Key] = val
+	}
+	return env, problems
+}
+
+// readYAML reads a config file into flattened keys.
+func readYAML(path string) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(configType)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	out := map[string]any{}
+	for _, k := range v.AllKeys() {
+		out[k] = v.Get(k)
+	}
+	return out, nil
+}
+
+// readConfig returns the effective settings: every layer merged in
+// precedence order.
+func readConfig() (*viper.Viper, error) {
+	layers, err := loadLayers()
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	for _, l := range layers {
+		for k, val := range l.values {
+			v.Set(k, val)
+		}
+	}
+	return v, nil
+}
+
+// Setting is an effective value and where it came from.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+	File   string `json:"file,omitempty"`
+	// Env is the variable for env sources.
+	Env string `json:"env,omitempty"`
+	// Profile is the profile name for profile sources.
+	Profile string `json:"profile,omitempty"`
+}
+
+// Effective lists every set key with the source that won, sorted by key.
+func Effective() ([]Setting, error) {
+	layers, err := loadLayers()
+	if err != nil {
+		return nil, err
+	}
+	won := map[string]Setting{}
+	for _, l := range layers {
+		for k, val := range l.values {
+			s := Setting{Key: k, Value: val, Source: l.source, File: l.file, Profile: l.profile}
+			if l.source == SourceEnv {
+				s.Env = EnvName(k)
+			}
+			won[k] = s
+		}
+	}
+	keys := slices.Sorted(maps.Keys(won))
+	out := make([]Setting, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, won[k])
+	}
+	return out, nil
+}
+
+// PromptPath resolves a prompt file repo-first: <git root>/.noji/prompts/name,
+// then the prompts.dir setting (e.g. from a profile), then the user prompts
+// directory.
+func PromptPath(name string) (string, error) {
+	var dirs []string
+	if root := RepoRoot(); root != "" {
+		dirs = append(dirs, filepath.Join(root, filepath.FromSlash(RepoPromptsDir)))
+	}
+	custom, err := GetString(keyPromptsDir)
+	if err != nil {
+		return "", err
+	}
+	if custom != "" {
+		dirs = append(dirs, expandHome(custom))
+	}
+	for _, dir := range dirs {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	dir, err := PromptsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import "fmt"
+
+// Config is the effective configuration as typed values, decoded from every
+// layer merged in precedence order. Its mapstructure tags are the schema
+// keys; every key of Schema has a field here.
+type Config struct {
+	Model   string `mapstructure:"model"`
+	Editor  string `mapstructure:"editor"`
+	Profile string `mapstructure:"profile"`
+	Prompts struct {
+		Dir string `mapstructure:"dir"`
+	} `mapstructure:"prompts"`
+	Models ModelsConfig `mapstructure:"models"`
+	GitHub struct {
+		Host string   `mapstructure:"host"`
+		Orgs []string `mapstructure:"orgs"`
+	} `mapstructure:"github"`
+	Tracker struct {
+		URL  string `mapstructure:"url"`
+		User string `mapstructure:"user"`
+	} `mapstructure:"tracker"`
+	Auth struct {
+		Backend string `mapstructure:"backend"`
+	} `mapstructure:"auth"`
+	Reviews ReviewSettings `mapstructure:"reviews"`
+	Redact  struct {
+		Enabled      bool     `mapstructure:"enabled"`
+		Disable      []string `mapstructure:"disable"`
+		Patterns     []string `mapstructure:"patterns"`
+		ExcludePaths []string `mapstructure:"exclude_paths"`
+		Strict       bool     `mapstructure:"strict"`
+		Show         bool     `mapstructure:"show"`
+	} `mapstructure:"redact"`
+	Diff struct {
+		Budget    int      `mapstructure:"budget"`
+		Generated []string `mapstructure:"generated"`
+		Summarize bool     `mapstructure:"summarize"`
+		Parallel  int      `mapstructure:"parallel"`
+		Cache     bool     `mapstructure:"cache"`
+	} `mapstructure:"diff"`
+	Standup struct {
+		Repos []string `mapstructure:"repos"`
+	} `mapstructure:"standup"`
+	Worktrees struct {
+		Dir string `mapstructure:"dir"`
+	} `mapstructure:"worktrees"`
+	Title struct {
+		Pattern       string   `mapstructure:"pattern"`
+		Types         []string `mapstructure:"types"`
+		Scope         string   `mapstructure:"scope"`
+		TicketPattern string   `mapstructure:"ticket_pattern"`
+		MaxLength     int      `mapstructure:"max_length"`
+		Enforce       bool     `mapstructure:"enforce"`
+	} `mapstructure:"title"`
+}
+
+// ModelsConfig holds the per-task models (see ResolveModel).
+type ModelsConfig struct {
+	Fallbacks    []string `mapstructure:"fallbacks"`
+	PRCreate     string   `mapstructure:"pr_create"`
+	PRUpdate     string   `mapstructure:"pr_update"`
+	PRReview     string   `mapstructure:"pr_review"`
+	PRRespond    string   `mapstructure:"pr_respond"`
+	CIExplain    string   `mapstructure:"ci_explain"`
+	Classify     string   `mapstructure:"classify"`
+	Summarize    string   `mapstructure:"summarize"`
+	Commit       string   `mapstructure:"commit"`
+	Standup      string   `mapstructure:"standup"`
+	TicketUpdate string   `mapstructure:"ticket_update"`
+	TicketEdit   string   `mapstructure:"ticket_edit"`
+	TicketList   string   `mapstructure:"ticket_list"`
+}
+
+// Load returns the effective configuration. Lists may be given as YAML
+// lists or comma-separated strings, durations as strings such as 24h.
+func Load() (*Config, error) {
+	v, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := v.Unmarshal(&c); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return &c, nil
+}
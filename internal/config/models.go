@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Tasks that can use their own model (models.<task>).
+const (
+	TaskPRCreate     = "pr_create"
+	TaskPRUpdate     = "pr_update"
+	TaskPRReview     = "pr_review"
+	TaskPRRespond    = "pr_respond"
+	TaskCIExplain    = "ci_explain"
+	TaskClassify     = "classify"
+	TaskSummarize    = "summarize"
+	TaskCommit       = "commit"
+	TaskStandup      = "standup"
+	TaskTicketUpdate = "ticket_update"
+	TaskTicketEdit   = "ticket_edit"
+	TaskTicketList   = "ticket_list"
+)
+
+// ModelTasks lists every task in the order `noji current` shows them.
+var ModelTasks = []string{
+	TaskPRCreate, TaskPRUpdate, TaskPRReview, TaskPRRespond, TaskCIExplain,
+	TaskClassify, TaskSummarize, TaskCommit, TaskStandup, TaskTicketUpdate, TaskTicketEdit, TaskTicketList,
+}
+
+const (
+	keyModels         = "models"
+	keyModelFallbacks = keyModels + ".fallbacks"
+)
+
+func modelKey(task string) string {
+	return keyModels + "." + task
+}
+
+// ModelChoice is the model resolved for a task and the setting it came from.
+type ModelChoice struct {
+	Task   string `json:"task"`
+	Model  string `json:"model"`
+	Key    string `json:"key"`
+	Source string `json:"source"`
+	// Fallbacks are tried in order when Model fails or is not available.
+	Fallbacks []string `json:"fallbacks,omitempty"`
+}
+
+// Chain returns the model followed by its fallbacks.
+func (c ModelChoice) Chain() []string {
+	var chain []string
+	if c.Model != "" {
+		chain = append(chain, c.Model)
+	}
+	return append(chain, c.Fallbacks...)
+}
+
+// ResolveModel returns the model of task: --model, then models.<task>, then
+// model. Fallbacks come from models.fallbacks without repeating the model.
+func ResolveModel(task string) (ModelChoice, error) {
+	if !slices.Contains(ModelTasks, task) {
+		return ModelChoice{}, fmt.Errorf("unknown model task %q", task)
+	}
+	settings, err := Effective()
+	if err != nil {
+		return ModelChoice{}, err
+	}
+	byKey := map[string]Setting{}
+	for _, s := range settings {
+		byKey[s.Key] = s
+	}
+	c := ModelChoice{Task: task}
+	pick := func(key string) bool {
+		s, ok := byKey[key]
+		if !ok || strings.TrimSpace(fmt.Sprint(s.Value)) == "" {
+			return false
+		}
+		c.Model, c.Key, c.Source = strings.TrimSpace(fmt.Sprint(s.Value)), key, s.Source
+		return true
+	}
+	// a --model flag beats every per-task setting
+	if byKey[keyModel].Source != SourceFlag || !pick(keyModel) {
+		if !pick(modelKey(task)) {
+			pick(keyModel)
+		}
+	}
+	for _, m := range toStrings(byKey[keyModelFallbacks].Value) {
+		if m = strings.TrimSpace(m); m != "" && m != c.Model && !slices.Contains(c.Fallbacks, m) {
+			c.Fallbacks = append(c.Fallbacks, m)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	keyProfile = "profile"
+	// profilesKey holds the named profiles in the user config:
+	// profiles.<name>.<setting> plus profiles.<name>.match.
+	profilesKey = "profiles"
+	matchKey    = "match"
+)
+
+// Reasons a profile is active.
+const (
+	ProfileByFlag    = "flag"
+	ProfileByEnv     = "env"
+	ProfileByRepo    = "repo"
+	ProfileByRemote  = "remote"
+	ProfileByDefault = "default"
+)
+
+// Profile is a named set of overrides from the user config.
+type Profile struct {
+	Name string `json:"name"`
+	// Match lists remote patterns such as github.com/acme/* that select the
+	// profile automatically.
+	Match    []string       `json:"match,omitempty"`
+	Settings map[string]any `json:"settings"`
+}
+
+// ActiveProfile describes the selected profile and why it was selected.
+type ActiveProfile struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// splitProfiles moves profiles.* keys out of the flattened user settings.
+func splitProfiles(user map[string]any) map[string]*Profile {
+	profiles := map[string]*Profile{}
+	for k, v := range user {
+		rest, ok := strings.CutPrefix(k, profilesKey+".")
+		if !ok {
+			continue
+		}
+		delete(user, k)
+		name, key, ok := strings.Cut(rest, ".")
+		if !ok {
+			continue
+		}
+		p := profiles[name]
+		if p == nil {
+			p = &Profile{Name: name, Settings: map[string]any{}}
+			profiles[name] = p
+		}
+		if key == matchKey {
+			p.Match = toStrings(v)
+			continue
+		}
+		p.Settings[key] = v
+	}
+	return profiles
+}
+
+// selectProfile picks the active profile: --profile, NOJI_PROFILE, the
+// repository's .noji.yaml, a profile whose match patterns fit the origin
+// remote, then the profile chosen with `noji profile use`.
+func selectProfile(profiles map[string]*Profile, user, repo map[string]any) (ActiveProfile, error) {
+	var active ActiveProfile
+	switch {
+	case flagValues[keyProfile] != nil:
+		active = ActiveProfile{Name: fmt.Sprint(flagValues[keyProfile]), Reason: ProfileByFlag}
+	case os.Getenv(EnvName(keyProfile)) != "":
+		active = ActiveProfile{Name: os.Getenv(EnvName(keyProfile)), Reason: ProfileByEnv}
+	case repo[keyProfile] != nil:
+		active = ActiveProfile{Name: fmt.Sprint(repo[keyProfile]), Reason: ProfileByRepo}
+	default:
+		if name := matchRemote(profiles, remoteID()); name != "" {
+			return ActiveProfile{Name: name, Reason: ProfileByRemote}, nil
+		}
+		if user[keyProfile] != nil {
+			active = ActiveProfile{Name: fmt.Sprint(user[keyProfile]), Reason: ProfileByDefault}
+		}
+	}
+	if active.Name != "" && profiles[active.Name] == nil {
+		return active, &UnknownProfileError{Name: active.Name, Reason: active.Reason, Defined: profileNames(profiles)}
+	}
+	return active, nil
+}
+
+// UnknownProfileError is returned when the selected profile is not defined
+// in the user config.
+type UnknownProfileError struct {
+	Name    string
+	Reason  string
+	Defined string
+}
+
+func (e *UnknownProfileError) Error() string {
+	return fmt.Sprintf("unknown profile %q (defined: %s)", e.Name, e.Defined)
+}
+
+// Fatal reports whether the profile was asked for with --profile. Profiles
+// named by NOJI_PROFILE, a committed .noji.yaml or the user config only
+// warn (see Check), so a missing profile does not break every command.
+func (e *UnknownProfileError) Fatal() bool {
+	return e.Reason == ProfileByFlag
+}
+
+// activeProfile is selectProfile falling back to no profile when a
+// non-fatal source names an unknown one.
+func activeProfile(profiles map[string]*Profile, user, repo map[string]any) (ActiveProfile, error) {
+	active, err := selectProfile(profiles, user, repo)
+	var unknown *UnknownProfileError
+	if errors.As(err, &unknown) && !unknown.Fatal() {
+		return ActiveProfile{}, nil
+	}
+	return active, err
+}
+
+// profileProblem reports an unknown profile that is ignored, or nil.
+func profileProblem() (*Problem, error) {
+	profiles, user, repo, err := readProfiles()
+	if err != nil {
+		return nil, err
+	}
+	_, err = selectProfile(profiles, user, repo)
+	var unknown *UnknownProfileError
+	if !errors.As(err, &unknown) || unknown.Fatal() {
+		return nil, nil
+	}
+	source := ""
+	switch unknown.Reason {
+	case ProfileByEnv:
+		source = EnvName(keyProfile)
+	case ProfileByRepo:
+		source = RepoConfigPath()
+	default:
+		source, _ = FilePath(false)
+	}
+	return &Problem{File: source, Key: keyProfile, Message: unknown.Error() + "; using no profile"}, nil
+}
+
+// matchRemote returns the first profile (by name) with a pattern matching
+// remote, or "".
+func matchRemote(profiles map[string]*Profile, remote string) string {
+	if remote == "" {
+		return ""
+	}
+	// patterns may leave out the host: acme/* matches github.com/acme/x
+	_, ownerRepo, _ := strings.Cut(remote, "/")
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		for _, pattern := range profiles[name].Match {
+			if ok, _ := path.Match(pattern, remote); ok {
+				return name
+			}
+			if ok, _ := path.Match(pattern, ownerRepo); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	remoteOnce sync.Once
+	remote     string
+)
+
+// remoteID returns the origin remote of the current repository as
+// host/owner/repo, or "". It is looked up once per process.
+func remoteID() string {
+	remoteOnce.Do(func() {
+		out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+		if err == nil {
+			remote = normalizeRemote(strings.TrimSpace(string(out)))
+		}
+	})
+	return remote
+}
+
+// normalizeRemote turns git@host:o/r.git and https://host/o/r.git into host/o/r.
+func normalizeRemote(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	} else if i := strings.Index(url, ":"); i >= 0 {
+		url = url[:i] + "/" + url[i+1:]
+	}
+	if i := strings.Index(url, "@"); i >= 0 && i < strings.Index(url+"/", "/") {
+		url = url[i+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+func profileNames(profiles map[string]*Profile) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	return strings.Join(slices.Sorted(maps.Keys(profiles)), ", ")
+}
+
+// Profiles returns the profiles of the user config sorted by name and the
+// active one (empty Name when none).
+func Profiles() ([]Profile, ActiveProfile, error) {
+	profiles, user, repo, err := readProfiles()
+	if err != nil {
+		return nil, ActiveProfile{}, err
+	}
+	active, err := activeProfile(profiles, user, repo)
+	out := make([]Profile, 0, len(profiles))
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		out = append(out, *profiles[name])
+	}
+	return out, active, err
+}
+
+// readProfiles reads the profiles and the remaining user settings, and the
+// repository's .noji.yaml.
+func readProfiles() (profiles map[string]*Profile, user, repo map[string]any, err error) {
+	userFile, _, err := EnsureConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if user, err = readYAML(userFile); err != nil {
+		return nil, nil, nil, err
+	}
+	if repoFile := RepoConfigPath(); repoFile != "" {
+		if repo, err = readRepoYAML(repoFile); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return splitProfiles(user), user, repo, nil
+}
+
+// UseProfile makes name the default profile in the user config; an empty
+// name clears it.
+func UseProfile(name string) error {
+	if name == "" {
+		_, err := Unset(keyProfile, false)
+		return err
+	}
+	profiles, _, _ := Profiles()
+	if !slices.ContainsFunc(profiles, func(p Profile) bool { return p.Name == name }) {
+		m := map[string]*Profile{}
+		for i := range profiles {
+			m[profiles[i].Name] = &profiles[i]
+		}
+		return fmt.Errorf("unknown profile %q (defined: %s)", name, profileNames(m))
+	}
+	return Set(keyProfile, name, false)
+}
+
+func toStrings(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		out := make([]string, len(v))
+		for i, x := range v {
+			out[i] = fmt.Sprint(x)
+		}
+		return out
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
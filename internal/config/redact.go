@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/dennisloska/noji/internal/redact"
+)
+
+const (
+	keyRedactEnabled      = "redact.enabled"
+	keyRedactDisable      = "redact.disable"
+	keyRedactPatterns     = "redact.patterns"
+	keyRedactExcludePaths = "redact.exclude_paths"
+	keyRedactStrict       = "redact.strict"
+	keyRedactShow         = "redact.show"
+)
+
+// RedactSettings configure what is masked in prompts sent to models.
+type RedactSettings struct {
+	Enabled bool
+	Rules   redact.Rules
+	// Strict refuses to send a prompt in which secrets were found.
+	Strict bool
+	// Show reports what was masked (--show-redactions).
+	Show bool
+}
+
+// GetRedactSettings reads the redact section of the effective config.
+func GetRedactSettings() (RedactSettings, error) {
+	c, err := Load()
+	if err != nil {
+		return RedactSettings{}, err
+	}
+	r := c.Redact
+	return RedactSettings{
+		Enabled: r.Enabled,
+		Rules:   redact.Rules{Disable: r.Disable, Patterns: r.Patterns, ExcludePaths: r.ExcludePaths},
+		Strict:  r.Strict,
+		Show:    r.Show,
+	}, nil
+}
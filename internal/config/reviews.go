@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+const (
+	keyReviewsSort    = "reviews.sort"
+	keyReviewsSLA     = "reviews.sla"
+	keyReviewsWeights = "reviews.weights"
+)
+
+// ReviewSorts lists the accepted values of reviews.sort.
+var ReviewSorts = []string{"weighted", "smallest", "oldest", "api"}
+
+// ReviewWeights tune the "weighted" review queue order. Higher scores are
+// reviewed first.
+type ReviewWeights struct {
+	Age      float64 `mapstructure:"age"`      // per day waiting
+	Size     float64 `mapstructure:"size"`     // per order of magnitude of changed lines (subtracted)
+	CI       float64 `mapstructure:"ci"`       // green CI adds, red CI subtracts
+	SLA      float64 `mapstructure:"sla"`      // bonus once the SLA is breached
+	Draft    float64 `mapstructure:"draft"`    // subtracted for drafts
+	Reviewed float64 `mapstructure:"reviewed"` // subtracted when I already reviewed
+}
+
+// ReviewSettings configures `noji pr reviews`.
+type ReviewSettings struct {
+	Sort    string        `mapstructure:"sort"`
+	SLA     time.Duration `mapstructure:"sla"` // 0 disables SLA highlighting
+	Weights ReviewWeights `mapstructure:"weights"`
+}
+
+// GetReviewSettings reads the reviews section of the effective config.
+func GetReviewSettings() (ReviewSettings, error) {
+	c, err := Load()
+	if err != nil {
+		return ReviewSettings{}, err
+	}
+	return c.Reviews, nil
+}
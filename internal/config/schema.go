@@ -0,0 +1,254 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennisloska/noji/internal/redact"
+	"github.com/dennisloska/noji/internal/secrets"
+	"github.com/dennisloska/noji/internal/titlelint"
+)
+
+// Kinds of setting values.
+const (
+	KindString   = "string"
+	KindInt      = "int"
+	KindFloat    = "float"
+	KindBool     = "bool"
+	KindDuration = "duration"
+	KindList     = "list" // comma-separated on the command line and in env
+	KindRegex    = "regex"
+)
+
+// Field describes one setting.
+type Field struct {
+	Key         string   `json:"key"`
+	Kind        string   `json:"type"`
+	Default     any      `json:"default,omitempty"`
+	Allowed     []string `json:"allowed,omitempty"`
+	Description string   `json:"description"`
+}
+
+// Schema lists every known setting. It is the single source of defaults,
+// env overrides and validation.
+var Schema = []Field{
+	{Key: keyModel, Kind: KindString, Default: "github-copilot/gpt-4.1", Description: "opencode model (provider/model)"},
+	{Key: keyEditor, Kind: KindString, Default: "vim", Description: "editor command, e.g. vim or 'code -w'"},
+	{Key: keyProfile, Kind: KindString, Description: "profile used when no other selects one (`noji profile use`)"},
+	{Key: keyPromptsDir, Kind: KindString, Description: "prompt files directory, searched before the user prompts"},
+
+	{Key: keyModelFallbacks, Kind: KindList, Description: "models tried in order when a task's model fails or is not listed by opencode"},
+	{Key: modelKey(TaskPRCreate), Kind: KindString, Description: "model of `pr create` (default: model)"},
+	{Key: modelKey(TaskPRUpdate), Kind: KindString, Description: "model of `pr update` (default: model)"},
+	{Key: modelKey(TaskPRReview), Kind: KindString, Description: "model of `pr review` (default: model)"},
+	{Key: modelKey(TaskPRRespond), Kind: KindString, Description: "model of `pr respond` (default: model)"},
+	{Key: modelKey(TaskCIExplain), Kind: KindString, Description: "model of `pr ci explain` (default: model)"},
+	{Key: modelKey(TaskClassify), Kind: KindString, Description: "model classifying comment severity; a cheap one is enough (default: model)"},
+	{Key: modelKey(TaskSummarize), Kind: KindString, Description: "model summarising diffs over the budget; a cheap one is enough (default: model)"},
+	{Key: modelKey(TaskCommit), Kind: KindString, Description: "model of `commit` (default: model)"},
+	{Key: modelKey(TaskStandup), Kind: KindString, Description: "model of `standup` (default: model)"},
+	{Key: modelKey(TaskTicketUpdate), Kind: KindString, Description: "model of `ticket update` (default: model)"},
+	{Key: modelKey(TaskTicketEdit), Kind: KindString, Description: "model of `ticket edit` (default: model)"},
+	{Key: modelKey(TaskTicketList), Kind: KindString, Description: "model listing your tickets (default: model)"},
+
+	{Key: keyGitHubHost, Kind: KindString, Description: "GitHub host for gh (GH_HOST), e.g. github.acme.com"},
+	{Key: keyGitHubOrgs, Kind: KindList, Description: "organizations `pr reviews` searches when --org is not given"},
+	{Key: keyTrackerURL, Kind: KindString, Description: "ticket browse URL, e.g. https://acme.atlassian.net/browse"},
+	{Key: keyTrackerUser, Kind: KindString, Description: "Jira account email of the `auth login jira` token (default on *.atlassian.net: git user.email; empty elsewhere sends a bearer token)"},
+	{Key: keyAuthBackend, Kind: KindString, Default: secrets.BackendAuto, Allowed: secrets.Backends, Description: "where `auth login` stores tokens: OS keyring, encrypted file, or keyring with file fallback"},
+
+	{Key: keyReviewsSort, Kind: KindString, Default: "weighted", Allowed: ReviewSorts, Description: "order of `pr reviews`"},
+	{Key: keyReviewsSLA, Kind: KindDuration, Default: "24h", Description: "review requests waiting longer are highlighted; 0s disables"},
+	{Key: keyReviewsWeights + ".age", Kind: KindFloat, Default: 1.0, Description: "weighted sort: per day waiting"},
+	{Key: keyReviewsWeights + ".size", Kind: KindFloat, Default: 1.0, Description: "weighted sort: per order of magnitude of changed lines (subtracted)"},
+	{Key: keyReviewsWeights + ".ci", Kind: KindFloat, Default: 1.0, Description: "weighted sort: added for green CI, subtracted for red"},
+	{Key: keyReviewsWeights + ".sla", Kind: KindFloat, Default: 2.0, Description: "weighted sort: added once the SLA is breached"},
+	{Key: keyReviewsWeights + ".draft", Kind: KindFloat, Default: 3.0, Description: "weighted sort: subtracted for drafts"},
+	{Key: keyReviewsWeights + ".reviewed", Kind: KindFloat, Default: 1.0, Description: "weighted sort: subtracted when you already reviewed"},
+
+	{Key: keyRedactEnabled, Kind: KindBool, Default: true, Description: "mask secrets and personal data in prompts sent to models"},
+	{Key: keyRedactDisable, Kind: KindList, Description: "built-in detectors to turn off: " + strings.Join(redact.Names(), ", ")},
+	{Key: keyRedactPatterns, Kind: KindList, Description: "extra regular expressions to mask in prompts"},
+	{Key: keyRedactExcludePaths, Kind: KindList, Default: redact.DefaultExcludePaths, Description: "globs of files whose diffs are never sent"},
+	{Key: keyRedactStrict, Kind: KindBool, Default: false, Description: "refuse to send a prompt in which secrets were found"},
+	{Key: keyRedactShow, Kind: KindBool, Default: false, Description: "report what was masked (--show-redactions)"},
+
+	{Key: keyDiffBudget, Kind: KindInt, Default: 16000, Description: "approximate tokens of diff per prompt; larger diffs are packed by relevance, 0 disables"},
+	{Key: keyDiffGenerated, Kind: KindList, Description: "extra globs of generated files, ranked below hand-written code"},
+	{Key: keyDiffSummarize, Kind: KindBool, Default: true, Description: "summarise diffs and review threads over the budget directory by directory instead of packing them"},
+	{Key: keyDiffParallel, Kind: KindInt, Default: 4, Description: "summaries requested at once"},
+	{Key: keyDiffCache, Kind: KindBool, Default: true, Description: "reuse summaries of unchanged directories and threads between runs"},
+
+	{Key: keyStandupRepos, Kind: KindList, Description: "repositories `standup` collects your commits from (default: the current one)"},
+
+	{Key: keyWorktreesDir, Kind: KindString, Description: "base directory of PR worktrees (default: next to the checkout)"},
+
+	{Key: keyTitlePattern, Kind: KindRegex, Description: "regex PR titles must match instead of the type/scope rules"},
+	{Key: keyTitleTypes, Kind: KindList, Default: titlelint.DefaultRules().Types, Description: "allowed conventional-commit types"},
+	{Key: keyTitleScope, Kind: KindString, Default: titlelint.ScopeTicket, Allowed: titlelint.Scopes, Description: "scope rule of PR titles"},
+	{Key: keyTitleTicketPattern, Kind: KindRegex, Default: titlelint.DefaultRules().TicketPattern, Description: "regex of ticket keys"},
+	{Key: keyTitleMaxLength, Kind: KindInt, Default: titlelint.DefaultRules().MaxLength, Description: "maximum PR title length; 0 disables"},
+	{Key: keyTitleEnforce, Kind: KindBool, Default: false, Description: "reject PR titles that are still invalid after the fix was offered"},
+}
+
+// Lookup returns the schema field of key.
+func Lookup(key string) (Field, bool) {
+	i := slices.IndexFunc(Schema, func(f Field) bool { return f.Key == key })
+	if i < 0 {
+		return Field{}, false
+	}
+	return Schema[i], true
+}
+
+// isSection reports whether key is a prefix of known keys ("reviews.weights").
+func isSection(key string) bool {
+	return slices.ContainsFunc(Schema, func(f Field) bool { return strings.HasPrefix(f.Key, key+".") })
+}
+
+// UnknownKeyError is returned for keys missing from the schema.
+type UnknownKeyError struct {
+	Key        string
+	Suggestion string
+}
+
+func (e *UnknownKeyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown config key %q (did you mean %q?)", e.Key, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown config key %q", e.Key)
+}
+
+func unknownKey(key string) error {
+	return &UnknownKeyError{Key: key, Suggestion: Suggest(key)}
+}
+
+// Parse converts a command-line or env value of the field.
+func (f Field) Parse(raw string) (any, error) {
+	raw = strings.TrimSpace(raw)
+	var val any
+	switch f.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", f.Key, raw)
+		}
+		val = n
+	case KindFloat:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", f.Key, raw)
+		}
+		val = x
+	case KindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not true or false", f.Key, raw)
+		}
+		val = b
+	case KindDuration:
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, fmt.Errorf("%s: %q is not a duration such as 24h or 90m", f.Key, raw)
+		}
+		val = raw
+	case KindList:
+		var list []string
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+		val = list
+	case KindRegex:
+		if _, err := regexp.Compile(raw); err != nil {
+			return nil, fmt.Errorf("%s: invalid regular expression: %w", f.Key, err)
+		}
+		val = raw
+	default:
+		val = raw
+	}
+	if len(f.Allowed) > 0 && !slices.Contains(f.Allowed, raw) {
+		return nil, fmt.Errorf("%s: invalid value %q (want one of: %s)", f.Key, raw, strings.Join(f.Allowed, ", "))
+	}
+	return val, nil
+}
+
+// Validate checks a value read from a config file.
+func (f Field) Validate(v any) error {
+	if list, ok := v.([]any); ok {
+		if f.Kind != KindList {
+			return fmt.Errorf("%s: expected %s, got a list", f.Key, f.Kind)
+		}
+		parts := make([]string, len(list))
+		for i, x := range list {
+			parts[i] = fmt.Sprint(x)
+		}
+		v = strings.Join(parts, ",")
+	}
+	if _, ok := v.(map[string]any); ok {
+		return fmt.Errorf("%s: expected %s, got a section", f.Key, f.Kind)
+	}
+	_, err := f.Parse(fmt.Sprint(v))
+	return err
+}
+
+// Suggest returns the known key closest to key, or "" when none is close.
+func Suggest(key string) string {
+	key = strings.ToLower(key)
+	best, bestDist := "", 0
+	for _, k := range knownKeys() {
+		d := editDistance(key, k)
+		// also compare against the last segment: "sla" -> reviews.sla
+		if i := strings.LastIndex(k, "."); i >= 0 {
+			if ds := editDistance(key, k[i+1:]); ds < d {
+				d = ds
+			}
+		}
+		if best == "" || d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if bestDist > max(2, len(key)/3) {
+		return ""
+	}
+	return best
+}
+
+// knownKeys returns the schema keys and their sections ("title").
+func knownKeys() []string {
+	var keys []string
+	for _, f := range Schema {
+		parts := strings.Split(f.Key, ".")
+		for i := 1; i < len(parts); i++ {
+			if section := strings.Join(parts[:i], "."); !slices.Contains(keys, section) {
+				keys = append(keys, section)
+			}
+		}
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,21 @@
+package config
+
+import "strings"
+
+const keyStandupRepos = "standup.repos"
+
+// GetStandupRepos returns the repositories `noji standup` collects commits
+// from, with a leading ~ expanded.
+func GetStandupRepos() ([]string, error) {
+	c, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, r := range c.Standup.Repos {
+		if r = strings.TrimSpace(r); r != "" {
+			dirs = append(dirs, expandHome(r))
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// FilePath returns the user config file, or the repository's .noji.yaml
+// when repo is set.
+func FilePath(repo bool) (string, error) {
+	if !repo {
+		cfg, _, err := EnsureConfig()
+		return cfg, err
+	}
+	root := RepoRoot()
+	if root == "" {
+		return "", errors.New("not inside a git repository")
+	}
+	return filepath.Join(root, RepoConfigFile), nil
+}
+
+// Set validates raw against the schema and writes key to the user config,
+// or to the repository's .noji.yaml when repo is set.
+func Set(key, raw string, repo bool) error {
+	f, ok := Lookup(key)
+	if !ok {
+		return unknownKey(key)
+	}
+	if repo && !RepoAllowed(key) {
+		return fmt.Errorf("%s can only be set in the user config, not in %s", key, RepoConfigFile)
+	}
+	val, err := f.Parse(raw)
+	if err != nil {
+		return err
+	}
+	path, err := FilePath(repo)
+	if err != nil {
+		return err
+	}
+	doc, err := readFileNode(path)
+	if err != nil {
+		return err
+	}
+	if err := setNode(doc.Content[0], strings.Split(key, "."), val); err != nil {
+		return err
+	}
+	return writeFileNode(path, doc)
+}
+
+// Unset removes key from the user config or the repository's .noji.yaml.
+// It reports whether the key was present.
+func Unset(key string, repo bool) (bool, error) {
+	if _, ok := Lookup(key); !ok && !isSection(key) {
+		return false, unknownKey(key)
+	}
+	path, err := FilePath(repo)
+	if err != nil {
+		return false, err
+	}
+	doc, err := readFileNode(path)
+	if err != nil {
+		return false, err
+	}
+	if !deleteNode(doc.Content[0], strings.Split(key, ".")) {
+		return false, nil
+	}
+	return true, writeFileNode(path, doc)
+}
+
+// Get returns the effective value of key.
+func Get(key string) (Setting, error) {
+	if _, ok := Lookup(key); !ok {
+		return Setting{}, unknownKey(key)
+	}
+	settings, err := Effective()
+	if err != nil {
+		return Setting{}, err
+	}
+	for _, s := range settings {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+	return Setting{Key: key}, nil
+}
+
+// Problem is an unknown key or invalid value in a config file or a NOJI_*
+// variable.
+type Problem struct {
+	File    string `json:"file"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// Check validates the user config, the repository's .noji.yaml and the
+// NOJI_* environment variables.
+func Check() ([]Problem, error) {
+	var problems []Problem
+	user, err := FilePath(false)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{user}
+	if repo := RepoConfigPath(); repo != "" {
+		files = append(files, repo)
+	}
+	for _, path := range files {
+		ps, err := CheckFile(path)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, ps...)
+	}
+	_, envProblems := envValues()
+	problems = append(problems, envProblems...)
+	if prob, err := profileProblem(); err != nil {
+		return nil, err
+	} else if prob != nil {
+		problems = append(problems, *prob)
+	}
+	return problems, nil
+}
+
+// CheckFile validates one config file against the schema. A file that is
+// not valid YAML is returned as an error. In a repository's .noji.yaml, keys
+// outside the allowed sections (see RepoAllowed) are reported as ignored.
+func CheckFile(path string) ([]Problem, error) {
+	m, err := readFileMap(path)
+	if err != nil {
+		return nil, err
+	}
+	repoFile := filepath.Base(path) == RepoConfigFile
+	var problems []Problem
+	report := func(key, format string, a ...any) {
+		problems = append(problems, Problem{File: path, Key: key, Message: fmt.Sprintf(format, a...)})
+	}
+	// walk checks m; display is the key prefix in the file, prefix the
+	// schema key prefix (they differ inside profiles).
+	var walk func(display, prefix string, m map[string]any)
+	walk = func(display, prefix string, m map[string]any) {
+		for _, k := range sortedKeys(m) {
+			key, shown, v := prefix+k, display+k, m[k]
+			if f, ok := Lookup(key); ok {
+				if repoFile && !RepoAllowed(key) {
+					report(shown, "%s can only be set in the user config; ignored", shown)
+					continue
+				}
+				if err := f.Validate(v); err != nil {
+					report(shown, "%s", strings.Replace(err.Error(), key, shown, 1))
+				}
+				continue
+			}
+			if isSection(key) {
+				if sub, ok := v.(map[string]any); ok {
+					walk(shown+".", key+".", sub)
+				} else {
+					report(shown, "%s is a section, not a value", shown)
+				}
+				continue
+			}
+			if secret := secretKeyIn(shown, v); secret != "" {
+				report(secret, "%s looks like a credential; store it with `noji auth login` instead of a config file", secret)
+				continue
+			}
+			err := &UnknownKeyError{Key: shown, Suggestion: Suggest(key)}
+			if err.Suggestion != "" {
+				err.Suggestion = strings.TrimSuffix(display, prefix) + err.Suggestion
+			}
+			report(shown, "%s", err)
+		}
+	}
+	for _, k := range sortedKeys(m) {
+		if k != profilesKey {
+			walk("", "", map[string]any{k: m[k]})
+			continue
+		}
+		if repoFile {
+			report(k, "%s can only be defined in the user config; ignored", k)
+			continue
+		}
+		profiles, ok := m[k].(map[string]any)
+		if !ok {
+			report(k, "%s must map profile names to settings", k)
+			continue
+		}
+		for _, name := range sortedKeys(profiles) {
+			display := profilesKey + "." + name + "."
+			settings, ok := profiles[name].(map[string]any)
+			if !ok {
+				report(display+name, "profile %q must be a section of settings", name)
+				continue
+			}
+			if match, ok := settings[matchKey]; ok {
+				if toStrings(match) == nil {
+					report(display+matchKey, "%s%s must be a list of remote patterns", display, matchKey)
+				}
+				settings = maps.Clone(settings)
+				delete(settings, matchKey)
+			}
+			if _, ok := settings[keyProfile]; ok {
+				report(display+keyProfile, "a profile cannot select another profile")
+				delete(settings, keyProfile)
+			}
+			walk(display, "", settings)
+		}
+	}
+	return problems, nil
+}
+
+// secretKeyIn returns key, or the first key below it, that looks like a
+// credential ("jira.api_token"), or "".
+func secretKeyIn(key string, v any) string {
+	if secrets.IsSecretKey(key) {
+		return key
+	}
+	if m, ok := v.(map[string]any); ok {
+		for _, k := range sortedKeys(m) {
+			if found := secretKeyIn(key+"."+k, m[k]); found != "" {
+				return found
+			}
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// readFileMap reads a YAML config file; a missing file is empty.
+func readFileMap(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if m == nil {
+		m = map[string]any{}
+	}
+	return m, nil
+}
+
+// readFileNode reads a YAML config file as a document whose content is a
+// mapping. Set and Unset edit the nodes so comments and key order survive;
+// a missing or empty file is an empty mapping.
+func readFileNode(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var parsed yaml.Node
+	if err := yaml.Unmarshal(b, &parsed); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if parsed.Kind == 0 {
+		// yaml.v3 drops a file of only comments; keep them on top
+		doc.HeadComment = strings.TrimSpace(string(b))
+		return doc, nil
+	}
+	if root := parsed.Content[0]; root.Kind != yaml.MappingNode {
+		if root.Tag == "!!null" {
+			parsed.Content[0] = doc.Content[0]
+			return &parsed, nil
+		}
+		return nil, fmt.Errorf("parse %s: expected a mapping of settings", path)
+	}
+	return &parsed, nil
+}
+
+// writeFileNode writes doc; an empty mapping leaves only the comments on top
+// of the file, if any.
+func writeFileNode(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	if len(doc.Content[0].Content) == 0 {
+		if doc.HeadComment != "" {
+			buf.WriteString(doc.HeadComment + "\n")
+		}
+	} else {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// mappingValue returns the index of key's value in the mapping m, or -1.
+func mappingValue(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// setNode sets path in the mapping m to val, creating sections as needed.
+// A replaced value keeps its comments.
+func setNode(m *yaml.Node, path []string, val any) error {
+	for i, key := range path {
+		j := mappingValue(m, key)
+		if j < 0 {
+			m.Content = append(m.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+			j = len(m.Content) - 1
+		}
+		old := m.Content[j]
+		if i < len(path)-1 {
+			if old.Kind != yaml.MappingNode {
+				m.Content[j] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: old.HeadComment, LineComment: old.LineComment}
+			}
+			m = m.Content[j]
+			continue
+		}
+		var v yaml.Node
+		if err := v.Encode(val); err != nil {
+			return err
+		}
+		v.HeadComment, v.LineComment, v.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		m.Content[j] = &v
+	}
+	return nil
+}
+
+// deleteNode removes path from the mapping m and prunes sections left empty.
+func deleteNode(m *yaml.Node, path []string) bool {
+	j := mappingValue(m, path[0])
+	if j < 0 {
+		return false
+	}
+	if len(path) > 1 {
+		sub := m.Content[j]
+		if sub.Kind != yaml.MappingNode || !deleteNode(sub, path[1:]) {
+			return false
+		}
+		if len(sub.Content) > 0 {
+			return true
+		}
+	}
+	m.Content = slices.Delete(m.Content, j-1, j+1)
+	return true
+}
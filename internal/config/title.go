@@ -0,0 +1,42 @@
+package config
+
+import (
+	"github.com/dennisloska/noji/internal/titlelint"
+)
+
+const (
+	keyTitlePattern       = "title.pattern"
+	keyTitleTypes         = "title.types"
+	keyTitleScope         = "title.scope"
+	keyTitleTicketPattern = "title.ticket_pattern"
+	keyTitleMaxLength     = "title.max_length"
+	keyTitleEnforce       = "title.enforce"
+)
+
+// TitleSettings configures PR title linting.
+type TitleSettings struct {
+	Rules titlelint.Rules
+	// Enforce makes pr create/update/edit title fail when the title is
+	// still invalid after the corrected title was offered.
+	Enforce bool
+}
+
+// GetTitleSettings reads the title section of the effective config.
+func GetTitleSettings() (TitleSettings, error) {
+	c, err := Load()
+	if err != nil {
+		return TitleSettings{}, err
+	}
+	t := c.Title
+	s := TitleSettings{
+		Rules: titlelint.Rules{
+			Pattern:       t.Pattern,
+			Types:         t.Types,
+			Scope:         t.Scope,
+			TicketPattern: t.TicketPattern,
+			MaxLength:     t.MaxLength,
+		},
+		Enforce: t.Enforce,
+	}
+	return s, s.Rules.Validate()
+}
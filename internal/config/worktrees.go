@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const keyWorktreesDir = "worktrees.dir"
+
+// GetWorktreesDir returns the configured base directory for PR worktrees
+// with a leading ~ expanded, or "" when unset.
+func GetWorktreesDir() (string, error) {
+	c, err := Load()
+	if err != nil {
+		return "", err
+	}
+	return expandHome(strings.TrimSpace(c.Worktrees.Dir)), nil
+}
+
+// expandHome expands a leading ~ to the home directory.
+func expandHome(dir string) string {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dir
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~"))
+}
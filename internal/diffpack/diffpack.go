@@ -0,0 +1,365 @@
+// Package diffpack fits a git diff into a token budget for model prompts.
+// Files are ranked by relevance (hand-written before generated files and
+// lockfiles, most changed first); what does not fit is kept as name + stat
+// so the model still knows the whole change set.
+package diffpack
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// File is the diff of one file, split into its header and hunks.
+type File struct {
+	Path    string
+	Header  string
+	Hunks   []string
+	Added   int
+	Deleted int
+}
+
+// Text returns the diff of the file.
+func (f File) Text() string {
+	return f.Header + strings.Join(f.Hunks, "")
+}
+
+// Parse splits a unified git diff into files and hunks.
+func Parse(diff string) []File {
+	var files []File
+	var cur *File
+	var hunk strings.Builder
+	flush := func() {
+		if cur != nil && hunk.Len() > 0 {
+			cur.Hunks = append(cur.Hunks, hunk.String())
+			hunk.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			files = append(files, File{})
+			cur = &files[len(files)-1]
+			cur.Header = line
+		case cur == nil:
+			continue
+		case len(cur.Hunks) == 0 && hunk.Len() == 0 && !strings.HasPrefix(line, "@@"):
+			cur.Header += line
+			if p, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "+++ b/"); ok {
+				cur.Path = p
+			} else if p, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "--- a/"); ok && cur.Path == "" {
+				cur.Path = p
+			}
+		default:
+			if strings.HasPrefix(line, "@@") {
+				flush()
+			} else if strings.HasPrefix(line, "+") {
+				cur.Added++
+			} else if strings.HasPrefix(line, "-") {
+				cur.Deleted++
+			}
+			hunk.WriteString(line)
+		}
+	}
+	flush()
+	for i := range files {
+		if files[i].Path == "" {
+			// binary files and pure renames have no ---/+++ lines
+			files[i].Path = headerPath(files[i].Header)
+		}
+	}
+	return files
+}
+
+func headerPath(header string) string {
+	first, _, _ := strings.Cut(header, "\n")
+	rest := strings.TrimPrefix(first, "diff --git ")
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return rest
+}
+
+// Tokens estimates the model tokens of s (about four bytes per token).
+func Tokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// Kinds of files, most relevant first.
+const (
+	KindSource    = "source"
+	KindGenerated = "generated"
+	KindLockfile  = "lockfile"
+)
+
+// Lockfiles are dependency lock files, matched by name.
+var Lockfiles = []string{"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+	"bun.lockb", "Cargo.lock", "Gemfile.lock", "composer.lock", "poetry.lock", "Pipfile.lock", "uv.lock", "mix.lock",
+	"Podfile.lock", "packages.lock.json", "flake.lock"}
+
+// GeneratedGlobs match generated or vendored files. A glob without a slash
+// matches the file name; "dir/*" matches everything below dir.
+var GeneratedGlobs = []string{"*.pb.go", "*_gen.go", "*.gen.go", "*_generated.go", "zz_generated*", "*.generated.*",
+	"*.min.js", "*.min.css", "*.map", "*.snap", "vendor/*", "dist/*", "node_modules/*"}
+
+// Options configure packing.
+type Options struct {
+	// Budget is the token budget of the diff; 0 or less means unlimited.
+	Budget int
+	// Generated are extra globs of generated files.
+	Generated []string
+}
+
+func matchGlob(glob, file string) bool {
+	if ok, _ := path.Match(glob, file); ok {
+		return true
+	}
+	if dir, ok := strings.CutSuffix(glob, "/*"); ok {
+		return strings.HasPrefix(file, dir+"/") || strings.Contains(file, "/"+dir+"/")
+	}
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(file))
+		return ok
+	}
+	return false
+}
+
+// Kind classifies f.
+func (o Options) Kind(f File) string {
+	if slices.Contains(Lockfiles, path.Base(f.Path)) {
+		return KindLockfile
+	}
+	for _, g := range append(slices.Clone(GeneratedGlobs), o.Generated...) {
+		if matchGlob(g, f.Path) {
+			return KindGenerated
+		}
+	}
+	if len(f.Hunks) > 0 {
+		head := f.Hunks[0]
+		if len(head) > 2000 {
+			head = head[:2000]
+		}
+		if strings.Contains(head, "Code generated") && strings.Contains(head, "DO NOT EDIT") {
+			return KindGenerated
+		}
+	}
+	return KindSource
+}
+
+var kindRank = map[string]int{KindSource: 0, KindGenerated: 1, KindLockfile: 2}
+
+// Rank returns the indexes of files, most relevant first.
+func (o Options) Rank(files []File) []int {
+	order := make([]int, len(files))
+	kinds := make([]string, len(files))
+	for i, f := range files {
+		order[i], kinds[i] = i, o.Kind(f)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if d := kindRank[kinds[a]] - kindRank[kinds[b]]; d != 0 {
+			return d
+		}
+		return (files[b].Added + files[b].Deleted) - (files[a].Added + files[a].Deleted)
+	})
+	return order
+}
+
+// Ways a file ends up in a packed diff.
+const (
+	ShownFull    = "full"
+	ShownPartial = "partial" // the first hunks that fit
+	ShownStat    = "stat"    // name and line counts only
+)
+
+// Entry is one file of a packed diff.
+type Entry struct {
+	Path       string `json:"path"`
+	Kind       string `json:"kind"`
+	Shown      string `json:"shown"`
+	Added      int    `json:"added"`
+	Deleted    int    `json:"deleted"`
+	Hunks      int    `json:"hunks"`
+	HunksShown int    `json:"hunks_shown"`
+}
+
+// Stat is the name + stat line of the file.
+func (e Entry) Stat() string {
+	s := fmt.Sprintf("%s | +%d -%d", e.Path, e.Added, e.Deleted)
+	if e.Kind != KindSource {
+		s += " (" + e.Kind + ")"
+	}
+	return s
+}
+
+// Result is a packed diff.
+type Result struct {
+	Text    string  `json:"-"`
+	Tokens  int     `json:"tokens"`
+	Budget  int     `json:"budget"`
+	Entries []Entry `json:"files"`
+}
+
+// LeftOut returns the files that are not shown in full.
+func (r Result) LeftOut() []Entry {
+	var out []Entry
+	for _, e := range r.Entries {
+		if e.Shown != ShownFull {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Report describes what was left out, or "" when everything fits.
+func (r Result) Report() string {
+	left := r.LeftOut()
+	if len(left) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, e := range left {
+		if e.Shown == ShownPartial {
+			parts = append(parts, fmt.Sprintf("%s (%d of %d hunks)", e.Path, e.HunksShown, e.Hunks))
+		} else {
+			parts = append(parts, e.Path+" (name + stat)")
+		}
+	}
+	return fmt.Sprintf("%d of %d files over the %d-token diff budget: %s",
+		len(left), len(r.Entries), r.Budget, strings.Join(parts, ", "))
+}
+
+const statHeader = "[noji: files over the diff budget, name and stat only]\n"
+
+func entryOf(o Options, f File) Entry {
+	return Entry{Path: f.Path, Kind: o.Kind(f), Added: f.Added, Deleted: f.Deleted, Hunks: len(f.Hunks)}
+}
+
+func omittedNote(f File, shown int) string {
+	n, s := len(f.Hunks)-shown, "s"
+	if n == 1 {
+		s = ""
+	}
+	return fmt.Sprintf("[noji: %d more hunk%s of %s omitted]\n", n, s, f.Path)
+}
+
+// Pack fits files into the budget. Files are taken in Rank order: whole when
+// they fit, else their first hunks, else as name + stat in a list after the
+// diff. Shown files keep their order in the diff.
+func Pack(files []File, o Options) Result {
+	res := Result{Budget: o.Budget, Entries: make([]Entry, len(files))}
+	texts := make([]string, len(files))
+	for i, f := range files {
+		res.Entries[i] = entryOf(o, f)
+	}
+	if o.Budget <= 0 {
+		for i, f := range files {
+			res.Entries[i].Shown, res.Entries[i].HunksShown = ShownFull, len(f.Hunks)
+			texts[i] = f.Text()
+		}
+		return finish(res, texts)
+	}
+	// room for every file's stat line stays reserved until the file is shown
+	reserve := Tokens(statHeader)
+	for _, e := range res.Entries {
+		reserve += Tokens(e.Stat() + "\n")
+	}
+	used := 0
+	for _, i := range o.Rank(files) {
+		f, e := files[i], &res.Entries[i]
+		stat := Tokens(e.Stat() + "\n")
+		avail := o.Budget - used - (reserve - stat)
+		if t := Tokens(f.Text()); t <= avail {
+			e.Shown, e.HunksShown, texts[i] = ShownFull, len(f.Hunks), f.Text()
+			used, reserve = used+t, reserve-stat
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(f.Header)
+		shown := 0
+		for _, h := range f.Hunks {
+			if Tokens(b.String()+h+omittedNote(f, shown+1)) > avail {
+				break
+			}
+			b.WriteString(h)
+			shown++
+		}
+		if shown == 0 {
+			e.Shown = ShownStat
+			continue
+		}
+		b.WriteString(omittedNote(f, shown))
+		e.Shown, e.HunksShown, texts[i] = ShownPartial, shown, b.String()
+		used, reserve = used+Tokens(b.String()), reserve-stat
+	}
+	return finish(res, texts)
+}
+
+func finish(res Result, texts []string) Result {
+	var b strings.Builder
+	for _, t := range texts {
+		b.WriteString(t)
+	}
+	var stats []string
+	for _, e := range res.Entries {
+		if e.Shown == ShownStat {
+			stats = append(stats, e.Stat()+"\n")
+		}
+	}
+	if len(stats) > 0 {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(statHeader)
+		b.WriteString(strings.Join(stats, ""))
+	}
+	res.Text = b.String()
+	res.Tokens = Tokens(res.Text)
+	return res
+}
+
+// Split groups the hand-written files into parts of at most the budget each,
+// for callers that send a large diff in several requests. A file larger than
+// the budget is split between hunks, repeating its header. Generated files
+// and lockfiles are not split into parts; they are returned as skipped.
+func Split(files []File, o Options) (parts []string, skipped []Entry) {
+	var b strings.Builder
+	emit := func() {
+		if b.Len() > 0 {
+			parts = append(parts, b.String())
+			b.Reset()
+		}
+	}
+	fits := func(s string) bool {
+		return o.Budget <= 0 || Tokens(b.String()+s) <= o.Budget
+	}
+	for _, i := range o.Rank(files) {
+		f := files[i]
+		if kind := o.Kind(f); kind != KindSource {
+			e := entryOf(o, f)
+			e.Shown = ShownStat
+			skipped = append(skipped, e)
+			continue
+		}
+		if whole := f.Text(); o.Budget <= 0 || Tokens(whole) <= o.Budget {
+			if !fits(whole) {
+				emit()
+			}
+			b.WriteString(whole)
+			continue
+		}
+		emit()
+		b.WriteString(f.Header)
+		for _, h := range f.Hunks {
+			if !fits(h) && b.Len() > len(f.Header) {
+				emit()
+				b.WriteString(f.Header)
+			}
+			b.WriteString(h)
+		}
+		emit()
+	}
+	emit()
+	return parts, skipped
+}
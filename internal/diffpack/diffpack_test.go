@@ -0,0 +1,155 @@
+package diffpack
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fileDiff returns the diff of a file with one hunk per entry of hunks, each
+// adding that many lines.
+func fileDiff(path string, hunks ...int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\nindex 1..2 100644\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	for i, n := range hunks {
+		fmt.Fprintf(&b, "@@ -%d,1 +%d,%d @@\n context\n", i*100+1, i*100+1, n+1)
+		for j := range n {
+			fmt.Fprintf(&b, "+line %d of hunk %d in %s\n", j, i, path)
+		}
+	}
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	diff := "preamble\n" + fileDiff("a.go", 2, 1) +
+		"diff --git a/old.go b/new.go\nsimilarity index 100%\nrename from old.go\nrename to new.go\n" +
+		"diff --git a/img.png b/img.png\nBinary files a/img.png and b/img.png differ\n" +
+		"diff --git a/gone.go b/gone.go\ndeleted file mode 100644\n--- a/gone.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n"
+	files := Parse(diff)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"a.go", "new.go", "img.png", "gone.go"}; !slices.Equal(paths, want) {
+		t.Fatalf("paths = %q, want %q", paths, want)
+	}
+	a := files[0]
+	if len(a.Hunks) != 2 || a.Added != 3 || a.Deleted != 0 || !strings.HasSuffix(a.Header, "+++ b/a.go\n") {
+		t.Errorf("a.go = %+v", a)
+	}
+	if a.Text() != fileDiff("a.go", 2, 1) {
+		t.Error("Text does not reproduce the diff of a.go")
+	}
+	if gone := files[3]; gone.Deleted != 1 || len(gone.Hunks) != 1 {
+		t.Errorf("gone.go = %+v", gone)
+	}
+	if len(Parse("")) != 0 {
+		t.Error("an empty diff has files")
+	}
+}
+
+func TestTokens(t *testing.T) {
+	for s, want := range map[string]int{"": 0, "a": 1, "abcd": 1, "abcde": 2} {
+		if got := Tokens(s); got != want {
+			t.Errorf("Tokens(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestKind(t *testing.T) {
+	o := Options{Generated: []string{"api/*.ts"}}
+	for p, want := range map[string]string{
+		"main.go":                  KindSource,
+		"web/package-lock.json":    KindLockfile,
+		"go.sum":                   KindLockfile,
+		"proto/x.pb.go":            KindGenerated,
+		"vendor/github.com/x/y.go": KindGenerated,
+		"web/node_modules/a/b.js":  KindGenerated,
+		"api/client.ts":            KindGenerated,
+		"src/api/client.ts":        KindSource,
+	} {
+		if got := o.Kind(File{Path: p}); got != want {
+			t.Errorf("Kind(%s) = %s, want %s", p, got, want)
+		}
+	}
+	marked := File{Path: "x.go", Hunks: []string{"@@ -0,0 +1 @@\n+// Code generated by stringer. DO NOT EDIT.\n"}}
+	if got := o.Kind(marked); got != KindGenerated {
+		t.Errorf("a file with a generated marker is %s", got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	files := Parse(fileDiff("go.sum", 50) + fileDiff("small.go", 1) + fileDiff("x.pb.go", 40) + fileDiff("big.go", 10))
+	var got []string
+	for _, i := range (Options{}).Rank(files) {
+		got = append(got, files[i].Path)
+	}
+	if want := []string{"big.go", "small.go", "x.pb.go", "go.sum"}; !slices.Equal(got, want) {
+		t.Errorf("Rank = %q, want %q", got, want)
+	}
+}
+
+func TestPackUnlimited(t *testing.T) {
+	diff := fileDiff("a.go", 3) + fileDiff("go.sum", 3)
+	res := Pack(Parse(diff), Options{})
+	if res.Text != diff || len(res.LeftOut()) != 0 || res.Report() != "" {
+		t.Errorf("unlimited pack changed the diff: %+v", res)
+	}
+}
+
+func TestPackBudget(t *testing.T) {
+	files := Parse(fileDiff("big.go", 30, 30, 30) + fileDiff("small.go", 2) + fileDiff("go.sum", 40))
+	budget := Tokens(files[1].Text()) + Tokens(files[0].Header+files[0].Hunks[0]) + 80
+	res := Pack(files, Options{Budget: budget})
+	if res.Tokens > budget {
+		t.Errorf("packed %d tokens over the budget of %d", res.Tokens, budget)
+	}
+	shown := map[string]string{}
+	for _, e := range res.Entries {
+		shown[e.Path] = e.Shown
+	}
+	if shown["small.go"] != ShownFull || shown["big.go"] != ShownPartial || shown["go.sum"] != ShownStat {
+		t.Errorf("shown = %v", shown)
+	}
+	// shown files keep their order in the diff, stats come last
+	big, small, stats := strings.Index(res.Text, "b/big.go"), strings.Index(res.Text, "b/small.go"), strings.Index(res.Text, statHeader)
+	if big < 0 || small < big || stats < small || !strings.Contains(res.Text, "go.sum | +40 -0 (lockfile)") {
+		t.Errorf("packed text:\n%s", res.Text)
+	}
+	if !strings.Contains(res.Text, "more hunks of big.go omitted]") {
+		t.Error("no note for the omitted hunks")
+	}
+	if r := res.Report(); !strings.Contains(r, "2 of 3 files") || !strings.Contains(r, "go.sum (name + stat)") {
+		t.Errorf("Report = %q", r)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	files := Parse(fileDiff("a.go", 5) + fileDiff("b.go", 5) + fileDiff("huge.go", 20, 20, 20) + fileDiff("yarn.lock", 5))
+	budget := Tokens(files[2].Header+files[2].Hunks[0]) + 5
+	parts, skipped := Split(files, Options{Budget: budget})
+	if len(skipped) != 1 || skipped[0].Path != "yarn.lock" {
+		t.Errorf("skipped = %+v", skipped)
+	}
+	var joined strings.Builder
+	for _, p := range parts {
+		if Tokens(p) > budget {
+			t.Errorf("part of %d tokens over the budget of %d", Tokens(p), budget)
+		}
+		joined.WriteString(p)
+	}
+	for _, f := range files[:3] {
+		for _, h := range f.Hunks {
+			if strings.Count(joined.String(), h) != 1 {
+				t.Errorf("a hunk of %s is not in exactly one part", f.Path)
+			}
+		}
+	}
+	if n := strings.Count(joined.String(), "+++ b/huge.go"); n != 3 {
+		t.Errorf("huge.go header repeated %d times, want once per part", n)
+	}
+	if parts, _ := Split(files, Options{}); len(parts) != 1 {
+		t.Errorf("unlimited Split gave %d parts", len(parts))
+	}
+}
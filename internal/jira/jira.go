@@ -0,0 +1,185 @@
+// Package jira reads issues from the Jira REST API. noji otherwise reaches
+// Jira through the model's MCP server; this is for facts that must be
+// gathered without a model, such as status transitions.
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Client talks to one Jira site.
+type Client struct {
+	// BaseURL is the site, e.g. https://acme.atlassian.net.
+	BaseURL string
+	// User is the account email for Jira Cloud API tokens; empty sends the
+	// token as a bearer token (Jira Data Center personal access tokens).
+	User  string
+	Token string
+	HTTP  *http.Client
+}
+
+// BaseFromBrowseURL turns a tracker URL such as
+// https://acme.atlassian.net/browse into the site URL.
+func BaseFromBrowseURL(browse string) string {
+	browse = strings.TrimRight(strings.TrimSpace(browse), "/")
+	return strings.TrimSuffix(browse, "/browse")
+}
+
+// Transition is a status change of an issue.
+type Transition struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	Author string    `json:"author,omitempty"`
+}
+
+// Issue is the part of an issue noji uses.
+type Issue struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
+	Status  string `json:"status"`
+	// Transitions are the status changes since the time asked for, oldest
+	// first.
+	Transitions []Transition `json:"transitions,omitempty"`
+}
+
+// ErrNotFound is returned for issues that do not exist or are not visible.
+var ErrNotFound = errors.New("issue not found")
+
+// history is one entry of an issue's changelog.
+type history struct {
+	Created string `json:"created"`
+	Author  struct {
+		DisplayName string `json:"displayName"`
+	} `json:"author"`
+	Items []struct {
+		Field      string `json:"field"`
+		FromString string `json:"fromString"`
+		ToString   string `json:"toString"`
+	} `json:"items"`
+}
+
+type issueResponse struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name string `json:"name"`
+		} `json:"status"`
+	} `json:"fields"`
+	Changelog struct {
+		Total     int       `json:"total"`
+		Histories []history `json:"histories"`
+	} `json:"changelog"`
+}
+
+// changelogPage is one page of /rest/api/2/issue/{key}/changelog.
+type changelogPage struct {
+	StartAt int       `json:"startAt"`
+	Total   int       `json:"total"`
+	IsLast  bool      `json:"isLast"`
+	Values  []history `json:"values"`
+}
+
+// timeLayout is Jira's timestamp format, e.g. 2024-05-02T10:11:12.000+0000.
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// changelogPageSize is the page size asked for; Jira may return fewer.
+const changelogPageSize = 100
+
+// Issue returns key with its status transitions since since. The changelog
+// embedded in the issue is cut off after 100 entries on Jira Cloud; longer
+// ones are read again through the paginated changelog endpoint.
+func (c *Client) Issue(key string, since time.Time) (*Issue, error) {
+	var r issueResponse
+	if err := c.get(key, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=summary,status&expand=changelog", &r); err != nil {
+		return nil, err
+	}
+	histories := r.Changelog.Histories
+	if r.Changelog.Total > len(histories) {
+		var err error
+		if histories, err = c.changelog(key); err != nil {
+			return nil, err
+		}
+	}
+	issue := &Issue{Key: r.Key, Summary: r.Fields.Summary, Status: r.Fields.Status.Name}
+	for _, h := range histories {
+		at, err := time.Parse(timeLayout, h.Created)
+		if err != nil || at.Before(since) {
+			continue
+		}
+		for _, it := range h.Items {
+			if it.Field == "status" {
+				issue.Transitions = append(issue.Transitions, Transition{From: it.FromString, To: it.ToString, At: at, Author: h.Author.DisplayName})
+			}
+		}
+	}
+	// the changelog order differs between Jira versions
+	slices.SortStableFunc(issue.Transitions, func(a, b Transition) int { return a.At.Compare(b.At) })
+	return issue, nil
+}
+
+// changelog reads the whole changelog of key page by page.
+func (c *Client) changelog(key string) ([]history, error) {
+	var all []history
+	for {
+		var page changelogPage
+		path := fmt.Sprintf("/rest/api/2/issue/%s/changelog?startAt=%d&maxResults=%d", url.PathEscape(key), len(all), changelogPageSize)
+		if err := c.get(key, path, &page); err != nil {
+			return nil, fmt.Errorf("changelog: %w", err)
+		}
+		all = append(all, page.Values...)
+		if page.IsLast || len(page.Values) == 0 || len(all) >= page.Total {
+			return all, nil
+		}
+	}
+}
+
+// get reads path on the site into v. key names the issue in errors.
+func (c *Client) get(key, path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		msg := strings.Join(strings.Fields(string(body)), " ")
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("jira %s: %s: %s", key, resp.Status, msg)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parse jira issue %s: %w", key, err)
+	}
+	return nil
+}
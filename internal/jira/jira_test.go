@@ -0,0 +1,120 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const issueJSON = `{
+  "key": "ABC-12",
+  "fields": {"summary": "Retry uploads", "status": {"name": "In Review"}},
+  "changelog": {"total": 3, "histories": [
+    {"created": "2024-05-02T15:30:00.000+0000", "author": {"displayName": "Ada"},
+     "items": [{"field": "status", "fromString": "In Progress", "toString": "In Review"}]},
+    {"created": "2024-05-02T09:00:00.000+0000", "author": {"displayName": "Ada"},
+     "items": [{"field": "assignee", "fromString": "", "toString": "Ada"},
+               {"field": "status", "fromString": "To Do", "toString": "In Progress"}]},
+    {"created": "2024-04-20T09:00:00.000+0000", "author": {"displayName": "Bob"},
+     "items": [{"field": "status", "fromString": "Backlog", "toString": "To Do"}]}
+  ]}
+}`
+
+func TestIssue(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/rest/api/2/issue/ABC-12":
+			w.Write([]byte(issueJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	c := &Client{BaseURL: BaseFromBrowseURL(srv.URL + "/browse/"), Token: "pat"}
+	issue, err := c.Issue("ABC-12", since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer pat" {
+		t.Errorf("Authorization = %q, want the bearer token", auth)
+	}
+	if issue.Summary != "Retry uploads" || issue.Status != "In Review" {
+		t.Errorf("issue = %+v", issue)
+	}
+	if len(issue.Transitions) != 2 ||
+		issue.Transitions[0].To != "In Progress" || issue.Transitions[1].To != "In Review" {
+		t.Errorf("transitions = %+v, want the two since %s, oldest first", issue.Transitions, since)
+	}
+
+	c.User = "ada@example.com"
+	if _, err := c.Issue("ABC-12", since); err != nil {
+		t.Fatal(err)
+	}
+	if auth == "" || auth == "Bearer pat" {
+		t.Errorf("Authorization = %q, want basic auth with the user", auth)
+	}
+
+	if _, err := c.Issue("ABC-99", since); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing issue: err = %v, want ErrNotFound", err)
+	}
+}
+
+// Jira Cloud embeds only the first 100 changelog entries in the issue.
+func TestIssueLongChangelog(t *testing.T) {
+	entry := func(i int) map[string]any {
+		at := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Hour)
+		item := map[string]string{"field": "labels"}
+		if i == 240 {
+			item = map[string]string{"field": "status", "fromString": "In Progress", "toString": "Done"}
+		}
+		return map[string]any{"created": at.Format(timeLayout), "items": []any{item}}
+	}
+	const total = 250
+	var starts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v any
+		switch r.URL.Path {
+		case "/rest/api/2/issue/ABC-12":
+			var histories []any
+			for i := range 100 {
+				histories = append(histories, entry(i))
+			}
+			v = map[string]any{"key": "ABC-12", "fields": map[string]any{"status": map[string]string{"name": "Done"}},
+				"changelog": map[string]any{"total": total, "histories": histories}}
+		case "/rest/api/2/issue/ABC-12/changelog":
+			starts = append(starts, r.URL.Query().Get("startAt"))
+			var start int
+			fmt.Sscan(r.URL.Query().Get("startAt"), &start)
+			var values []any
+			for i := start; i < min(start+100, total); i++ {
+				values = append(values, entry(i))
+			}
+			v = map[string]any{"startAt": start, "total": total, "isLast": start+100 >= total, "values": values}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "pat"}
+	issue, err := c.Issue("ABC-12", time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issue.Transitions) != 1 || issue.Transitions[0].To != "Done" {
+		t.Errorf("transitions = %+v, want the one in the last page", issue.Transitions)
+	}
+	if fmt.Sprint(starts) != "[0 100 200]" {
+		t.Errorf("pages read from %v", starts)
+	}
+}
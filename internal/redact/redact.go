@@ -0,0 +1,296 @@
+// Package redact masks secrets and personal data in prompts before they are
+// sent to a model, and drops diffs of files that should never leave the
+// machine (.env, keys).
+package redact
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Detector finds one kind of secret. When Re has a group named "secret"
+// only that group is masked, so surrounding code stays readable.
+type Detector struct {
+	Name string
+	Re   *regexp.Regexp
+	// PII marks personal data rather than secrets: it is masked, but does
+	// not make redact.strict refuse a prompt.
+	PII bool
+}
+
+// Builtin lists the detectors that are on unless disabled.
+var Builtin = []Detector{
+	{Name: "aws-access-key", Re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-key", Re: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`)},
+	{Name: "github-token", Re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "jwt", Re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{Name: "private-key", Re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY-----|\z)`)},
+	{Name: "password", Re: regexp.MustCompile(`(?i)(?:password|passwd|secret|api_?key|access_?token|auth_?token)["']?\s*[:=]\s*["'](?P<secret>[^"'\s]{8,})["']`)},
+	{Name: "email", Re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`), PII: true},
+}
+
+// Names returns the names of the built-in detectors.
+func Names() []string {
+	names := make([]string, len(Builtin))
+	for i, d := range Builtin {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// Rules configure a Redactor.
+type Rules struct {
+	// Disable lists built-in detectors to turn off, e.g. "email".
+	Disable []string
+	// Patterns are extra regular expressions to mask.
+	Patterns []string
+	// ExcludePaths are globs of files whose diffs are dropped entirely. A
+	// glob without a slash matches the file name in any directory.
+	ExcludePaths []string
+	// Literals are exact secrets to mask, such as the tokens noji holds.
+	Literals []string
+}
+
+// DefaultExcludePaths are files whose changes are never worth sending.
+var DefaultExcludePaths = []string{".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "id_rsa", "id_ed25519", ".npmrc", ".netrc"}
+
+// Redactor masks prompts.
+type Redactor struct {
+	detectors []Detector
+	exclude   []string
+}
+
+// New builds a Redactor; unknown detector names, invalid patterns and
+// invalid globs are errors.
+func New(r Rules) (*Redactor, error) {
+	for _, name := range r.Disable {
+		if !slices.Contains(Names(), name) {
+			return nil, fmt.Errorf("unknown redaction detector %q (want one of: %s)", name, strings.Join(Names(), ", "))
+		}
+	}
+	red := &Redactor{}
+	for _, d := range Builtin {
+		if !slices.Contains(r.Disable, d.Name) {
+			red.detectors = append(red.detectors, d)
+		}
+	}
+	for i, p := range r.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		red.detectors = append(red.detectors, Detector{Name: fmt.Sprintf("pattern-%d", i+1), Re: re})
+	}
+	for _, l := range r.Literals {
+		if l != "" {
+			red.detectors = append(red.detectors, Detector{Name: "stored-token", Re: regexp.MustCompile(regexp.QuoteMeta(l))})
+		}
+	}
+	for _, g := range r.ExcludePaths {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude glob %q: %w", g, err)
+		}
+		red.exclude = append(red.exclude, g)
+	}
+	return red, nil
+}
+
+// Excluded reports whether the diff of file should be dropped.
+func (r *Redactor) Excluded(file string) bool {
+	for _, g := range r.exclude {
+		if ok, _ := path.Match(g, file); ok {
+			return true
+		}
+		if !strings.Contains(g, "/") {
+			if ok, _ := path.Match(g, path.Base(file)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Finding is one masked secret.
+type Finding struct {
+	Detector string `json:"detector"`
+	PII      bool   `json:"pii,omitempty"`
+	// Line is the 1-based line of the prompt.
+	Line int `json:"line"`
+	// Path is the file when the secret was inside a diff.
+	Path string `json:"path,omitempty"`
+}
+
+// Result is a redacted prompt.
+type Result struct {
+	Text     string    `json:"-"`
+	Findings []Finding `json:"findings"`
+	// Omitted lists files whose diffs were dropped.
+	Omitted []string `json:"omitted"`
+}
+
+// Clean reports whether nothing was masked or dropped.
+func (res Result) Clean() bool {
+	return len(res.Findings) == 0 && len(res.Omitted) == 0
+}
+
+// Secrets returns the findings that are secrets, not personal data.
+func (res Result) Secrets() []Finding {
+	var out []Finding
+	for _, f := range res.Findings {
+		if !f.PII {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Summary describes what was masked, e.g.
+// "github-token in config/dev.yaml, email (2), omitted diff of .env".
+func (res Result) Summary() string {
+	counts := map[string]int{}
+	var keys []string
+	for _, f := range res.Findings {
+		key := f.Detector
+		if f.Path != "" {
+			key += " in " + f.Path
+		}
+		if counts[key] == 0 {
+			keys = append(keys, key)
+		}
+		counts[key]++
+	}
+	parts := make([]string, 0, len(keys)+len(res.Omitted))
+	for _, k := range keys {
+		if counts[k] > 1 {
+			k = fmt.Sprintf("%s (%d)", k, counts[k])
+		}
+		parts = append(parts, k)
+	}
+	for _, p := range res.Omitted {
+		parts = append(parts, "omitted diff of "+p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Mask is the replacement of a secret found by detector.
+func Mask(detector string) string {
+	return "[REDACTED:" + detector + "]"
+}
+
+// Apply drops excluded diffs from prompt and masks every detector match.
+func (r *Redactor) Apply(prompt string) Result {
+	var res Result
+	text, paths := r.dropExcluded(prompt, &res)
+	for _, d := range r.detectors {
+		text = r.mask(d, text, paths, &res)
+	}
+	slices.SortStableFunc(res.Findings, func(a, b Finding) int { return a.Line - b.Line })
+	res.Text = text
+	return res
+}
+
+// mask replaces the matches of d and records them. paths maps lines of
+// text to the diff file they belong to.
+func (r *Redactor) mask(d Detector, text string, paths []string, res *Result) string {
+	group := d.Re.SubexpIndex("secret")
+	var b strings.Builder
+	last := 0
+	for _, m := range d.Re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		if group > 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		if start == end || strings.HasPrefix(text[start:], "[REDACTED:") {
+			continue
+		}
+		line := strings.Count(text[:start], "\n")
+		f := Finding{Detector: d.Name, PII: d.PII, Line: line + 1}
+		if line < len(paths) {
+			f.Path = paths[line]
+		}
+		res.Findings = append(res.Findings, f)
+		b.WriteString(text[last:start])
+		b.WriteString(Mask(d.Name))
+		// keep the lines of a multi-line secret (private keys) so line
+		// numbers stay stable and diff lines keep their +/- marker
+		for _, cont := range strings.Split(text[start:end], "\n")[1:] {
+			b.WriteString("\n")
+			if cont != "" && strings.ContainsRune("+- ", rune(cont[0])) {
+				b.WriteByte(cont[0])
+			}
+		}
+		last = end
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// diffBodyPrefixes start the lines that belong to a file in a git diff.
+var diffBodyPrefixes = []string{"+", "-", " ", "@@", "\\", "index ", "new file", "deleted file",
+	"old mode", "new mode", "similarity", "rename ", "copy ", "Binary files"}
+
+func isDiffBody(line string) bool {
+	if line == "" {
+		return false
+	}
+	for _, p := range diffBodyPrefixes {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffPath returns the new path of a "diff --git a/x b/y" header.
+func diffPath(header string) string {
+	rest := strings.TrimPrefix(header, "diff --git ")
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return strings.TrimPrefix(rest, "a/")
+}
+
+// dropExcluded replaces the diffs of excluded files with a note and returns
+// the text with, per line, the diff file the line belongs to.
+func (r *Redactor) dropExcluded(prompt string, res *Result) (string, []string) {
+	lines := strings.Split(prompt, "\n")
+	out := make([]string, 0, len(lines))
+	var paths []string
+	cur, skip := "", false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") {
+			cur = diffPath(line)
+			skip = r.Excluded(cur)
+			out, paths = append(out, line), append(paths, cur)
+			if skip {
+				res.Omitted = append(res.Omitted, cur)
+				out, paths = append(out, "[changes to "+cur+" omitted by noji]"), append(paths, cur)
+			}
+			continue
+		}
+		if cur != "" && !isDiffBody(line) {
+			cur, skip = "", false
+		}
+		if skip {
+			continue
+		}
+		out, paths = append(out, line), append(paths, cur)
+	}
+	return strings.Join(out, "\n"), paths
+}
+
+// StrictError refuses to send a prompt that contained secrets.
+type StrictError struct {
+	Result Result
+}
+
+func (e *StrictError) Error() string {
+	secrets := Result{Findings: e.Result.Secrets()}
+	return fmt.Sprintf("refusing to send the prompt to the model, it contains secrets: %s (redact.strict is on)", secrets.Summary())
+}
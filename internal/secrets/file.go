@@ -0,0 +1,236 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PassphraseEnv holds the passphrase of the encrypted file for
+// non-interactive use.
+const PassphraseEnv = "NOJI_PASSPHRASE"
+
+// ErrPassphraseRequired is returned when the encrypted file must be read
+// but no passphrase can be asked for.
+var ErrPassphraseRequired = errors.New("passphrase required")
+
+const fileVersion = 1
+
+// kdfRounds are the PBKDF2 rounds of a new file; tests lower them.
+var kdfRounds = 600_000
+
+// vault is the encrypted file. Services lists the stored names in clear
+// text so status needs no passphrase; it is authenticated as additional data.
+type vault struct {
+	Version  int      `json:"version"`
+	Services []string `json:"services"`
+	Rounds   int      `json:"rounds"`
+	Salt     []byte   `json:"salt"`
+	Nonce    []byte   `json:"nonce"`
+	Data     []byte   `json:"data"`
+}
+
+// readVault reads path; a missing file is nil.
+func readVault(path string) (*vault, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var v vault
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if v.Version != fileVersion {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, v.Version)
+	}
+	return &v, nil
+}
+
+func fileServices(path string) ([]string, error) {
+	v, err := readVault(path)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.Services, nil
+}
+
+func (s *Store) passphrase(confirm bool) (string, error) {
+	if s.pass != "" {
+		return s.pass, nil
+	}
+	pass := os.Getenv(PassphraseEnv)
+	if pass == "" {
+		if s.Passphrase == nil {
+			return "", fmt.Errorf("%w: %s is encrypted; set %s", ErrPassphraseRequired, s.File, PassphraseEnv)
+		}
+		var err error
+		if pass, err = s.Passphrase(confirm); err != nil {
+			return "", err
+		}
+	}
+	if pass == "" {
+		return "", errors.New("empty passphrase")
+	}
+	s.pass = pass
+	return pass, nil
+}
+
+func aead(pass string, salt []byte, rounds int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, pass, salt, rounds, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// cipher returns the AEAD of pass for salt. Deriving the key is slow on
+// purpose, so the last one is kept for the life of the Store.
+func (s *Store) cipher(pass string, salt []byte, rounds int) (cipher.AEAD, error) {
+	if s.gcm != nil && s.gcmPass == pass && s.gcmRounds == rounds && bytes.Equal(s.gcmSalt, salt) {
+		return s.gcm, nil
+	}
+	gcm, err := aead(pass, salt, rounds)
+	if err != nil {
+		return nil, err
+	}
+	s.gcm, s.gcmPass, s.gcmSalt, s.gcmRounds = gcm, pass, salt, rounds
+	return gcm, nil
+}
+
+func additionalData(services []string) []byte {
+	return []byte(strings.Join(services, ","))
+}
+
+// fileLoad decrypts the file into tokens by service; a missing file is empty.
+func (s *Store) fileLoad(confirm bool) (map[string]string, error) {
+	v, err := readVault(s.File)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return map[string]string{}, nil
+	}
+	pass, err := s.passphrase(confirm)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := s.cipher(pass, v.Salt, v.Rounds)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, v.Nonce, v.Data, additionalData(v.Services))
+	if err != nil {
+		s.pass, s.gcm = "", nil
+		return nil, fmt.Errorf("decrypt %s: wrong passphrase or damaged file", s.File)
+	}
+	tokens := map[string]string{}
+	if err := json.Unmarshal(plain, &tokens); err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", s.File, err)
+	}
+	for _, t := range tokens {
+		remember(t)
+	}
+	return tokens, nil
+}
+
+// fileSave encrypts tokens with a fresh salt and nonce; no tokens removes
+// the file.
+func (s *Store) fileSave(tokens map[string]string) error {
+	if len(tokens) == 0 {
+		if err := os.Remove(s.File); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	pass, err := s.passphrase(true)
+	if err != nil {
+		return err
+	}
+	v := vault{Version: fileVersion, Rounds: kdfRounds, Salt: make([]byte, 16)}
+	for name := range tokens {
+		v.Services = append(v.Services, name)
+	}
+	slices.Sort(v.Services)
+	if _, err := rand.Read(v.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(pass, v.Salt, v.Rounds)
+	if err != nil {
+		return err
+	}
+	v.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(v.Nonce); err != nil {
+		return err
+	}
+	plain, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	v.Data = gcm.Seal(nil, v.Nonce, plain, additionalData(v.Services))
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.File), 0o700); err != nil {
+		return err
+	}
+	// write next to the file and rename so a failed write keeps the old one
+	tmp := s.File + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.File)
+}
+
+func (s *Store) fileGet(service string) (string, bool, error) {
+	names, err := fileServices(s.File)
+	if err != nil || !slices.Contains(names, service) {
+		return "", false, err
+	}
+	tokens, err := s.fileLoad(false)
+	if err != nil {
+		return "", false, err
+	}
+	t, ok := tokens[service]
+	return t, ok, nil
+}
+
+func (s *Store) fileSet(service, token string) error {
+	_, statErr := os.Stat(s.File)
+	tokens, err := s.fileLoad(errors.Is(statErr, os.ErrNotExist))
+	if err != nil {
+		return err
+	}
+	tokens[service] = token
+	return s.fileSave(tokens)
+}
+
+func (s *Store) fileDelete(service string) (bool, error) {
+	names, err := fileServices(s.File)
+	if err != nil || !slices.Contains(names, service) {
+		return false, err
+	}
+	tokens, err := s.fileLoad(false)
+	if err != nil {
+		return false, err
+	}
+	delete(tokens, service)
+	return true, s.fileSave(tokens)
+}
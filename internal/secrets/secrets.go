@@ -0,0 +1,260 @@
+// Package secrets stores API tokens of integrations (GitHub, Jira, Linear)
+// outside the config file: in the OS keyring when available, otherwise in a
+// passphrase-encrypted file. NOJI_<SERVICE>_TOKEN overrides both.
+package secrets
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Service is an integration whose token noji can store.
+type Service struct {
+	Name        string `json:"name"`
+	Env         string `json:"env"`
+	Description string `json:"description"`
+	// Export is the variable the token is passed in to gh and to the MCP
+	// servers opencode starts.
+	Export string `json:"export"`
+}
+
+// Services lists the known integrations.
+var Services = []Service{
+	{Name: "github", Env: "NOJI_GITHUB_TOKEN", Description: "GitHub personal access token", Export: "GH_TOKEN"},
+	{Name: "jira", Env: "NOJI_JIRA_TOKEN", Description: "Jira API token", Export: "JIRA_API_TOKEN"},
+	{Name: "linear", Env: "NOJI_LINEAR_TOKEN", Description: "Linear API key", Export: "LINEAR_API_KEY"},
+}
+
+// Lookup returns the service called name.
+func Lookup(name string) (Service, error) {
+	i := slices.IndexFunc(Services, func(s Service) bool { return s.Name == name })
+	if i < 0 {
+		names := make([]string, len(Services))
+		for i, s := range Services {
+			names[i] = s.Name
+		}
+		return Service{}, fmt.Errorf("unknown service %q (want one of: %s)", name, strings.Join(names, ", "))
+	}
+	return Services[i], nil
+}
+
+// Where a token is stored.
+const (
+	SourceEnv     = "env"
+	SourceKeyring = "keyring"
+	SourceFile    = "file"
+)
+
+// Backends select where tokens are written (auth.backend).
+const (
+	BackendAuto    = "auto" // keyring, falling back to the file
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+)
+
+// Backends lists the valid auth.backend values.
+var Backends = []string{BackendAuto, BackendKeyring, BackendFile}
+
+// keyringService is the keyring entry name; the user is the service name.
+const keyringService = "noji"
+
+// Store reads and writes tokens.
+type Store struct {
+	// Backend is one of Backends; empty means auto.
+	Backend string
+	// File is the encrypted fallback file.
+	File string
+	// Passphrase returns the passphrase of File; confirm is set when the
+	// file is about to be created.
+	Passphrase func(confirm bool) (string, error)
+	// Warnf receives keyring failures that fell back to the file.
+	Warnf func(format string, a ...any)
+
+	pass string
+	// the last derived key, see cipher
+	gcm       cipher.AEAD
+	gcmPass   string
+	gcmSalt   []byte
+	gcmRounds int
+}
+
+func (s *Store) useKeyring() bool { return s.Backend != BackendFile }
+func (s *Store) useFile() bool    { return s.Backend != BackendKeyring }
+
+func (s *Store) warnf(format string, a ...any) {
+	if s.Warnf != nil {
+		s.Warnf(format, a...)
+	}
+}
+
+// Get returns the token of service and where it came from: the env
+// variable, the keyring, then the file. A missing token is "" without error.
+func (s *Store) Get(service string) (token, source string, err error) {
+	svc, err := Lookup(service)
+	if err != nil {
+		return "", "", err
+	}
+	if v := strings.TrimSpace(os.Getenv(svc.Env)); v != "" {
+		remember(v)
+		return v, SourceEnv, nil
+	}
+	if s.useKeyring() {
+		v, err := keyring.Get(keyringService, service)
+		switch {
+		case err == nil:
+			remember(v)
+			return v, SourceKeyring, nil
+		case !errors.Is(err, keyring.ErrNotFound) && s.Backend == BackendKeyring:
+			return "", "", fmt.Errorf("read keyring: %w", err)
+		}
+	}
+	if s.useFile() {
+		v, ok, err := s.fileGet(service)
+		if err != nil || !ok {
+			return "", "", err
+		}
+		remember(v)
+		return v, SourceFile, nil
+	}
+	return "", "", nil
+}
+
+// Export sets the Export variable of every service that has a token, so gh,
+// opencode and the tools they start inherit it. Variables that are already
+// set win. It never asks for the passphrase: without NOJI_PASSPHRASE an
+// encrypted file is skipped.
+func (s *Store) Export() error {
+	ask := s.Passphrase
+	s.Passphrase = nil
+	defer func() { s.Passphrase = ask }()
+	var errs []error
+	for _, svc := range Services {
+		if os.Getenv(svc.Export) != "" {
+			continue
+		}
+		token, _, err := s.Get(svc.Name)
+		if errors.Is(err, ErrPassphraseRequired) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s token: %w", svc.Name, err))
+			continue
+		}
+		if token != "" {
+			os.Setenv(svc.Export, token)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Set stores the token of service and returns where it went. With the auto
+// backend a keyring failure falls back to the file.
+func (s *Store) Set(service, token string) (string, error) {
+	if _, err := Lookup(service); err != nil {
+		return "", err
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("empty token")
+	}
+	remember(token)
+	if s.useKeyring() {
+		err := keyring.Set(keyringService, service, token)
+		if err == nil {
+			// drop an older copy so the file cannot shadow a logout later
+			if s.useFile() {
+				if names, _ := fileServices(s.File); slices.Contains(names, service) {
+					if _, err := s.fileDelete(service); err != nil {
+						s.warnf("Could not remove the old %s token from %s: %v\n", service, s.File, err)
+					}
+				}
+			}
+			return SourceKeyring, nil
+		}
+		if !s.useFile() {
+			return "", fmt.Errorf("write keyring: %w", err)
+		}
+		s.warnf("OS keyring unavailable (%v), using the encrypted file %s.\n", err, s.File)
+	}
+	if err := s.fileSet(service, token); err != nil {
+		return "", err
+	}
+	return SourceFile, nil
+}
+
+// Delete removes the token of service from the keyring and the file and
+// returns where it was found.
+func (s *Store) Delete(service string) ([]string, error) {
+	if _, err := Lookup(service); err != nil {
+		return nil, err
+	}
+	var removed []string
+	if s.useKeyring() {
+		err := keyring.Delete(keyringService, service)
+		switch {
+		case err == nil:
+			removed = append(removed, SourceKeyring)
+		case !errors.Is(err, keyring.ErrNotFound) && s.Backend == BackendKeyring:
+			return nil, fmt.Errorf("delete from keyring: %w", err)
+		}
+	}
+	if s.useFile() {
+		ok, err := s.fileDelete(service)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed = append(removed, SourceFile)
+		}
+	}
+	return removed, nil
+}
+
+// Status is where the token of a service is set; Active is the one Get uses.
+type Status struct {
+	Service string `json:"service"`
+	Active  string `json:"active,omitempty"`
+	Env     bool   `json:"env"`
+	Keyring bool   `json:"keyring"`
+	File    bool   `json:"file"`
+	// KeyringError is set when the keyring could not be queried.
+	KeyringError string `json:"keyring_error,omitempty"`
+}
+
+// Status reports where every service has a token without decrypting the file.
+func (s *Store) Status() ([]Status, error) {
+	inFile, err := fileServices(s.File)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(Services))
+	for _, svc := range Services {
+		st := Status{Service: svc.Name, Env: strings.TrimSpace(os.Getenv(svc.Env)) != ""}
+		if s.useKeyring() {
+			_, err := keyring.Get(keyringService, svc.Name)
+			switch {
+			case err == nil:
+				st.Keyring = true
+			case !errors.Is(err, keyring.ErrNotFound):
+				st.KeyringError = err.Error()
+			}
+		}
+		st.File = s.useFile() && slices.Contains(inFile, svc.Name)
+		switch {
+		case st.Env:
+			st.Active = SourceEnv
+		case st.Keyring:
+			st.Active = SourceKeyring
+		case st.File:
+			st.Active = SourceFile
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
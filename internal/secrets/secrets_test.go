@@ -0,0 +1,178 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func init() {
+	kdfRounds = 1000
+}
+
+func fileStore(t *testing.T, pass string) *Store {
+	t.Helper()
+	t.Setenv(PassphraseEnv, "")
+	for _, svc := range Services {
+		t.Setenv(svc.Env, "")
+	}
+	return &Store{
+		Backend:    BackendFile,
+		File:       filepath.Join(t.TempDir(), "credentials.enc"),
+		Passphrase: func(bool) (string, error) { return pass, nil },
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	s := fileStore(t, "correct horse")
+	if _, err := s.Set("github", "gh-token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Set("jira", "jira-token"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(s.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "gh-token") {
+		t.Fatal("the file holds a token in clear text")
+	}
+
+	// a new store must derive the key again from the passphrase
+	fresh := &Store{Backend: BackendFile, File: s.File, Passphrase: s.Passphrase}
+	for service, want := range map[string]string{"github": "gh-token", "jira": "jira-token", "linear": ""} {
+		got, source, err := fresh.Get(service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want || (want != "" && source != SourceFile) {
+			t.Errorf("Get(%s) = %q from %q, want %q from the file", service, got, source, want)
+		}
+	}
+	gcm := fresh.gcm
+	if _, _, err := fresh.Get("jira"); err != nil || fresh.gcm != gcm {
+		t.Errorf("the key was derived again (err %v)", err)
+	}
+	if removed, err := fresh.Delete("github"); err != nil || len(removed) != 1 {
+		t.Errorf("Delete = %v, %v", removed, err)
+	}
+	status, err := fresh.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status[0].File || !status[1].File {
+		t.Errorf("status after delete = %+v", status)
+	}
+}
+
+func TestFileWrongPassphrase(t *testing.T) {
+	s := fileStore(t, "right")
+	if _, err := s.Set("github", "gh-token"); err != nil {
+		t.Fatal(err)
+	}
+	wrong := &Store{Backend: BackendFile, File: s.File, Passphrase: func(bool) (string, error) { return "wrong", nil }}
+	if _, _, err := wrong.Get("github"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Get with a wrong passphrase: %v", err)
+	}
+	none := &Store{Backend: BackendFile, File: s.File}
+	if _, _, err := none.Get("github"); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Get without a passphrase: %v, want ErrPassphraseRequired", err)
+	}
+}
+
+func TestFileCorrupted(t *testing.T) {
+	s := fileStore(t, "pass")
+	if _, err := s.Set("github", "gh-token"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(s.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, edit := range map[string]func(v *vault){
+		"data":     func(v *vault) { v.Data[0] ^= 1 },
+		"services": func(v *vault) { v.Services = append(v.Services, "jira") },
+		"version":  func(v *vault) { v.Version = 2 },
+	} {
+		var v vault
+		if err := json.Unmarshal(b, &v); err != nil {
+			t.Fatal(err)
+		}
+		edit(&v)
+		out, _ := json.Marshal(v)
+		if err := os.WriteFile(s.File, out, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		fresh := &Store{Backend: BackendFile, File: s.File, Passphrase: s.Passphrase}
+		if _, _, err := fresh.Get("github"); err == nil {
+			t.Errorf("%s: reading a damaged file succeeded", name)
+		}
+	}
+	if err := os.WriteFile(s.File, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := (&Store{Backend: BackendFile, File: s.File}).Get("github"); err == nil {
+		t.Error("reading an invalid file succeeded")
+	}
+}
+
+func TestBackendFallback(t *testing.T) {
+	s := fileStore(t, "pass")
+	s.Backend = BackendAuto
+	var warnings []string
+	s.Warnf = func(format string, a ...any) { warnings = append(warnings, format) }
+
+	keyring.MockInit()
+	if where, err := s.Set("github", "kr-token"); err != nil || where != SourceKeyring {
+		t.Fatalf("Set with a keyring = %q, %v", where, err)
+	}
+	if _, err := os.Stat(s.File); !errors.Is(err, os.ErrNotExist) {
+		t.Error("the file was written although the keyring works")
+	}
+
+	keyring.MockInitWithError(errors.New("no keyring"))
+	if where, err := s.Set("jira", "file-token"); err != nil || where != SourceFile {
+		t.Fatalf("Set without a keyring = %q, %v", where, err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %q, want the fallback reported", warnings)
+	}
+	if got, source, err := s.Get("jira"); err != nil || got != "file-token" || source != SourceFile {
+		t.Errorf("Get = %q from %q, %v", got, source, err)
+	}
+
+	s.Backend = BackendKeyring
+	if _, err := s.Set("linear", "x"); err == nil {
+		t.Error("Set with the keyring backend and no keyring succeeded")
+	}
+}
+
+func TestExportSkipsEncryptedFileWithoutPassphrase(t *testing.T) {
+	s := fileStore(t, "pass")
+	if _, err := s.Set("github", "gh-token"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_TOKEN", "")
+	os.Unsetenv("GH_TOKEN")
+	asked := false
+	locked := &Store{Backend: BackendFile, File: s.File, Passphrase: func(bool) (string, error) { asked = true; return "pass", nil }}
+	if err := locked.Export(); err != nil {
+		t.Fatal(err)
+	}
+	if asked || os.Getenv("GH_TOKEN") != "" {
+		t.Errorf("Export asked for the passphrase (%v) or exported %q", asked, os.Getenv("GH_TOKEN"))
+	}
+	t.Setenv(PassphraseEnv, "pass")
+	if err := locked.Export(); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("GH_TOKEN"); got != "gh-token" {
+		t.Errorf("GH_TOKEN = %q, want the stored token", got)
+	}
+}
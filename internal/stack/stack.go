@@ -0,0 +1,226 @@
+// Package stack keeps the local metadata of stacked branches: which branch
+// each one is built on and which PR it belongs to. It is stored as JSON in
+// the repository's git directory so all worktrees share it.
+package stack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the metadata location relative to the git common directory.
+const FileName = "noji/stack.json"
+
+// Branch is one tracked branch of a stack.
+type Branch struct {
+	Parent string `json:"parent"`
+	// Base is the commit of Parent the branch was last (re)based on; it is
+	// the upstream argument of `git rebase --onto` when restacking.
+	Base string `json:"base,omitempty"`
+	PR   int    `json:"pr,omitempty"`
+}
+
+// Stack is the set of tracked branches. Untracked parents are trunks.
+type Stack struct {
+	Branches map[string]*Branch `json:"branches"`
+	path     string
+}
+
+// Load reads the metadata from gitDir, returning an empty stack when none exists.
+func Load(gitDir string) (*Stack, error) {
+	s := &Stack{Branches: map[string]*Branch{}, path: filepath.Join(gitDir, filepath.FromSlash(FileName))}
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	if s.Branches == nil {
+		s.Branches = map[string]*Branch{}
+	}
+	return s, nil
+}
+
+// Save writes the metadata back.
+func (s *Stack) Save() error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(b, '\n'), 0o644)
+}
+
+// Tracked reports whether name is a stacked branch (not a trunk).
+func (s *Stack) Tracked(name string) bool {
+	_, ok := s.Branches[name]
+	return ok
+}
+
+// Track records name as a child of parent.
+func (s *Stack) Track(name, parent, base string) error {
+	if name == parent {
+		return fmt.Errorf("branch %s cannot be its own parent", name)
+	}
+	// the walk includes the trunk: a trunk cannot be tracked on its stack
+	for p := parent; ; p = s.Branches[p].Parent {
+		if p == name {
+			return fmt.Errorf("tracking %s on %s would create a cycle", name, parent)
+		}
+		if !s.Tracked(p) {
+			break
+		}
+	}
+	b := s.Branches[name]
+	if b == nil {
+		b = &Branch{}
+		s.Branches[name] = b
+	}
+	b.Parent, b.Base = parent, base
+	return nil
+}
+
+// Remove drops name from the stack; its children move onto its parent.
+// Their Base is kept, so a restack replays only their own commits.
+func (s *Stack) Remove(name string) {
+	b, ok := s.Branches[name]
+	if !ok {
+		return
+	}
+	for _, c := range s.Children(name) {
+		s.Branches[c].Parent = b.Parent
+	}
+	delete(s.Branches, name)
+}
+
+// Children returns the branches directly on top of name, sorted.
+func (s *Stack) Children(name string) []string {
+	var out []string
+	for n, b := range s.Branches {
+		if b.Parent == name {
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Trunk returns the untracked branch at the bottom of name's stack.
+func (s *Stack) Trunk(name string) string {
+	for s.Tracked(name) {
+		name = s.Branches[name].Parent
+	}
+	return name
+}
+
+// Chain returns name's stack bottom-up: its ancestors above the trunk,
+// name itself and all branches built on it (depth first).
+func (s *Stack) Chain(name string) []string {
+	var down []string
+	for n := name; s.Tracked(n); n = s.Branches[n].Parent {
+		down = append(down, n)
+	}
+	chain := make([]string, 0, len(down))
+	for i := len(down) - 1; i >= 0; i-- {
+		chain = append(chain, down[i])
+	}
+	if !s.Tracked(name) {
+		// name is a trunk: everything stacked on it
+		chain = nil
+	}
+	var walk func(string)
+	walk = func(n string) {
+		for _, c := range s.Children(n) {
+			chain = append(chain, c)
+			walk(c)
+		}
+	}
+	walk(name)
+	return chain
+}
+
+// Depth is the number of tracked ancestors of name.
+func (s *Stack) Depth(name string) int {
+	d := 0
+	for n := name; s.Tracked(n) && s.Tracked(s.Branches[n].Parent); n = s.Branches[n].Parent {
+		d++
+	}
+	return d
+}
+
+// Section markers of the navigation block in PR bodies.
+const (
+	navStart = "<!-- noji-stack -->"
+	navEnd   = "<!-- /noji-stack -->"
+)
+
+// NavSection renders the "Stack" navigation block for the PR of current.
+func (s *Stack) NavSection(chain []string, current string) string {
+	var b strings.Builder
+	b.WriteString(navStart + "\n### Stack\n\n")
+	if len(chain) > 0 {
+		fmt.Fprintf(&b, "- `%s`\n", s.Trunk(chain[0]))
+	}
+	for _, n := range chain {
+		indent := strings.Repeat("  ", s.Depth(n)+1)
+		label := "`" + n + "`"
+		if pr := s.Branches[n].PR; pr > 0 {
+			label = fmt.Sprintf("#%d %s", pr, label)
+		}
+		if n == current {
+			label = "**" + label + "** ← this PR"
+		}
+		fmt.Fprintf(&b, "%s- %s\n", indent, label)
+	}
+	b.WriteString(navEnd)
+	return b.String()
+}
+
+// navBlock returns the start and end offsets of the navigation block in
+// body. Markers without their counterpart, e.g. left by an edit, are not
+// part of it.
+func navBlock(body string) (int, int, bool) {
+	for off := 0; ; {
+		j := strings.Index(body[off:], navEnd)
+		if j < 0 {
+			return 0, 0, false
+		}
+		j += off
+		if i := strings.LastIndex(body[off:j], navStart); i >= 0 {
+			return off + i, j + len(navEnd), true
+		}
+		off = j + len(navEnd)
+	}
+}
+
+// ReplaceNav puts section into body, replacing an existing block or
+// appending one.
+func ReplaceNav(body, section string) string {
+	if i, j, ok := navBlock(body); ok {
+		return body[:i] + section + body[j:]
+	}
+	body = strings.TrimRight(body, "\n")
+	if body == "" {
+		return section + "\n"
+	}
+	return body + "\n\n" + section + "\n"
+}
+
+// StripNav removes the navigation block from body.
+func StripNav(body string) string {
+	if i, j, ok := navBlock(body); ok {
+		return body[:i] + body[j:]
+	}
+	return body
+}
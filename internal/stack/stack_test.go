@@ -0,0 +1,177 @@
+package stack
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// build returns a stack of the given branch → parent pairs.
+func build(parents map[string]string) *Stack {
+	s := &Stack{Branches: map[string]*Branch{}}
+	for name, parent := range parents {
+		s.Branches[name] = &Branch{Parent: parent, Base: parent + "-base"}
+	}
+	return s
+}
+
+// main ← a ← b ← c, b ← d, main ← x
+var tree = map[string]string{"a": "main", "b": "a", "c": "b", "d": "b", "x": "main"}
+
+func TestTrack(t *testing.T) {
+	for _, tc := range []struct {
+		name, parent string
+		err          string
+	}{
+		{"e", "c", ""},
+		{"c", "x", ""},
+		{"a", "develop", ""},
+		{"a", "a", "its own parent"},
+		{"a", "c", "cycle"},
+		{"b", "d", "cycle"},
+		{"main", "a", "cycle"},
+	} {
+		s := build(tree)
+		err := s.Track(tc.name, tc.parent, "base")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Track(%s, %s) = %v, want %q", tc.name, tc.parent, err, tc.err)
+			}
+			if tc.name != "main" && s.Branches[tc.name].Parent != tree[tc.name] {
+				t.Errorf("Track(%s, %s) changed the stack on error", tc.name, tc.parent)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Track(%s, %s): %v", tc.name, tc.parent, err)
+			continue
+		}
+		if b := s.Branches[tc.name]; b.Parent != tc.parent || b.Base != "base" {
+			t.Errorf("Track(%s, %s) = %+v", tc.name, tc.parent, b)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	for _, tc := range []struct {
+		remove string
+		want   map[string]string
+	}{
+		{"b", map[string]string{"a": "main", "c": "a", "d": "a", "x": "main"}},
+		{"a", map[string]string{"b": "main", "c": "b", "d": "b", "x": "main"}},
+		{"c", map[string]string{"a": "main", "b": "a", "d": "b", "x": "main"}},
+		{"main", tree},
+	} {
+		s := build(tree)
+		s.Remove(tc.remove)
+		got := map[string]string{}
+		for n, b := range s.Branches {
+			got[n] = b.Parent
+			// children keep their base so a restack replays only their commits
+			if b.Base != tree[n]+"-base" {
+				t.Errorf("remove %s: base of %s = %s", tc.remove, n, b.Base)
+			}
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("remove %s: %v, want %v", tc.remove, got, tc.want)
+		}
+		for n, p := range tc.want {
+			if got[n] != p {
+				t.Errorf("remove %s: parent of %s = %s, want %s", tc.remove, n, got[n], p)
+			}
+		}
+	}
+}
+
+func TestChainDepth(t *testing.T) {
+	s := build(tree)
+	for _, tc := range []struct {
+		name  string
+		chain []string
+		depth int
+		trunk string
+	}{
+		{"a", []string{"a", "b", "c", "d"}, 0, "main"},
+		{"b", []string{"a", "b", "c", "d"}, 1, "main"},
+		{"c", []string{"a", "b", "c"}, 2, "main"},
+		{"d", []string{"a", "b", "d"}, 2, "main"},
+		{"x", []string{"x"}, 0, "main"},
+		{"main", []string{"a", "b", "c", "d", "x"}, 0, "main"},
+		{"other", nil, 0, "other"},
+	} {
+		if got := s.Chain(tc.name); !slices.Equal(got, tc.chain) {
+			t.Errorf("Chain(%s) = %q, want %q", tc.name, got, tc.chain)
+		}
+		if got := s.Depth(tc.name); got != tc.depth {
+			t.Errorf("Depth(%s) = %d, want %d", tc.name, got, tc.depth)
+		}
+		if got := s.Trunk(tc.name); got != tc.trunk {
+			t.Errorf("Trunk(%s) = %s, want %s", tc.name, got, tc.trunk)
+		}
+	}
+}
+
+func TestNavSection(t *testing.T) {
+	s := build(tree)
+	s.Branches["b"].PR = 12
+	want := navStart + "\n### Stack\n\n- `main`\n  - `a`\n    - **#12 `b`** ← this PR\n      - `c`\n" + navEnd
+	if got := s.NavSection(s.Chain("c"), "b"); got != want {
+		t.Errorf("NavSection =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReplaceStripNav(t *testing.T) {
+	section := navStart + "\nnav\n" + navEnd
+	other := navStart + "\nother\n" + navEnd
+	for _, tc := range []struct {
+		body, replaced, stripped string
+	}{
+		{"", section + "\n", ""},
+		{"Body.\n\n", "Body.\n\n" + section + "\n", "Body.\n\n"},
+		{"Body.\n\n" + other + "\n", "Body.\n\n" + section + "\n", "Body.\n\n\n"},
+		{"Top.\n" + other + "\nBottom.", "Top.\n" + section + "\nBottom.", "Top.\n\nBottom."},
+		// markers without their counterpart are left alone
+		{navEnd + "\nBody.\n" + other, navEnd + "\nBody.\n" + section, navEnd + "\nBody.\n"},
+		{"Body.\n" + navStart + "\nrest", "Body.\n" + navStart + "\nrest\n\n" + section + "\n", "Body.\n" + navStart + "\nrest"},
+	} {
+		replaced := ReplaceNav(tc.body, section)
+		if replaced != tc.replaced {
+			t.Errorf("ReplaceNav(%q) = %q, want %q", tc.body, replaced, tc.replaced)
+		}
+		if again := ReplaceNav(replaced, section); again != replaced {
+			t.Errorf("ReplaceNav is not idempotent on %q: %q", tc.body, again)
+		}
+		stripped := StripNav(tc.body)
+		if stripped != tc.stripped {
+			t.Errorf("StripNav(%q) = %q, want %q", tc.body, stripped, tc.stripped)
+		}
+		if again := StripNav(stripped); again != stripped {
+			t.Errorf("StripNav is not idempotent on %q: %q", tc.body, again)
+		}
+		if got := StripNav(replaced); strings.Contains(got, "\nnav\n") {
+			t.Errorf("StripNav(ReplaceNav(%q)) kept the block: %q", tc.body, got)
+		}
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Load(dir)
+	if err != nil || len(s.Branches) != 0 {
+		t.Fatalf("Load of a new repository = %v, %v", s, err)
+	}
+	if err := s.Track("feature", "main", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	s.Branches["feature"].PR = 7
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := s.Branches["feature"]; b == nil || *b != (Branch{Parent: "main", Base: "abc", PR: 7}) {
+		t.Errorf("loaded %+v", b)
+	}
+}
@@ -0,0 +1,78 @@
+package summarize
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dennisloska/noji/internal/diffpack"
+)
+
+// DiffGroups splits the hand-written files of a diff into groups of at most
+// the budget each. A directory whose changes fit is one group; larger ones
+// are split into their subdirectories, and the files directly inside them
+// into parts of the budget. Groups only change when their own files do, so
+// cached summaries of untouched directories stay valid. Generated files and
+// lockfiles are not grouped; they are returned as skipped.
+func DiffGroups(files []diffpack.File, o diffpack.Options) (groups []Group, skipped []diffpack.Entry) {
+	var source []diffpack.File
+	for _, f := range files {
+		if kind := o.Kind(f); kind != diffpack.KindSource {
+			skipped = append(skipped, diffpack.Entry{Path: f.Path, Kind: kind, Shown: diffpack.ShownStat,
+				Added: f.Added, Deleted: f.Deleted, Hunks: len(f.Hunks)})
+			continue
+		}
+		source = append(source, f)
+	}
+	slices.SortFunc(source, func(a, b diffpack.File) int { return strings.Compare(a.Path, b.Path) })
+	return groupDir("", source, o), skipped
+}
+
+func groupDir(dir string, files []diffpack.File, o diffpack.Options) []Group {
+	if len(files) == 0 {
+		return nil
+	}
+	name := strings.TrimSuffix(dir, "/")
+	if name == "" {
+		name = "."
+	}
+	var whole strings.Builder
+	for _, f := range files {
+		whole.WriteString(f.Text())
+	}
+	if o.Budget <= 0 || diffpack.Tokens(whole.String()) <= o.Budget {
+		return []Group{{Name: name + "/", Text: whole.String()}}
+	}
+	var direct []diffpack.File
+	var children []string
+	below := map[string][]diffpack.File{}
+	for _, f := range files {
+		rel := strings.TrimPrefix(f.Path, dir)
+		child, _, nested := strings.Cut(rel, "/")
+		if !nested {
+			direct = append(direct, f)
+			continue
+		}
+		if _, ok := below[child]; !ok {
+			children = append(children, child)
+		}
+		below[child] = append(below[child], f)
+	}
+	var groups []Group
+	if len(children) == 1 && len(direct) == 0 {
+		return groupDir(dir+children[0]+"/", files, o)
+	}
+	parts, _ := diffpack.Split(direct, o)
+	for i, part := range parts {
+		g := Group{Name: name + "/", Text: part}
+		if len(parts) > 1 {
+			g.Name = fmt.Sprintf("%s/ (part %d/%d)", name, i+1, len(parts))
+		}
+		groups = append(groups, g)
+	}
+	for _, child := range children {
+		groups = append(groups, groupDir(path.Join(dir, child)+"/", below[child], o)...)
+	}
+	return groups
+}
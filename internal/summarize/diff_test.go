@@ -0,0 +1,125 @@
+package summarize
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dennisloska/noji/internal/diffpack"
+)
+
+// fileDiff returns the diff of a file adding n lines.
+func fileDiff(path string, n int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1,1 +1,%d @@\n context\n", path, path, path, path, n+1)
+	for i := range n {
+		fmt.Fprintf(&b, "+line %d of %s\n", i, path)
+	}
+	return b.String()
+}
+
+func groupNames(groups []Group) []string {
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+func TestDiffGroups(t *testing.T) {
+	diff := fileDiff("go.sum", 50) + fileDiff("main.go", 2) + fileDiff("api/a.go", 20) + fileDiff("api/b.go", 20) +
+		fileDiff("web/x.ts", 10) + fileDiff("web/gen/y.pb.go", 10)
+	o := diffpack.Options{Budget: diffpack.Tokens(fileDiff("api/a.go", 20)+fileDiff("api/b.go", 20)) + 10}
+	groups, skipped := DiffGroups(diffpack.Parse(diff), o)
+	if want := []string{"./", "api/", "web/"}; !slices.Equal(groupNames(groups), want) {
+		t.Errorf("groups = %q, want %q", groupNames(groups), want)
+	}
+	var paths []string
+	for _, e := range skipped {
+		paths = append(paths, e.Path)
+	}
+	if want := []string{"go.sum", "web/gen/y.pb.go"}; !slices.Equal(paths, want) {
+		t.Errorf("skipped = %q, want %q", paths, want)
+	}
+	for _, g := range groups {
+		if diffpack.Tokens(g.Text) > o.Budget {
+			t.Errorf("%s has %d tokens, over the budget of %d", g.Name, diffpack.Tokens(g.Text), o.Budget)
+		}
+	}
+
+	all, _ := DiffGroups(diffpack.Parse(diff), diffpack.Options{})
+	if len(all) != 1 || all[0].Name != "./" {
+		t.Errorf("unlimited groups = %q", groupNames(all))
+	}
+}
+
+func TestDiffGroupsSplitsLargeDirs(t *testing.T) {
+	diff := fileDiff("lib/a.go", 30) + fileDiff("lib/b.go", 30) + fileDiff("lib/c.go", 30) +
+		fileDiff("lib/sub/d.go", 5) + fileDiff("deep/only/one/e.go", 5)
+	o := diffpack.Options{Budget: diffpack.Tokens(fileDiff("lib/a.go", 30)) + 10}
+	// deep/ fits as a whole; lib/ is split into its files and lib/sub/
+	groups, _ := DiffGroups(diffpack.Parse(diff), o)
+	want := []string{"deep/", "lib/ (part 1/3)", "lib/ (part 2/3)", "lib/ (part 3/3)", "lib/sub/"}
+	if !slices.Equal(groupNames(groups), want) {
+		t.Errorf("groups = %q, want %q", groupNames(groups), want)
+	}
+}
+
+// The groups of directories without changes must not change, or their
+// cached summaries would be missed.
+func TestDiffGroupsStable(t *testing.T) {
+	files := map[string]int{"main.go": 3, "api/a.go": 20, "api/b.go": 20, "web/x.ts": 10, "web/y.ts": 10, "cmd/tool/main.go": 8}
+	build := func(changed string, n int) []Group {
+		var diff strings.Builder
+		paths := make([]string, 0, len(files))
+		for p := range files {
+			paths = append(paths, p)
+		}
+		// the order of the diff must not matter either
+		slices.SortFunc(paths, func(a, b string) int { return strings.Compare(b, a) })
+		for _, p := range paths {
+			lines := files[p]
+			if p == changed {
+				lines = n
+			}
+			diff.WriteString(fileDiff(p, lines))
+		}
+		if changed == "web/z.ts" {
+			diff.WriteString(fileDiff(changed, n))
+		}
+		groups, _ := DiffGroups(diffpack.Parse(diff.String()), diffpack.Options{Budget: 200})
+		return groups
+	}
+	byName := func(groups []Group) map[string]string {
+		m := map[string]string{}
+		for _, g := range groups {
+			m[g.Name] = g.Text
+		}
+		return m
+	}
+	before := byName(build("", 0))
+	for _, tc := range []struct {
+		changed string
+		lines   int
+		dir     string
+	}{
+		{"api/b.go", 25, "api/"},
+		{"web/x.ts", 2, "web/"},
+		{"web/z.ts", 4, "web/"},
+		{"main.go", 6, "./"},
+	} {
+		after := byName(build(tc.changed, tc.lines))
+		for name, text := range before {
+			if dir, _, _ := strings.Cut(name, " "); dir == tc.dir {
+				if after[name] == text {
+					t.Errorf("changing %s left %s unchanged", tc.changed, name)
+				}
+				continue
+			}
+			if after[name] != text {
+				t.Errorf("changing %s changed group %s", tc.changed, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Package summarize condenses input that does not fit into one prompt. Each
+// group (a directory of a diff, the review threads of a file) is summarised
+// on its own, several at a time, and the partial summaries are combined until
+// they fit. Partial summaries are cached by model and content hash, so a
+// re-run only summarises the groups that changed.
+package summarize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dennisloska/noji/internal/diffpack"
+)
+
+// Group is one part of the input, summarised on its own.
+type Group struct {
+	Name string
+	Text string
+}
+
+// Partial is the summary of one group.
+type Partial struct {
+	Name    string
+	Summary string
+	Cached  bool
+}
+
+// promptVersion is part of the cache key; bump it when the instructions or
+// the handling of the answers change, so old summaries are not reused.
+const promptVersion = 1
+
+// Instructions of the map and reduce steps; %s is what is summarised, e.g.
+// "part of a git diff". They are part of the cache key.
+const (
+	mapInstructions = `Summarise the following %s for someone writing a pull request description or ticket comment.
+List what changed and why it matters as short bullet points, naming files, functions and behaviour changes.
+Leave out formatting-only changes. Output only the bullet points.`
+
+	reduceInstructions = `The following are summaries of parts of one %s.
+Combine them into one summary of short bullet points, merging duplicates and keeping the most important changes.
+Output only the bullet points.`
+)
+
+// Cache stores partial summaries as files named by their key.
+type Cache struct {
+	Dir string
+	// MaxAge and MaxBytes bound the cache when it is pruned; 0 is no limit.
+	MaxAge   time.Duration
+	MaxBytes int64
+}
+
+// DefaultCache returns the cache below the user cache directory. It keeps
+// summaries used in the last 30 days, up to 50 MB.
+func DefaultCache() (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: filepath.Join(dir, "noji", "summaries"), MaxAge: 30 * 24 * time.Hour, MaxBytes: 50 << 20}, nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".md")
+}
+
+// Get returns the summary stored under key.
+func (c *Cache) Get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", false
+	}
+	// the modification time is the last use, see Prune
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+	return string(b), true
+}
+
+// Put stores summary under key.
+func (c *Cache) Put(key, summary string) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(key), []byte(summary), 0o644)
+}
+
+// Prune removes summaries not used within MaxAge, then the least recently
+// used ones until the cache is within MaxBytes.
+func (c *Cache) Prune(now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	type file struct {
+		path string
+		used time.Time
+		size int64
+	}
+	var files []file
+	var total int64
+	var errs []error
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || filepath.Ext(e.Name()) != ".md" {
+			continue
+		}
+		f := file{path: filepath.Join(c.Dir, e.Name()), used: info.ModTime(), size: info.Size()}
+		if c.MaxAge > 0 && now.Sub(f.used) > c.MaxAge {
+			errs = append(errs, os.Remove(f.path))
+			continue
+		}
+		files = append(files, f)
+		total += f.size
+	}
+	slices.SortFunc(files, func(a, b file) int { return a.used.Compare(b.used) })
+	for _, f := range files {
+		if c.MaxBytes <= 0 || total <= c.MaxBytes {
+			break
+		}
+		errs = append(errs, os.Remove(f.path))
+		total -= f.size
+	}
+	return errors.Join(errs...)
+}
+
+// Key is the cache key of summarising text with instructions on model.
+func Key(model, instructions, text string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d\x00%s\x00", promptVersion, model)
+	h.Write([]byte(instructions))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Summarizer runs the map and reduce steps.
+type Summarizer struct {
+	// Budget is the token budget of the input of one request.
+	Budget int
+	// Parallel is the number of requests run at once; 0 or less means one.
+	Parallel int
+	// Cache keeps partial summaries between runs; nil disables caching.
+	Cache *Cache
+	// Model returns the model the next request runs on, which is part of
+	// the cache key; nil leaves it out.
+	Model func() string
+	// Run sends a prompt to the model and returns its answer. It is called
+	// from several goroutines when Parallel is above one.
+	Run func(prompt string) (string, error)
+}
+
+// Map summarises every group. Groups that fail are reported in the error;
+// the summaries of the others are still returned.
+func (s *Summarizer) Map(what string, groups []Group) ([]Partial, error) {
+	return s.each(fmt.Sprintf(mapInstructions, what), groups)
+}
+
+func (s *Summarizer) each(instructions string, groups []Group) ([]Partial, error) {
+	partials := make([]Partial, len(groups))
+	errs := make([]error, len(groups))
+	sem := make(chan struct{}, max(s.Parallel, 1))
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		partials[i].Name = g.Name
+		if summary, ok := s.Cache.Get(s.key(instructions, g.Text)); ok {
+			partials[i].Summary, partials[i].Cached = summary, true
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			out, err := s.Run(instructions + "\n\n" + g.Text)
+			if err != nil {
+				errs[i] = fmt.Errorf("summarise %s: %w", g.Name, err)
+				return
+			}
+			partials[i].Summary = strings.TrimSpace(out)
+			// keyed after the run: a failed model may have been replaced
+			if err := s.Cache.Put(s.key(instructions, g.Text), partials[i].Summary); err != nil {
+				errs[i] = fmt.Errorf("cache summary of %s: %w", g.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return partials, errors.Join(errs...)
+}
+
+func (s *Summarizer) key(instructions, text string) string {
+	model := ""
+	if s.Model != nil {
+		model = s.Model()
+	}
+	return Key(model, instructions, text)
+}
+
+// Reduce combines partial summaries until their text fits into the budget
+// and returns it. Summaries are combined in batches that fit, so very many
+// groups take several rounds.
+func (s *Summarizer) Reduce(what string, partials []Partial) (string, error) {
+	instructions := fmt.Sprintf(reduceInstructions, what)
+	for round := 1; ; round++ {
+		text := Join(partials)
+		if s.Budget <= 0 || diffpack.Tokens(text) <= s.Budget || len(partials) < 2 {
+			return text, nil
+		}
+		var batches []Group
+		var b strings.Builder
+		first := ""
+		emit := func(last string) {
+			if b.Len() == 0 {
+				return
+			}
+			name := first
+			if last != first {
+				name += " … " + last
+			}
+			batches = append(batches, Group{Name: name, Text: b.String()})
+			b.Reset()
+		}
+		last := ""
+		for _, p := range partials {
+			section := section(p)
+			if b.Len() > 0 && diffpack.Tokens(b.String()+section) > s.Budget {
+				emit(last)
+			}
+			if b.Len() == 0 {
+				first = p.Name
+			}
+			b.WriteString(section)
+			last = p.Name
+		}
+		emit(last)
+		if len(batches) >= len(partials) {
+			// every summary alone is over the budget; combining cannot help
+			return text, nil
+		}
+		var err error
+		if partials, err = s.each(instructions, batches); err != nil {
+			return "", fmt.Errorf("combine summaries (round %d): %w", round, err)
+		}
+	}
+}
+
+func section(p Partial) string {
+	return "### " + p.Name + "\n" + p.Summary + "\n\n"
+}
+
+// Join formats partial summaries as one section per group.
+func Join(partials []Partial) string {
+	var b strings.Builder
+	for _, p := range partials {
+		if p.Summary != "" {
+			b.WriteString(section(p))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
@@ -0,0 +1,162 @@
+package summarize
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dennisloska/noji/internal/diffpack"
+)
+
+func TestKeyDependsOnModel(t *testing.T) {
+	if Key("a", "i", "t") == Key("b", "i", "t") {
+		t.Error("the same key for two models")
+	}
+	if Key("a", "i", "t") != Key("a", "i", "t") {
+		t.Error("the key is not stable")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	c := &Cache{Dir: t.TempDir(), MaxAge: 24 * time.Hour, MaxBytes: 10}
+	for name, age := range map[string]time.Duration{"old": 48 * time.Hour, "older": 3 * time.Hour, "newer": time.Hour} {
+		path := filepath.Join(c.Dir, name+".md")
+		if err := os.WriteFile(path, []byte("123456"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Prune(now); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"old": false, "older": false, "newer": true} {
+		if _, ok := c.Get(name); ok != want {
+			t.Errorf("%s kept = %v, want %v", name, ok, want)
+		}
+	}
+	if err := (&Cache{Dir: filepath.Join(c.Dir, "missing")}).Prune(now); err != nil {
+		t.Errorf("missing dir: %v", err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	var calls atomic.Int32
+	var running, most atomic.Int32
+	s := &Summarizer{
+		Parallel: 2,
+		Cache:    &Cache{Dir: t.TempDir()},
+		Model:    func() string { return "m" },
+		Run: func(prompt string) (string, error) {
+			calls.Add(1)
+			n := running.Add(1)
+			defer running.Add(-1)
+			if n > most.Load() {
+				most.Store(n)
+			}
+			time.Sleep(5 * time.Millisecond)
+			if strings.Contains(prompt, "broken") {
+				return "", errors.New("model failed")
+			}
+			_, text, _ := strings.Cut(prompt, "\n\n")
+			return "  summary of " + text + "\n", nil
+		},
+	}
+	groups := []Group{{"a/", "alpha"}, {"b/", "beta"}, {"c/", "gamma"}, {"d/", "delta"}}
+	partials, err := s.Map("diff", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range partials {
+		if p.Name != groups[i].Name || p.Summary != "summary of "+groups[i].Text || p.Cached {
+			t.Errorf("partial %d = %+v", i, p)
+		}
+	}
+	if most.Load() > 2 {
+		t.Errorf("%d requests at once, want at most 2", most.Load())
+	}
+
+	// a re-run only summarises what changed
+	calls.Store(0)
+	groups[1].Text = "beta two"
+	partials, err = s.Map("diff", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 || partials[1].Cached || !partials[0].Cached || partials[0].Summary != "summary of alpha" {
+		t.Errorf("%d calls, partials %+v", calls.Load(), partials)
+	}
+	s.Model = func() string { return "other" }
+	calls.Store(0)
+	if _, err := s.Map("diff", groups); err != nil || calls.Load() != 4 {
+		t.Errorf("another model: %d calls, %v", calls.Load(), err)
+	}
+
+	partials, err = s.Map("diff", append(groups, Group{"e/", "broken"}))
+	if err == nil || !strings.Contains(err.Error(), "summarise e/") {
+		t.Errorf("err = %v", err)
+	}
+	if partials[0].Summary == "" || partials[4].Summary != "" {
+		t.Errorf("partials = %+v", partials)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	var mu sync.Mutex
+	var batches []string
+	s := &Summarizer{Budget: 60}
+	s.Run = func(prompt string) (string, error) {
+		_, text, _ := strings.Cut(prompt, "\n\n")
+		if diffpack.Tokens(text) > s.Budget {
+			t.Errorf("batch of %d tokens over the budget of %d", diffpack.Tokens(text), s.Budget)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, text)
+		return fmt.Sprintf("- %d combined", strings.Count(text, "### ")), nil
+	}
+	var partials []Partial
+	for i := range 60 {
+		partials = append(partials, Partial{Name: fmt.Sprintf("dir%02d/", i), Summary: "- a change worth a line"})
+	}
+	got, err := s.Reduce("diff", partials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffpack.Tokens(got) > s.Budget {
+		t.Errorf("result of %d tokens over the budget:\n%s", diffpack.Tokens(got), got)
+	}
+	// ten batches of six summaries, then their summaries in two
+	if len(batches) != 12 {
+		t.Errorf("%d batches, want 12", len(batches))
+	}
+	if !strings.HasPrefix(got, "### dir00/ … dir") || !strings.Contains(got, "… dir59/\n") {
+		t.Errorf("result %q does not name the first and last group", got)
+	}
+
+	// summaries that already fit are joined as they are
+	batches = nil
+	short := partials[:2]
+	if got, err := s.Reduce("diff", short); err != nil || got != Join(short) || len(batches) != 0 {
+		t.Errorf("Reduce of short = %q, %v, %d batches", got, err, len(batches))
+	}
+
+	// summaries each over the budget cannot be combined
+	long := []Partial{{Name: "a/", Summary: strings.Repeat("x", 300)}, {Name: "b/", Summary: strings.Repeat("y", 300)}}
+	if got, err := s.Reduce("diff", long); err != nil || got != Join(long) || len(batches) != 0 {
+		t.Errorf("Reduce of long = %d tokens, %v, %d batches", diffpack.Tokens(got), err, len(batches))
+	}
+
+	s.Run = func(string) (string, error) { return "", errors.New("model failed") }
+	if _, err := s.Reduce("diff", partials); err == nil || !strings.Contains(err.Error(), "round 1") {
+		t.Errorf("err = %v", err)
+	}
+}
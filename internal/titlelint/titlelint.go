@@ -0,0 +1,222 @@
+// Package titlelint checks PR titles against conventional-commit rules
+// (`type(scope): subject`) or a custom regular expression, and proposes a
+// corrected title when a wrong one can be repaired mechanically.
+package titlelint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Scope rules.
+const (
+	ScopeTicket   = "ticket"   // scope must be the ticket key, e.g. feat(FOO-12): ...
+	ScopeRequired = "required" // any non-empty scope
+	ScopeOptional = "optional"
+	ScopeNone     = "none" // no scope allowed
+)
+
+// Scopes lists the accepted scope rules.
+var Scopes = []string{ScopeTicket, ScopeRequired, ScopeOptional, ScopeNone}
+
+// Rules configure the validator.
+type Rules struct {
+	// Pattern, when set, replaces the type and scope checks: the title must
+	// match this regular expression.
+	Pattern       string
+	Types         []string
+	Scope         string
+	TicketPattern string
+	MaxLength     int // 0 disables the check
+}
+
+// DefaultRules mirror the title format the PR prompts ask for.
+func DefaultRules() Rules {
+	return Rules{
+		Types:         []string{"feat", "fix", "chore", "docs", "refactor", "test", "perf", "build", "ci", "style", "revert"},
+		Scope:         ScopeTicket,
+		TicketPattern: `[A-Z][A-Z0-9]+-[0-9]+`,
+		MaxLength:     72,
+	}
+}
+
+// Validate reports invalid regular expressions or unknown scope rules.
+func (r Rules) Validate() error {
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid title pattern: %w", err)
+		}
+	}
+	if _, err := regexp.Compile(r.TicketPattern); err != nil {
+		return fmt.Errorf("invalid ticket pattern: %w", err)
+	}
+	if r.Scope != "" && !slices.Contains(Scopes, r.Scope) {
+		return fmt.Errorf("invalid scope rule %q (want one of: %s)", r.Scope, strings.Join(Scopes, ", "))
+	}
+	return nil
+}
+
+var (
+	conventionalRe = regexp.MustCompile(`^([a-z]+)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+	// looseRe accepts the usual near misses: capitalised types, missing
+	// space after the colon, a dash instead of a colon.
+	looseRe = regexp.MustCompile(`^(?i)([a-z]+)\s*(?:\(([^()]*)\))?\s*(!)?\s*(?::|\s-\s)\s*(.*)$`)
+)
+
+// typeAliases maps common misspellings to conventional types.
+var typeAliases = map[string]string{
+	"feature":     "feat",
+	"features":    "feat",
+	"bug":         "fix",
+	"bugfix":      "fix",
+	"hotfix":      "fix",
+	"doc":         "docs",
+	"tests":       "test",
+	"refactoring": "refactor",
+}
+
+// Check returns the problems found in title; none means it is valid.
+// ticket is the expected ticket key (usually from the branch name), or "".
+func (r Rules) Check(title, ticket string) []string {
+	var problems []string
+	if strings.TrimSpace(title) == "" {
+		return []string{"title is empty"}
+	}
+	if r.MaxLength > 0 && len([]rune(title)) > r.MaxLength {
+		problems = append(problems, fmt.Sprintf("title is %d characters long (max %d)", len([]rune(title)), r.MaxLength))
+	}
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return append(problems, err.Error())
+		}
+		if !re.MatchString(title) {
+			problems = append(problems, fmt.Sprintf("title does not match %s", r.Pattern))
+		}
+		return problems
+	}
+
+	m := conventionalRe.FindStringSubmatch(title)
+	if m == nil {
+		return append(problems, "title is not in the form type(scope): subject")
+	}
+	typ, scope, subject := m[1], m[2], m[4]
+	if len(r.Types) > 0 && !slices.Contains(r.Types, typ) {
+		problems = append(problems, fmt.Sprintf("unknown type %q (allowed: %s)", typ, strings.Join(r.Types, ", ")))
+	}
+	switch r.Scope {
+	case ScopeTicket:
+		switch {
+		case scope == "":
+			problems = append(problems, "scope must be the ticket key")
+		case ticket != "" && scope != ticket:
+			problems = append(problems, fmt.Sprintf("scope %q should be the ticket %s", scope, ticket))
+		case ticket == "" && !r.isTicket(scope):
+			problems = append(problems, fmt.Sprintf("scope %q is not a ticket key", scope))
+		}
+	case ScopeRequired:
+		if strings.TrimSpace(scope) == "" {
+			problems = append(problems, "scope is required")
+		}
+	case ScopeNone:
+		if scope != "" {
+			problems = append(problems, "scope is not allowed")
+		}
+	}
+	if strings.HasSuffix(subject, ".") {
+		problems = append(problems, "subject ends with a period")
+	}
+	return problems
+}
+
+// Fix returns a corrected title, or "" when title cannot be repaired.
+func (r Rules) Fix(title, ticket string) string {
+	s := strings.Join(strings.Fields(title), " ")
+	typ, scope, bang, subject := "", "", "", s
+	if m := looseRe.FindStringSubmatch(s); m != nil {
+		if typ = r.normalizeType(m[1]); typ == "" {
+			// an unknown type such as "wip:"; guessing would hide it
+			return ""
+		}
+		scope, bang, subject = strings.TrimSpace(m[2]), m[3], m[4]
+	}
+	// A leading ticket key ("FOO-12: add x", "[FOO-12] add x") is a scope.
+	if key := r.ticketPrefix(subject); key != "" {
+		if scope == "" {
+			scope = strings.Trim(key, "[]: -")
+		}
+		subject = strings.TrimLeft(subject[len(key):], " :-")
+	}
+	if ticket == "" && r.isTicket(strings.ToUpper(scope)) {
+		ticket = strings.ToUpper(scope)
+	}
+	if typ == "" {
+		if len(r.Types) == 0 {
+			return ""
+		}
+		typ = r.Types[0]
+	}
+	switch r.Scope {
+	case ScopeTicket:
+		if ticket == "" {
+			return ""
+		}
+		scope = ticket
+	case ScopeRequired:
+		if scope == "" {
+			scope = ticket
+		}
+	case ScopeNone:
+		scope = ""
+	}
+	subject = strings.TrimRight(strings.TrimSpace(subject), ".")
+	if subject == "" {
+		return ""
+	}
+	fixed := typ
+	if scope != "" {
+		fixed += "(" + scope + ")"
+	}
+	fixed += bang + ": " + subject
+	if fixed == title || len(r.Check(fixed, ticket)) > 0 {
+		return ""
+	}
+	return fixed
+}
+
+// TicketFrom returns the first ticket key in s (e.g. a branch name such as
+// feature/foo-12-login), upper-cased, or "".
+func (r Rules) TicketFrom(s string) string {
+	re, err := regexp.Compile("(?i)" + r.TicketPattern)
+	if err != nil {
+		return ""
+	}
+	return strings.ToUpper(re.FindString(s))
+}
+
+func (r Rules) isTicket(s string) bool {
+	re, err := regexp.Compile("^(?:" + r.TicketPattern + ")$")
+	return err == nil && re.MatchString(s)
+}
+
+// ticketPrefix returns the leading "[KEY]" / "KEY:" / "KEY " of s, or "".
+func (r Rules) ticketPrefix(s string) string {
+	re, err := regexp.Compile(`(?i)^\[?(?:` + r.TicketPattern + `)\]?[: -]*`)
+	if err != nil {
+		return ""
+	}
+	return re.FindString(s)
+}
+
+func (r Rules) normalizeType(t string) string {
+	t = strings.ToLower(t)
+	if a, ok := typeAliases[t]; ok {
+		t = a
+	}
+	if len(r.Types) > 0 && !slices.Contains(r.Types, t) {
+		return ""
+	}
+	return t
+}
@@ -0,0 +1,138 @@
+package titlelint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	rules := DefaultRules()
+	for _, tc := range []struct {
+		title, ticket string
+		problem       string // a substring of the only problem; "" for valid
+	}{
+		{"feat(FOO-12): add login", "FOO-12", ""},
+		{"feat(FOO-12)!: drop v1", "", ""},
+		{"", "", "empty"},
+		{"add login", "", "not in the form"},
+		{"wip(FOO-12): add login", "FOO-12", "unknown type"},
+		{"feat: add login", "FOO-12", "must be the ticket key"},
+		{"feat(BAR-1): add login", "FOO-12", "should be the ticket FOO-12"},
+		{"feat(auth): add login", "", "not a ticket key"},
+		{"feat(FOO-12): add login.", "FOO-12", "ends with a period"},
+		{"feat(FOO-12): " + strings.Repeat("x", 70), "FOO-12", "max 72"},
+	} {
+		got := rules.Check(tc.title, tc.ticket)
+		switch {
+		case tc.problem == "" && len(got) > 0:
+			t.Errorf("Check(%q) = %q, want valid", tc.title, got)
+		case tc.problem != "" && (len(got) != 1 || !strings.Contains(got[0], tc.problem)):
+			t.Errorf("Check(%q) = %q, want one problem with %q", tc.title, got, tc.problem)
+		}
+	}
+}
+
+func TestCheckScopes(t *testing.T) {
+	for _, tc := range []struct {
+		scope, title string
+		valid        bool
+	}{
+		{ScopeRequired, "feat(api): x", true},
+		{ScopeRequired, "feat: x", false},
+		{ScopeOptional, "feat: x", true},
+		{ScopeNone, "feat: x", true},
+		{ScopeNone, "feat(api): x", false},
+	} {
+		rules := DefaultRules()
+		rules.Scope = tc.scope
+		if got := len(rules.Check(tc.title, "")) == 0; got != tc.valid {
+			t.Errorf("%s: Check(%q) valid = %v, want %v", tc.scope, tc.title, got, tc.valid)
+		}
+	}
+}
+
+func TestCheckPattern(t *testing.T) {
+	rules := Rules{Pattern: `^\[[A-Z]+-[0-9]+\] .+`, MaxLength: 20}
+	for title, want := range map[string]int{
+		"[FOO-1] add x":               0,
+		"feat(FOO-1): add x":          1,
+		"[FOO-1] a much longer title": 1,
+		"no":                          1,
+	} {
+		if got := rules.Check(title, ""); len(got) != want {
+			t.Errorf("Check(%q) = %q, want %d problems", title, got, want)
+		}
+	}
+	if problems := (Rules{Pattern: "("}).Check("x", ""); len(problems) != 1 {
+		t.Errorf("an invalid pattern gave %q", problems)
+	}
+}
+
+func TestFix(t *testing.T) {
+	rules := DefaultRules()
+	for _, tc := range []struct {
+		title, ticket, want string
+	}{
+		{"Feat(FOO-12): add login", "FOO-12", "feat(FOO-12): add login"},
+		{"feat(FOO-12):add login", "FOO-12", "feat(FOO-12): add login"},
+		{"feature - add login.", "FOO-12", "feat(FOO-12): add login"},
+		{"bugfix: crash", "FOO-12", "fix(FOO-12): crash"},
+		{"FOO-12: add login", "", "feat(FOO-12): add login"},
+		{"[foo-12] add login", "", "feat(FOO-12): add login"},
+		{"fix(foo-12)!: drop v1", "", "fix(FOO-12)!: drop v1"},
+		{"feat(BAR-1): add login", "FOO-12", "feat(FOO-12): add login"},
+		{"add login", "", ""},            // no ticket to use as the scope
+		{"wip: add login", "FOO-12", ""}, // unknown types are not guessed
+		{"feat(FOO-12): add login", "FOO-12", ""},
+		{"feat(FOO-12): ", "FOO-12", ""},
+	} {
+		if got := rules.Fix(tc.title, tc.ticket); got != tc.want {
+			t.Errorf("Fix(%q, %q) = %q, want %q", tc.title, tc.ticket, got, tc.want)
+		}
+	}
+
+	optional := DefaultRules()
+	optional.Scope = ScopeNone
+	if got := optional.Fix("Docs(readme): typo.", ""); got != "docs: typo" {
+		t.Errorf("scope none: Fix = %q", got)
+	}
+	long := DefaultRules()
+	long.MaxLength = 20
+	if got := long.Fix("feature: "+strings.Repeat("x", 30), "FOO-1"); got != "" {
+		t.Errorf("a fix over MaxLength = %q, want none", got)
+	}
+}
+
+func TestTicketHelpers(t *testing.T) {
+	rules := DefaultRules()
+	for s, want := range map[string]string{
+		"feature/foo-12-login": "FOO-12",
+		"main":                 "",
+	} {
+		if got := rules.TicketFrom(s); got != want {
+			t.Errorf("TicketFrom(%q) = %q, want %q", s, got, want)
+		}
+	}
+	for s, want := range map[string]string{
+		"[FOO-12] add": "[FOO-12] ",
+		"FOO-12: add":  "FOO-12: ",
+		"foo-12 - add": "foo-12 - ",
+		"add FOO-12":   "",
+		"FOO-12add":    "FOO-12",
+	} {
+		if got := rules.ticketPrefix(s); got != want {
+			t.Errorf("ticketPrefix(%q) = %q, want %q", s, got, want)
+		}
+	}
+	for typ, want := range map[string]string{
+		"FEAT":        "feat",
+		"features":    "feat",
+		"hotfix":      "fix",
+		"refactoring": "refactor",
+		"wip":         "",
+	} {
+		if got := rules.normalizeType(typ); got != want {
+			t.Errorf("normalizeType(%q) = %q, want %q", typ, got, want)
+		}
+	}
+}